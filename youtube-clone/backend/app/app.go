@@ -2,45 +2,201 @@ package app
 
 import (
 	"context"
+	"io"
+	"sync/atomic"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
 	"github.com/joshua468/youtube-clone/backend/repository"
+	"github.com/joshua468/youtube-clone/backend/search"
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/transcode"
 	"github.com/joshua468/youtube-clone/backend/utils/helpers"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
 )
 
 // App represents the core application struct
 type App struct {
-	env             models.Env
-	logger          zerolog.Logger
-	userRepository  repository.UserRepository
-	videoRepository repository.VideoRepository
+	env                    models.Env
+	logger                 zerolog.Logger
+	userRepository         repository.UserRepo
+	videoRepository        repository.VideoRepo
+	uploadRepository       repository.UploadRepo
+	jobRepository          repository.JobRepo
+	renditionRepository    repository.RenditionRepo
+	thumbnailRepository    repository.ThumbnailRepo
+	channelRepository      repository.ChannelRepo
+	subscriptionRepository repository.SubscriptionRepo
+	commentRepository      repository.CommentRepo
+	ratingRepository       repository.RatingRepo
+	watchHistoryRepository repository.WatchHistoryRepo
+	playlistRepository     repository.PlaylistRepo
+	searchQueryRepository  repository.SearchQueryRepo
+	refreshTokenRepository repository.RefreshTokenRepo
+	revocationRepository   repository.RevocationRepo
+	sessionRepository      repository.SessionRepo
+	blobStore              storage.BlobStore
+	searchIndex            search.Index
+	transcoder             transcode.Transcoder
+	segmenter              transcode.Segmenter
+	thumbnailer            transcode.Thumbnailer
+	prober                 transcode.Prober
+	storyboarder           transcode.Storyboarder
+	uploadLocks            uploadLocks
+	views                  *viewCounter
+	queries                *queryCounter
+	suggester              atomic.Pointer[search.Suggester]
+	videoSuggestions       videoSuggestions
+	revocations            *revocationCache
+	sessions               *sessionTracker
 }
 
 // Operations defines the operations supported by the App
 type Operations interface {
 	GetUserByID(ctx *gin.Context, userID uuid.UUID) (*models.User, error)
-	CreateUser(ctx *gin.Context, userRequest models.CreateUserRequest) (*models.User, error)
+	CreateUser(ctx *gin.Context, userRequest models.SignupRequest) (*models.User, error)
+	UserExistsByEmail(ctx context.Context, email string) (bool, error)
+	GetUsers(ctx context.Context, page helpers.Page) ([]*models.User, helpers.PageInfo, error)
+	SearchUsers(ctx context.Context, query string, page helpers.Page) ([]*models.User, helpers.PageInfo, error)
 	Login(ctx *gin.Context, loginReq models.LoginRequest) (*models.User, error)
 	CreateVideo(ctx context.Context, video models.Video) (*models.Video, error)
-	GetVideos(ctx context.Context, page helpers.Page) ([]*models.Video, helpers.PageInfo, error)
-	GetUserVideos(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	GetVideoByID(ctx context.Context, videoID, viewerID uuid.UUID) (*models.Video, error)
+	UpdateVideo(ctx context.Context, videoID uuid.UUID, req models.UpdateVideoRequest) (*models.Video, error)
+	UploadVideo(ctx context.Context, video models.Video, file io.Reader, fileName, contentType string) (*models.Video, error)
+	CreateUpload(ctx context.Context, upload models.Upload) (*models.Upload, error)
+	GetUpload(ctx context.Context, uploadID, userID uuid.UUID) (*models.Upload, error)
+	WriteUploadChunk(ctx context.Context, uploadID, userID uuid.UUID, offset, length int64, chunk io.Reader) (*models.Upload, error)
+	TerminateUpload(ctx context.Context, uploadID, userID uuid.UUID) error
+	ExpireUploads(ctx context.Context, now time.Time) (int, error)
+	TranscodeVideo(ctx context.Context, job *models.Job) error
+	PackageVideo(ctx context.Context, job *models.Job) error
+	GetHLSFile(ctx context.Context, videoID uuid.UUID, name string) (io.ReadSeekCloser, *storage.Object, error)
+	GetDASHManifest(ctx context.Context, videoID uuid.UUID) ([]byte, *models.Video, error)
+	OpenVideoStream(ctx context.Context, videoID, viewerID uuid.UUID, renditionName string) (*VideoStream, error)
+	ExtractThumbnails(ctx context.Context, job *models.Job) error
+	SelectThumbnail(ctx context.Context, videoID, userID, thumbnailID uuid.UUID) (*models.Thumbnail, error)
+	UploadThumbnail(ctx context.Context, videoID, userID uuid.UUID, file io.Reader) (*models.Thumbnail, error)
+	GetThumbnail(ctx context.Context, videoID, thumbnailID, viewerID uuid.UUID) (io.ReadSeekCloser, *storage.Object, *models.Thumbnail, error)
+	GenerateStoryboard(ctx context.Context, job *models.Job) error
+	GetStoryboardFile(ctx context.Context, videoID uuid.UUID, name string) (io.ReadSeekCloser, *storage.Object, error)
+	CreatePlaybackURL(ctx context.Context, videoID, viewerID uuid.UUID, bindViewer bool) (*models.PlaybackURL, error)
+	GetVideos(ctx context.Context, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	GetUserVideos(ctx context.Context, userID, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	SetVisibility(ctx context.Context, videoID, userID uuid.UUID, req models.UpdateVisibilityRequest) (*models.Video, error)
+	PublishScheduledVideos(ctx context.Context, now time.Time) (int64, error)
+	CreateChannel(ctx context.Context, userID uuid.UUID, req models.ChannelRequest) (*models.Channel, error)
+	GetChannel(ctx context.Context, ref string, viewerID uuid.UUID) (*models.Channel, error)
+	GetUserChannels(ctx context.Context, userID uuid.UUID) ([]*models.Channel, error)
+	UpdateChannel(ctx context.Context, channelID, userID uuid.UUID, req models.ChannelRequest) (*models.Channel, error)
+	DeleteChannel(ctx context.Context, channelID, userID uuid.UUID) error
+	GetChannelVideos(ctx context.Context, channelID, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	Subscribe(ctx context.Context, channelID, userID uuid.UUID, notifications string) (*models.Subscription, error)
+	Unsubscribe(ctx context.Context, channelID, userID uuid.UUID) error
+	GetSubscriptionFeed(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	CreateComment(ctx context.Context, videoID, userID uuid.UUID, req models.CreateCommentRequest) (*models.Comment, error)
+	GetComments(ctx context.Context, videoID, viewerID uuid.UUID, sort string, page helpers.Page) ([]*models.Comment, helpers.PageInfo, error)
+	GetReplies(ctx context.Context, videoID, commentID, viewerID uuid.UUID, page helpers.Page) ([]*models.Comment, helpers.PageInfo, error)
+	UpdateComment(ctx context.Context, videoID, commentID, userID uuid.UUID, req models.UpdateCommentRequest) (*models.Comment, error)
+	DeleteComment(ctx context.Context, videoID, commentID, userID uuid.UUID) error
+	SetCommentPinned(ctx context.Context, videoID, commentID, userID uuid.UUID, pinned bool) (*models.Comment, error)
+	SetCommentsDisabled(ctx context.Context, videoID, userID uuid.UUID, disabled bool) (*models.Video, error)
+	RateVideo(ctx context.Context, videoID, userID uuid.UUID, value string) (*models.Video, error)
+	ClearVideoRating(ctx context.Context, videoID, userID uuid.UUID) (*models.Video, error)
+	RateComment(ctx context.Context, videoID, commentID, userID uuid.UUID, value string) (*models.Comment, error)
+	ClearCommentRating(ctx context.Context, videoID, commentID, userID uuid.UUID) (*models.Comment, error)
+	RecordView(ctx context.Context, videoID, viewerID uuid.UUID, clientIP string, watchedSeconds float64) (bool, error)
+	FlushViews(ctx context.Context) (int, error)
+	RecordProgress(ctx context.Context, videoID, userID uuid.UUID, position float64) (*models.WatchHistory, error)
+	GetHistory(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.WatchHistory, helpers.PageInfo, error)
+	RemoveFromHistory(ctx context.Context, userID, videoID uuid.UUID) error
+	ClearHistory(ctx context.Context, userID uuid.UUID) error
+	GetHistorySettings(ctx context.Context, userID uuid.UUID) (*models.HistorySettings, error)
+	SetHistoryPaused(ctx context.Context, userID uuid.UUID, paused bool) (*models.HistorySettings, error)
+	CreatePlaylist(ctx context.Context, userID uuid.UUID, req models.CreatePlaylistRequest) (*models.Playlist, error)
+	EnsureWatchLater(ctx context.Context, userID uuid.UUID) (*models.Playlist, error)
+	BackfillWatchLater(ctx context.Context) (int, error)
+	GetPlaylist(ctx context.Context, playlistID, viewerID uuid.UUID) (*models.Playlist, error)
+	GetUserPlaylists(ctx context.Context, userID, viewerID uuid.UUID, page helpers.Page) ([]*models.Playlist, helpers.PageInfo, error)
+	UpdatePlaylist(ctx context.Context, playlistID, userID uuid.UUID, req models.UpdatePlaylistRequest) (*models.Playlist, error)
+	DeletePlaylist(ctx context.Context, playlistID, userID uuid.UUID) error
+	GetPlaylistItems(ctx context.Context, playlistID, viewerID uuid.UUID, page helpers.Page) ([]*models.PlaylistItem, helpers.PageInfo, error)
+	AddPlaylistItem(ctx context.Context, playlistID, userID, videoID uuid.UUID) (*models.PlaylistItem, error)
+	RemovePlaylistItem(ctx context.Context, playlistID, itemID, userID uuid.UUID) error
+	MovePlaylistItem(ctx context.Context, playlistID, itemID, userID uuid.UUID, position int) (*models.PlaylistItem, error)
+	AddPlaylistCollaborator(ctx context.Context, playlistID, userID, collaboratorID uuid.UUID) (*models.Playlist, error)
+	RemovePlaylistCollaborator(ctx context.Context, playlistID, userID, collaboratorID uuid.UUID) error
+	SearchVideos(ctx context.Context, req models.SearchRequest, viewerID uuid.UUID, clientIP string, page helpers.Page) ([]*models.SearchResult, helpers.PageInfo, error)
+	SyncSearchIndex(ctx context.Context) (int, error)
+	RebuildSearchIndex(ctx context.Context) (int, error)
+	SuggestQueries(ctx context.Context, prefix string, limit int) []string
+	FlushSearchQueries(ctx context.Context) (int, error)
+	RefreshSuggestions(ctx context.Context) error
+	SaveRefreshToken(ctx context.Context, token models.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, currentID uuid.UUID, next models.RefreshToken) error
+	IsTokenRevoked(ctx context.Context, tokenID, familyID uuid.UUID, userID string, issuedAt time.Time) bool
+	Logout(ctx context.Context, userID, tokenID, familyID uuid.UUID, expiresAt time.Time) error
+	LogoutEverywhere(ctx context.Context, userID uuid.UUID) error
+	SyncRevocations(ctx context.Context) error
+	StartSession(ctx context.Context, userID, familyID uuid.UUID, userAgent, ip string, expiresAt time.Time) error
+	GetSessions(ctx context.Context, userID, currentFamilyID uuid.UUID) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, sessionID, userID uuid.UUID) error
+	TouchSession(ctx context.Context, familyID uuid.UUID, ip string)
 }
 
 // New creates a new instance of App
-func New(env models.Env, store repository.Store, logger zerolog.Logger) *App {
+func New(env models.Env, store repository.Store, blobStore storage.BlobStore, searchIndex search.Index, logger zerolog.Logger) *App {
 	appLogger := logger.With().Str("package", "app").Logger()
 
-	userRepo := repository.NewUserRepository(store)
-	videoRepo := repository.NewVideoRepository(store)
+	userRepo := repository.NewUser(&store)
+	videoRepo := repository.NewVideo(&store)
+	uploadRepo := repository.NewUpload(&store)
+	jobRepo := repository.NewJob(&store)
+	renditionRepo := repository.NewRendition(&store)
+	thumbnailRepo := repository.NewThumbnail(&store)
+	channelRepo := repository.NewChannel(&store)
+	subscriptionRepo := repository.NewSubscription(&store)
+	commentRepo := repository.NewComment(&store)
+	ratingRepo := repository.NewRating(&store)
+	watchHistoryRepo := repository.NewWatchHistory(&store)
+	playlistRepo := repository.NewPlaylist(&store)
+	searchQueryRepo := repository.NewSearchQuery(&store)
+	refreshTokenRepo := repository.NewRefreshToken(&store)
+	revocationRepo := repository.NewRevocation(&store)
+	sessionRepo := repository.NewSession(&store)
+	ffmpeg := transcode.NewFFmpeg(env.FFmpegPath)
 
 	return &App{
-		env:             env,
-		logger:          appLogger,
-		userRepository:  userRepo,
-		videoRepository: videoRepo,
+		env:                    env,
+		logger:                 appLogger,
+		userRepository:         userRepo,
+		videoRepository:        videoRepo,
+		uploadRepository:       uploadRepo,
+		jobRepository:          jobRepo,
+		renditionRepository:    renditionRepo,
+		thumbnailRepository:    thumbnailRepo,
+		channelRepository:      channelRepo,
+		subscriptionRepository: subscriptionRepo,
+		commentRepository:      commentRepo,
+		ratingRepository:       ratingRepo,
+		watchHistoryRepository: watchHistoryRepo,
+		playlistRepository:     playlistRepo,
+		searchQueryRepository:  searchQueryRepo,
+		refreshTokenRepository: refreshTokenRepo,
+		revocationRepository:   revocationRepo,
+		sessionRepository:      sessionRepo,
+		blobStore:              blobStore,
+		searchIndex:            searchIndex,
+		transcoder:             ffmpeg,
+		segmenter:              ffmpeg,
+		thumbnailer:            ffmpeg,
+		prober:                 transcode.NewFFprobe(env.FFprobePath),
+		storyboarder:           ffmpeg,
+		views:                  newViewCounter(),
+		queries:                newQueryCounter(),
+		revocations:            newRevocationCache(),
+		sessions:               newSessionTracker(),
 	}
 }
@@ -0,0 +1,153 @@
+// channel.go
+
+package app
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// channelHandlePattern keeps handles usable in URLs as /@handle
+var channelHandlePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,30}$`)
+
+// CreateChannel creates a new channel owned by userID
+func (a *App) CreateChannel(ctx context.Context, userID uuid.UUID, req models.ChannelRequest) (*models.Channel, error) {
+	if !channelHandlePattern.MatchString(req.Handle) {
+		return nil, helpers.ErrInvalidChannelHandle
+	}
+
+	channel, err := a.channelRepository.Create(ctx, models.Channel{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Handle:      req.Handle,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		BannerURL:   req.BannerURL,
+		AvatarURL:   req.AvatarURL,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create channel")
+		return nil, err
+	}
+	return channel, nil
+}
+
+// GetChannel looks a channel up by its ID, or by its handle when ref starts with '@',
+// together with the subscription of viewerID to it
+func (a *App) GetChannel(ctx context.Context, ref string, viewerID uuid.UUID) (*models.Channel, error) {
+	var channel *models.Channel
+	var err error
+	if handle, ok := strings.CutPrefix(ref, "@"); ok {
+		channel, err = a.channelRepository.GetByHandle(ctx, handle)
+	} else if channelID, parseErr := uuid.Parse(ref); parseErr == nil {
+		channel, err = a.channelRepository.GetByID(ctx, channelID)
+	} else {
+		err = helpers.ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.withSubscription(ctx, channel, viewerID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get channel subscription")
+		return nil, err
+	}
+	return channel, nil
+}
+
+// GetUserChannels retrieves the channels owned by a user
+func (a *App) GetUserChannels(ctx context.Context, userID uuid.UUID) ([]*models.Channel, error) {
+	channels, err := a.channelRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get user channels")
+		return nil, err
+	}
+	return channels, nil
+}
+
+// UpdateChannel replaces the editable fields of a channel owned by userID
+func (a *App) UpdateChannel(ctx context.Context, channelID, userID uuid.UUID, req models.ChannelRequest) (*models.Channel, error) {
+	if !channelHandlePattern.MatchString(req.Handle) {
+		return nil, helpers.ErrInvalidChannelHandle
+	}
+
+	channel, err := a.getOwnedChannel(ctx, channelID, userID)
+	if err != nil {
+		return nil, err
+	}
+	channel.Handle = req.Handle
+	channel.DisplayName = req.DisplayName
+	channel.Description = req.Description
+	channel.BannerURL = req.BannerURL
+	channel.AvatarURL = req.AvatarURL
+
+	updated, err := a.channelRepository.Update(ctx, *channel)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update channel")
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeleteChannel removes a channel owned by userID, its videos are kept
+func (a *App) DeleteChannel(ctx context.Context, channelID, userID uuid.UUID) error {
+	channel, err := a.getOwnedChannel(ctx, channelID, userID)
+	if err != nil {
+		return err
+	}
+	if err := a.channelRepository.DeleteByID(ctx, channel.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to delete channel")
+		return err
+	}
+	return nil
+}
+
+// GetChannelVideos retrieves the videos of a channel listed for viewerID
+func (a *App) GetChannelVideos(ctx context.Context, channelID, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+	videos, pageInfo, err := a.videoRepository.GetAllVideos(ctx, models.Video{ChannelID: &channelID}, viewerID, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get channel videos")
+		return nil, helpers.PageInfo{}, err
+	}
+	withThumbnails(videos...)
+	return videos, pageInfo, nil
+}
+
+// getOwnedChannel loads a channel and makes sure userID is its owner
+func (a *App) getOwnedChannel(ctx context.Context, channelID, userID uuid.UUID) (*models.Channel, error) {
+	channel, err := a.channelRepository.GetByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.UserID != userID {
+		return nil, helpers.ErrForbidden
+	}
+	return channel, nil
+}
+
+// uploadChannel picks the channel a new video of userID is published under: the requested one,
+// which has to belong to the user, or else their first channel. Users without channels upload without one.
+func (a *App) uploadChannel(ctx context.Context, userID uuid.UUID, channelID *uuid.UUID) (*uuid.UUID, error) {
+	if channelID != nil {
+		channel, err := a.getOwnedChannel(ctx, *channelID, userID)
+		if err != nil {
+			return nil, err
+		}
+		return &channel.ID, nil
+	}
+
+	channels, err := a.channelRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	return &channels[0].ID, nil
+}
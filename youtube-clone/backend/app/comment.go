@@ -0,0 +1,190 @@
+// comment.go
+
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// CreateComment adds a comment by userID to a video. Replies to a reply are attached to its
+// top-level comment, so threads stay one level deep.
+func (a *App) CreateComment(ctx context.Context, videoID, userID uuid.UUID, req models.CreateCommentRequest) (*models.Comment, error) {
+	video, err := a.getCommentableVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := models.Comment{
+		ID:      uuid.New(),
+		VideoID: video.ID,
+		UserID:  userID,
+		Body:    req.Body,
+	}
+	if req.ParentID != nil {
+		parent, err := a.getVideoComment(ctx, video.ID, *req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		comment.ParentID = &parent.ID
+		if parent.ParentID != nil {
+			comment.ParentID = parent.ParentID
+		}
+	}
+
+	newComment, err := a.commentRepository.Create(ctx, comment)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create comment")
+		return nil, err
+	}
+	return newComment, nil
+}
+
+// GetComments lists the top-level comments of a video visible to viewerID, sorted by newest or top
+func (a *App) GetComments(ctx context.Context, videoID, viewerID uuid.UUID, sort string, page helpers.Page) ([]*models.Comment, helpers.PageInfo, error) {
+	video, err := a.getCommentableVideo(ctx, videoID, viewerID)
+	if err != nil {
+		return nil, helpers.PageInfo{}, err
+	}
+
+	comments, pageInfo, err := a.commentRepository.GetTopLevel(ctx, video.ID, sort, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get comments")
+		return nil, helpers.PageInfo{}, err
+	}
+	return comments, pageInfo, nil
+}
+
+// GetReplies lists the replies to a top-level comment of a video visible to viewerID
+func (a *App) GetReplies(ctx context.Context, videoID, commentID, viewerID uuid.UUID, page helpers.Page) ([]*models.Comment, helpers.PageInfo, error) {
+	video, err := a.getCommentableVideo(ctx, videoID, viewerID)
+	if err != nil {
+		return nil, helpers.PageInfo{}, err
+	}
+	comment, err := a.getVideoComment(ctx, video.ID, commentID)
+	if err != nil {
+		return nil, helpers.PageInfo{}, err
+	}
+
+	replies, pageInfo, err := a.commentRepository.GetReplies(ctx, comment.ID, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get comment replies")
+		return nil, helpers.PageInfo{}, err
+	}
+	return replies, pageInfo, nil
+}
+
+// UpdateComment replaces the text of a comment, only its author may edit it
+func (a *App) UpdateComment(ctx context.Context, videoID, commentID, userID uuid.UUID, req models.UpdateCommentRequest) (*models.Comment, error) {
+	video, err := a.getCommentableVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := a.getVideoComment(ctx, video.ID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.UserID != userID {
+		return nil, helpers.ErrForbidden
+	}
+
+	if err := a.commentRepository.UpdateBody(ctx, comment.ID, req.Body); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update comment")
+		return nil, err
+	}
+	comment.Body = req.Body
+	comment.Edited = true
+	return comment, nil
+}
+
+// DeleteComment removes a comment and its replies from a video userID can see the comments of.
+// The author and the owner of the video may delete it.
+func (a *App) DeleteComment(ctx context.Context, videoID, commentID, userID uuid.UUID) error {
+	video, err := a.getCommentableVideo(ctx, videoID, userID)
+	if err != nil {
+		return err
+	}
+	comment, err := a.getVideoComment(ctx, video.ID, commentID)
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID && video.UserID != userID {
+		return helpers.ErrForbidden
+	}
+
+	if err := a.commentRepository.SoftDeleteByID(ctx, comment.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to delete comment")
+		return err
+	}
+	return nil
+}
+
+// SetCommentPinned pins a top-level comment to the top of a video owned by userID, or unpins it
+func (a *App) SetCommentPinned(ctx context.Context, videoID, commentID, userID uuid.UUID, pinned bool) (*models.Comment, error) {
+	video, err := a.getOwnedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := a.getVideoComment(ctx, video.ID, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.ParentID != nil {
+		return nil, helpers.ErrCommentNotTopLevel
+	}
+
+	if err := a.commentRepository.SetPinned(ctx, video.ID, comment.ID, pinned); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to pin comment")
+		return nil, err
+	}
+	comment.Pinned = pinned
+	return comment, nil
+}
+
+// SetCommentsDisabled turns the comments of a video owned by userID off or back on
+func (a *App) SetCommentsDisabled(ctx context.Context, videoID, userID uuid.UUID, disabled bool) (*models.Video, error) {
+	video, err := a.getOwnedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.videoRepository.UpdateCommentsDisabled(ctx, video.ID, disabled); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update comment settings")
+		return nil, err
+	}
+	video.CommentsDisabled = disabled
+	withThumbnails(video)
+	return video, nil
+}
+
+// getCommentableVideo loads a video whose comments viewerID may read and write. Videos the
+// viewer cannot watch are not found; disabled comments stay available to the owner only.
+func (a *App) getCommentableVideo(ctx context.Context, videoID, viewerID uuid.UUID) (*models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWatch(video, viewerID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+	if video.CommentsDisabled && video.UserID != viewerID {
+		return nil, helpers.ErrCommentsDisabled
+	}
+	return video, nil
+}
+
+// getVideoComment loads a comment, making sure it belongs to videoID
+func (a *App) getVideoComment(ctx context.Context, videoID, commentID uuid.UUID) (*models.Comment, error) {
+	comment, err := a.commentRepository.GetByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if comment.VideoID != videoID {
+		return nil, helpers.ErrRecordNotFound
+	}
+	return comment, nil
+}
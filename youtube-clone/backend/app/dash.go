@@ -0,0 +1,36 @@
+// dash.go
+
+package app
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/streaming"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// GetDASHManifest builds the MPD of a ready video from its stored rendition metadata
+func (a *App) GetDASHManifest(ctx context.Context, videoID uuid.UUID) ([]byte, *models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if video.Status != models.VideoStatusReady {
+		return nil, nil, helpers.ErrVideoNotReady
+	}
+
+	renditions, err := a.renditionRepository.GetByVideoID(ctx, video.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, err := streaming.MPD(renditions)
+	if err != nil {
+		a.logger.Error().Err(err).Str("video", video.ID.String()).Msg("Failed to build DASH manifest")
+		return nil, nil, err
+	}
+	return manifest, video, nil
+}
@@ -0,0 +1,149 @@
+// history.go
+
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// RecordProgress is the player heartbeat, it stores how far userID got into a video. Nothing is
+// recorded while the user has paused their history, the returned entry is nil then.
+func (a *App) RecordProgress(ctx context.Context, videoID, userID uuid.UUID, position float64) (*models.WatchHistory, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWatch(video, userID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+
+	settings, err := a.GetHistorySettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Paused {
+		return nil, nil
+	}
+
+	percent := 0.0
+	if video.Duration > 0 {
+		position = min(position, video.Duration)
+		percent = position / video.Duration * 100
+	}
+
+	now := time.Now()
+	entry, err := a.watchHistoryRepository.Save(ctx, models.WatchHistory{
+		ID:             uuid.New(),
+		UserID:         userID,
+		VideoID:        video.ID,
+		Position:       position,
+		PercentWatched: percent,
+		Completed:      percent >= models.WatchCompletedPercent,
+		WatchedAt:      now,
+		UpdatedAt:      now,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to record watch progress")
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetHistory lists the videos userID watched, most recent first
+func (a *App) GetHistory(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.WatchHistory, helpers.PageInfo, error) {
+	entries, pageInfo, err := a.watchHistoryRepository.GetByUserID(ctx, userID, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get watch history")
+		return nil, helpers.PageInfo{}, err
+	}
+
+	videoIDs := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		videoIDs = append(videoIDs, entry.VideoID)
+	}
+	videos, err := a.videoRepository.GetByIDs(ctx, videoIDs)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get watch history videos")
+		return nil, helpers.PageInfo{}, err
+	}
+	withThumbnails(videos...)
+
+	byID := make(map[uuid.UUID]*models.Video, len(videos))
+	for _, video := range videos {
+		byID[video.ID] = video
+	}
+	for _, entry := range entries {
+		entry.Video = byID[entry.VideoID]
+	}
+	return entries, pageInfo, nil
+}
+
+// RemoveFromHistory forgets that userID watched a video
+func (a *App) RemoveFromHistory(ctx context.Context, userID, videoID uuid.UUID) error {
+	if err := a.watchHistoryRepository.DeleteEntry(ctx, userID, videoID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to remove watch history entry")
+		return err
+	}
+	return nil
+}
+
+// ClearHistory forgets every video userID watched
+func (a *App) ClearHistory(ctx context.Context, userID uuid.UUID) error {
+	if err := a.watchHistoryRepository.DeleteByUserID(ctx, userID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to clear watch history")
+		return err
+	}
+	return nil
+}
+
+// GetHistorySettings retrieves the watch history preferences of userID
+func (a *App) GetHistorySettings(ctx context.Context, userID uuid.UUID) (*models.HistorySettings, error) {
+	settings, err := a.watchHistoryRepository.GetSettings(ctx, userID)
+	if err != nil {
+		if errors.Is(err, helpers.ErrRecordNotFound) {
+			return &models.HistorySettings{UserID: userID}, nil
+		}
+		a.logger.Error().Err(err).Msg("Failed to get history settings")
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetHistoryPaused stops or resumes the recording of the watch history of userID
+func (a *App) SetHistoryPaused(ctx context.Context, userID uuid.UUID, paused bool) (*models.HistorySettings, error) {
+	settings, err := a.watchHistoryRepository.SaveSettings(ctx, models.HistorySettings{
+		UserID:    userID,
+		Paused:    paused,
+		UpdatedAt: time.Now(),
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update history settings")
+		return nil, err
+	}
+	return settings, nil
+}
+
+// withResumePosition tells viewerID where they stopped watching video. Finished videos start over.
+func (a *App) withResumePosition(ctx context.Context, video *models.Video, viewerID uuid.UUID) error {
+	if viewerID == uuid.Nil {
+		return nil
+	}
+	entry, err := a.watchHistoryRepository.Get(ctx, viewerID, video.ID)
+	if err != nil {
+		if errors.Is(err, helpers.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !entry.Completed && entry.Position > 0 {
+		video.ResumeAt = &entry.Position
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+// hls.go
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/streaming"
+	"github.com/joshua468/youtube-clone/backend/transcode"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	audioRenditionName = "audio"
+	segmentContentType = "video/iso.segment"
+)
+
+// PackageVideo is the job handler splitting the renditions of a video into HLS segments
+func (a *App) PackageVideo(ctx context.Context, job *models.Job) error {
+	video, err := a.videoRepository.GetByID(ctx, job.VideoID)
+	if err != nil {
+		return err
+	}
+
+	err = a.packageVideo(ctx, video)
+	if err != nil && job.Attempts >= job.MaxAttempts {
+		a.markVideoFailed(ctx, video.ID)
+	}
+	return err
+}
+
+func (a *App) packageVideo(ctx context.Context, video *models.Video) error {
+	renditions, err := a.renditionRepository.GetByVideoID(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+
+	workDir, err := os.MkdirTemp("", "package-"+video.ID.String()+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	packaged := make([]*models.Rendition, 0, len(renditions)+1)
+	var audioSource string
+	var audioBitrate int
+	for _, rendition := range renditions {
+		if rendition.Kind != models.RenditionKindVideo {
+			continue
+		}
+
+		input := filepath.Join(workDir, rendition.Name+path.Ext(rendition.ObjectKey))
+		if err := a.downloadToFile(ctx, rendition.ObjectKey, input); err != nil {
+			return err
+		}
+
+		segments, err := a.segmenter.Segment(ctx, input, filepath.Join(workDir, "hls", rendition.Name), transcode.TrackVideo)
+		if err != nil {
+			return err
+		}
+		if err := a.publishSegments(ctx, video.ID, rendition, segments, "video/mp4"); err != nil {
+			return err
+		}
+		packaged = append(packaged, rendition)
+
+		// renditions are sorted by height, so the audio is taken from the best one
+		audioSource, audioBitrate = input, rendition.AudioBitrate
+	}
+	if len(packaged) == 0 {
+		return fmt.Errorf("video %s has no renditions to package", video.ID)
+	}
+
+	segments, err := a.segmenter.Segment(ctx, audioSource, filepath.Join(workDir, "hls", audioRenditionName), transcode.TrackAudio)
+	switch {
+	case errors.Is(err, transcode.ErrNoTrack):
+		a.logger.Info().Str("video", video.ID.String()).Msg("Video has no audio track")
+	case err != nil:
+		return err
+	default:
+		audio := &models.Rendition{
+			ID:           uuid.New(),
+			VideoID:      video.ID,
+			Name:         audioRenditionName,
+			Kind:         models.RenditionKindAudio,
+			AudioBitrate: audioBitrate,
+			Codecs:       transcode.AudioCodec,
+			MimeType:     "audio/mp4",
+		}
+		if err := a.publishSegments(ctx, video.ID, audio, segments, "audio/mp4"); err != nil {
+			return err
+		}
+		packaged = append(packaged, audio)
+	}
+
+	rows := make([]models.Rendition, 0, len(packaged))
+	for _, rendition := range packaged {
+		rows = append(rows, *rendition)
+	}
+	if err := a.renditionRepository.ReplaceForVideo(ctx, video.ID, rows); err != nil {
+		return err
+	}
+
+	master := streaming.MasterPlaylist(packaged)
+	if _, err := a.blobStore.Put(ctx, hlsObjectKey(video.ID, streaming.HLSMasterPlaylist), strings.NewReader(master), streaming.HLSContentType); err != nil {
+		return err
+	}
+
+	if err := a.videoRepository.UpdateStatus(ctx, video.ID, models.VideoStatusReady); err != nil {
+		return err
+	}
+	a.reindexVideos(ctx, video.ID)
+
+	// a failure here only delays the poster images and seek previews, the video itself is playable
+	_ = a.enqueueJob(ctx, video.ID, models.JobTypeThumbnails)
+	_ = a.enqueueJob(ctx, video.ID, models.JobTypeStoryboard)
+	return nil
+}
+
+// publishSegments uploads the segments of a rendition with their media playlist and records them on the rendition
+func (a *App) publishSegments(ctx context.Context, videoID uuid.UUID, rendition *models.Rendition, segments *transcode.Segments, initContentType string) error {
+	prefix := hlsObjectKey(videoID, rendition.Name)
+
+	if _, err := a.uploadFile(ctx, filepath.Join(segments.Dir, segments.InitFile), prefix+"/"+segments.InitFile, initContentType); err != nil {
+		return err
+	}
+
+	entries := make([]streaming.MediaSegment, 0, len(segments.Files))
+	duration := 0.0
+	for i, file := range segments.Files {
+		if _, err := a.uploadFile(ctx, filepath.Join(segments.Dir, file), prefix+"/"+file, segmentContentType); err != nil {
+			return err
+		}
+		entries = append(entries, streaming.MediaSegment{URI: file, Duration: segments.Durations[i]})
+		duration += segments.Durations[i]
+	}
+
+	playlist := streaming.MediaPlaylist(segments.InitFile, entries)
+	if _, err := a.blobStore.Put(ctx, prefix+"/"+streaming.HLSMediaPlaylist, strings.NewReader(playlist), streaming.HLSContentType); err != nil {
+		return err
+	}
+
+	rendition.SegmentPrefix = prefix
+	rendition.SegmentCount = len(segments.Files)
+	rendition.SegmentDuration = transcode.SegmentDuration.Seconds()
+	rendition.Duration = duration
+	return nil
+}
+
+// GetHLSFile opens a playlist or segment of a ready video. name is relative to the master playlist
+func (a *App) GetHLSFile(ctx context.Context, videoID uuid.UUID, name string) (io.ReadSeekCloser, *storage.Object, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if video.Status != models.VideoStatusReady {
+		return nil, nil, helpers.ErrVideoNotReady
+	}
+
+	return a.openBlob(ctx, hlsObjectKey(video.ID, name))
+}
+
+// openBlob opens a stored object together with its metadata
+func (a *App) openBlob(ctx context.Context, key string) (io.ReadSeekCloser, *storage.Object, error) {
+	object, err := a.blobStore.Stat(ctx, key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, nil, helpers.ErrRecordNotFound
+		}
+		return nil, nil, err
+	}
+
+	reader, err := a.blobStore.Get(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, object, nil
+}
+
+func hlsObjectKey(videoID uuid.UUID, name string) string {
+	return fmt.Sprintf("videos/%s/hls/%s", videoID.String(), name)
+}
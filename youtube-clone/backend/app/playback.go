@@ -0,0 +1,57 @@
+// playback.go
+
+package app
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/streaming"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// playbackURLExpiry is how long signed playback URLs stay valid, configured in minutes
+func (a *App) playbackURLExpiry() time.Duration {
+	ttl, err := strconv.Atoi(a.env.PlaybackURLExpiry)
+	if err != nil || ttl <= 0 {
+		return 4 * time.Hour
+	}
+	return time.Minute * time.Duration(ttl)
+}
+
+// CreatePlaybackURL signs the media locations of a video for viewerID. URLs the owner gets for a
+// video nobody else may watch yet are always bound to them.
+func (a *App) CreatePlaybackURL(ctx context.Context, videoID, viewerID uuid.UUID, bindViewer bool) (*models.PlaybackURL, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWatch(video, viewerID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+
+	token := helpers.PlaybackToken{
+		VideoID:   video.ID,
+		ExpiresAt: time.Now().Add(a.playbackURLExpiry()).Truncate(time.Second),
+	}
+	if bindViewer || (video.UserID == viewerID && !video.IsVisibleTo(uuid.Nil, time.Now())) ||
+		video.Status != models.VideoStatusReady {
+		token.ViewerID = viewerID
+	}
+	signed := token.Sign(helpers.PlaybackKey(a.env.PlaybackSigningSecret))
+
+	base := models.PlaybackPath(video.ID, signed)
+	return &models.PlaybackURL{
+		Token:         signed,
+		ExpiresAt:     token.ExpiresAt,
+		BoundToViewer: token.ViewerID != uuid.Nil,
+		StreamURL:     base + "/stream",
+		HLSURL:        base + "/hls/" + streaming.HLSMasterPlaylist,
+		DASHURL:       base + "/dash/" + streaming.DASHManifest,
+		StoryboardURL: base + "/" + streaming.StoryboardTrack,
+	}, nil
+}
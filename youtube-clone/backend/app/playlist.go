@@ -0,0 +1,343 @@
+// playlist.go
+
+package app
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// CreatePlaylist creates a new playlist owned by userID, private unless asked otherwise
+func (a *App) CreatePlaylist(ctx context.Context, userID uuid.UUID, req models.CreatePlaylistRequest) (*models.Playlist, error) {
+	if req.Visibility == "" {
+		req.Visibility = models.VideoVisibilityPrivate
+	}
+
+	playlist, err := a.playlistRepository.Create(ctx, models.Playlist{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Kind:        models.PlaylistKindCustom,
+		Title:       req.Title,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create playlist")
+		return nil, err
+	}
+	return playlist, nil
+}
+
+// watchLaterBackfillBatch is how many users BackfillWatchLater creates playlists for at a time
+const watchLaterBackfillBatch = 500
+
+// EnsureWatchLater retrieves the watch later playlist of userID before a change to it. It is created
+// at signup and by BackfillWatchLater, this covers a signup that failed to create it.
+func (a *App) EnsureWatchLater(ctx context.Context, userID uuid.UUID) (*models.Playlist, error) {
+	watchLaterID := WatchLaterPlaylistID(userID)
+	playlist, err := a.getPlaylist(ctx, watchLaterID)
+	if !errors.Is(err, helpers.ErrRecordNotFound) {
+		return playlist, err
+	}
+	if err := a.createWatchLater(ctx, userID); err != nil {
+		return nil, err
+	}
+	return a.getPlaylist(ctx, watchLaterID)
+}
+
+// BackfillWatchLater creates the watch later playlists of the accounts that were created before
+// playlists existed, so they are listed with the other playlists of their owners
+func (a *App) BackfillWatchLater(ctx context.Context) (int, error) {
+	created := 0
+	for {
+		userIDs, err := a.playlistRepository.GetUserIDsWithoutKind(ctx, models.PlaylistKindWatchLater, watchLaterBackfillBatch)
+		if err != nil {
+			a.logger.Error().Err(err).Msg("Failed to read users without watch later playlist")
+			return created, err
+		}
+		if len(userIDs) == 0 {
+			if created > 0 {
+				a.logger.Info().Int("playlists", created).Msg("Created missing watch later playlists")
+			}
+			return created, nil
+		}
+		for _, userID := range userIDs {
+			if err := a.createWatchLater(ctx, userID); err != nil {
+				// the user would be read again, stop rather than loop on the failure
+				return created, err
+			}
+			created++
+		}
+	}
+}
+
+// createWatchLater creates the watch later playlist of userID unless it exists
+func (a *App) createWatchLater(ctx context.Context, userID uuid.UUID) error {
+	err := a.playlistRepository.CreateIfMissing(ctx, models.Playlist{
+		ID:         WatchLaterPlaylistID(userID),
+		UserID:     userID,
+		Kind:       models.PlaylistKindWatchLater,
+		Title:      "Watch later",
+		Visibility: models.VideoVisibilityPrivate,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create watch later playlist")
+		return err
+	}
+	return nil
+}
+
+// WatchLaterPlaylistID derives the ID from the user, so concurrent first uses agree on a single playlist
+// and the playlist can be addressed without reading it
+func WatchLaterPlaylistID(userID uuid.UUID) uuid.UUID {
+	return uuid.NewSHA1(userID, []byte(models.PlaylistKindWatchLater))
+}
+
+// GetPlaylist retrieves a playlist viewerID may see, with its collaborators
+func (a *App) GetPlaylist(ctx context.Context, playlistID, viewerID uuid.UUID) (*models.Playlist, error) {
+	playlist, err := a.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	if !playlist.IsVisibleTo(viewerID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+	return playlist, nil
+}
+
+// GetUserPlaylists lists the playlists of userID, other viewers only see the public ones
+func (a *App) GetUserPlaylists(ctx context.Context, userID, viewerID uuid.UUID, page helpers.Page) ([]*models.Playlist, helpers.PageInfo, error) {
+	playlists, pageInfo, err := a.playlistRepository.GetByUserID(ctx, userID, userID != viewerID, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get user playlists")
+		return nil, helpers.PageInfo{}, err
+	}
+	return playlists, pageInfo, nil
+}
+
+// UpdatePlaylist replaces the title, description and visibility of a playlist owned by userID
+func (a *App) UpdatePlaylist(ctx context.Context, playlistID, userID uuid.UUID, req models.UpdatePlaylistRequest) (*models.Playlist, error) {
+	playlist, err := a.getOwnedPlaylist(ctx, playlistID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Kind == models.PlaylistKindWatchLater {
+		return nil, helpers.ErrWatchLaterPlaylist
+	}
+	playlist.Title = req.Title
+	playlist.Description = req.Description
+	playlist.Visibility = req.Visibility
+
+	updated, err := a.playlistRepository.Update(ctx, *playlist)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update playlist")
+		return nil, err
+	}
+	return updated, nil
+}
+
+// DeletePlaylist removes a playlist owned by userID, the videos in it are kept
+func (a *App) DeletePlaylist(ctx context.Context, playlistID, userID uuid.UUID) error {
+	playlist, err := a.getOwnedPlaylist(ctx, playlistID, userID)
+	if err != nil {
+		return err
+	}
+	if playlist.Kind == models.PlaylistKindWatchLater {
+		return helpers.ErrWatchLaterPlaylist
+	}
+	if err := a.playlistRepository.DeleteByID(ctx, playlist.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to delete playlist")
+		return err
+	}
+	return nil
+}
+
+// GetPlaylistItems lists the items of a playlist viewerID may see. Items whose video viewerID
+// cannot watch (anymore) are listed without their video.
+func (a *App) GetPlaylistItems(ctx context.Context, playlistID, viewerID uuid.UUID, page helpers.Page) ([]*models.PlaylistItem, helpers.PageInfo, error) {
+	playlist, err := a.GetPlaylist(ctx, playlistID, viewerID)
+	if err != nil {
+		return nil, helpers.PageInfo{}, err
+	}
+
+	items, pageInfo, err := a.playlistRepository.GetItems(ctx, playlist.ID, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get playlist items")
+		return nil, helpers.PageInfo{}, err
+	}
+
+	videoIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		videoIDs = append(videoIDs, item.VideoID)
+	}
+	videos, err := a.videoRepository.GetByIDs(ctx, videoIDs)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get playlist videos")
+		return nil, helpers.PageInfo{}, err
+	}
+	withThumbnails(videos...)
+
+	byID := make(map[uuid.UUID]*models.Video, len(videos))
+	for _, video := range videos {
+		if (video.DeletedAt == nil || !video.DeletedAt.Valid) && canWatch(video, viewerID) {
+			byID[video.ID] = video
+		}
+	}
+	for _, item := range items {
+		item.Video = byID[item.VideoID]
+	}
+	return items, pageInfo, nil
+}
+
+// AddPlaylistItem appends a video to a playlist. The owner and the collaborators may add videos.
+func (a *App) AddPlaylistItem(ctx context.Context, playlistID, userID, videoID uuid.UUID) (*models.PlaylistItem, error) {
+	playlist, err := a.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	if !canEditPlaylist(playlist, userID) {
+		return nil, helpers.ErrForbidden
+	}
+
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWatch(video, userID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+
+	item, err := a.playlistRepository.AddItem(ctx, models.PlaylistItem{
+		ID:         uuid.New(),
+		PlaylistID: playlist.ID,
+		VideoID:    video.ID,
+		AddedBy:    userID,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to add playlist item")
+		return nil, err
+	}
+	withThumbnails(video)
+	item.Video = video
+	return item, nil
+}
+
+// RemovePlaylistItem takes an item off a playlist. The owner may remove any item, collaborators
+// only the ones they added.
+func (a *App) RemovePlaylistItem(ctx context.Context, playlistID, itemID, userID uuid.UUID) error {
+	playlist, err := a.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return err
+	}
+	item, err := a.playlistRepository.GetItem(ctx, playlist.ID, itemID)
+	if err != nil {
+		return err
+	}
+	if playlist.UserID != userID && !(canEditPlaylist(playlist, userID) && item.AddedBy == userID) {
+		return helpers.ErrForbidden
+	}
+
+	if err := a.playlistRepository.RemoveItem(ctx, playlist.ID, item.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to remove playlist item")
+		return err
+	}
+	return nil
+}
+
+// MovePlaylistItem moves an item of a playlist owned by userID to position, counted from 0
+func (a *App) MovePlaylistItem(ctx context.Context, playlistID, itemID, userID uuid.UUID, position int) (*models.PlaylistItem, error) {
+	playlist, err := a.getOwnedPlaylist(ctx, playlistID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := a.playlistRepository.MoveItem(ctx, playlist.ID, itemID, position)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to move playlist item")
+		return nil, err
+	}
+	return item, nil
+}
+
+// AddPlaylistCollaborator allows collaboratorID to add videos to a playlist owned by userID
+func (a *App) AddPlaylistCollaborator(ctx context.Context, playlistID, userID, collaboratorID uuid.UUID) (*models.Playlist, error) {
+	playlist, err := a.getOwnedPlaylist(ctx, playlistID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.Kind == models.PlaylistKindWatchLater {
+		return nil, helpers.ErrWatchLaterPlaylist
+	}
+
+	// the owner can always edit, recording them as a collaborator would only be noise
+	if collaboratorID != userID {
+		err = a.playlistRepository.AddCollaborator(ctx, models.PlaylistCollaborator{
+			PlaylistID: playlist.ID,
+			UserID:     collaboratorID,
+		})
+		if err != nil {
+			a.logger.Error().Err(err).Msg("Failed to add playlist collaborator")
+			return nil, err
+		}
+	}
+	return a.getPlaylist(ctx, playlist.ID)
+}
+
+// RemovePlaylistCollaborator revokes the right of collaboratorID to add videos. The owner may remove
+// anybody, collaborators may remove themselves.
+func (a *App) RemovePlaylistCollaborator(ctx context.Context, playlistID, userID, collaboratorID uuid.UUID) error {
+	playlist, err := a.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return err
+	}
+	if playlist.UserID != userID && collaboratorID != userID {
+		return helpers.ErrForbidden
+	}
+
+	if err := a.playlistRepository.RemoveCollaborator(ctx, playlist.ID, collaboratorID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to remove playlist collaborator")
+		return err
+	}
+	return nil
+}
+
+// getPlaylist loads a playlist together with its collaborators
+func (a *App) getPlaylist(ctx context.Context, playlistID uuid.UUID) (*models.Playlist, error) {
+	playlist, err := a.playlistRepository.GetByID(ctx, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	playlist.Collaborators, err = a.playlistRepository.GetCollaborators(ctx, playlist.ID)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get playlist collaborators")
+		return nil, err
+	}
+	return playlist, nil
+}
+
+func (a *App) getOwnedPlaylist(ctx context.Context, playlistID, userID uuid.UUID) (*models.Playlist, error) {
+	playlist, err := a.getPlaylist(ctx, playlistID)
+	if err != nil {
+		return nil, err
+	}
+	if playlist.UserID != userID {
+		return nil, helpers.ErrForbidden
+	}
+	return playlist, nil
+}
+
+// canEditPlaylist reports whether userID may add videos to playlist
+func canEditPlaylist(playlist *models.Playlist, userID uuid.UUID) bool {
+	if playlist.UserID == userID {
+		return true
+	}
+	return slices.ContainsFunc(playlist.Collaborators, func(collaborator *models.PlaylistCollaborator) bool {
+		return collaborator.UserID == userID
+	})
+}
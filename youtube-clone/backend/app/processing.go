@@ -0,0 +1,146 @@
+// processing.go
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/transcode"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// enqueueJob schedules background processing of a video
+func (a *App) enqueueJob(ctx context.Context, videoID uuid.UUID, jobType string) error {
+	_, err := a.jobRepository.Enqueue(ctx, models.Job{
+		VideoID:     videoID,
+		Type:        jobType,
+		MaxAttempts: 3,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Str("video", videoID.String()).Str("type", jobType).Msg("Failed to enqueue job")
+		return err
+	}
+	return nil
+}
+
+// TranscodeVideo is the job handler producing the renditions of a freshly uploaded video
+func (a *App) TranscodeVideo(ctx context.Context, job *models.Job) error {
+	video, err := a.videoRepository.GetByID(ctx, job.VideoID)
+	if err != nil {
+		return err
+	}
+
+	err = a.transcodeVideo(ctx, video)
+	if err != nil && job.Attempts >= job.MaxAttempts {
+		a.markVideoFailed(ctx, video.ID)
+	}
+	return err
+}
+
+func (a *App) transcodeVideo(ctx context.Context, video *models.Video) error {
+	workDir, err := os.MkdirTemp("", "transcode-"+video.ID.String()+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "source"+path.Ext(video.ObjectKey))
+	if err := a.downloadToFile(ctx, video.ObjectKey, input); err != nil {
+		return err
+	}
+
+	outputs, err := a.transcoder.Transcode(ctx, input, workDir, transcode.ProfilesFor(video.Height, transcode.DefaultProfiles))
+	if err != nil {
+		return err
+	}
+
+	renditions := make([]models.Rendition, 0, len(outputs))
+	for _, output := range outputs {
+		// the width follows the aspect ratio of the source, the manifests need the encoded one
+		info, err := a.prober.Probe(ctx, output.Path)
+		if err != nil {
+			return fmt.Errorf("probe rendition %s: %w", output.Profile.Name, err)
+		}
+
+		key := renditionObjectKey(video.ID, output.Profile.Name, path.Ext(output.Path))
+		object, err := a.uploadFile(ctx, output.Path, key, output.MimeType)
+		if err != nil {
+			return err
+		}
+
+		renditions = append(renditions, models.Rendition{
+			ID:           uuid.New(),
+			VideoID:      video.ID,
+			Name:         output.Profile.Name,
+			Kind:         models.RenditionKindVideo,
+			Width:        info.Width,
+			Height:       info.Height,
+			VideoBitrate: output.Profile.VideoBitrate,
+			PeakBitrate:  output.Profile.PeakBitrate(),
+			AudioBitrate: output.Profile.AudioBitrate,
+			Codecs:       output.Profile.VideoCodec,
+			ObjectKey:    object.Key,
+			Size:         object.Size,
+			MimeType:     output.MimeType,
+		})
+	}
+
+	if err := a.renditionRepository.ReplaceForVideo(ctx, video.ID, renditions); err != nil {
+		return err
+	}
+
+	// the video becomes ready once its renditions are packaged for adaptive streaming
+	return a.enqueueJob(ctx, video.ID, models.JobTypePackage)
+}
+
+func (a *App) markVideoFailed(ctx context.Context, videoID uuid.UUID) {
+	if err := a.videoRepository.UpdateStatus(ctx, videoID, models.VideoStatusFailed); err != nil {
+		a.logger.Error().Err(err).Str("video", videoID.String()).Msg("Failed to mark video as failed")
+	}
+}
+
+// downloadToFile copies a blob to a local file so external tools can read it
+func (a *App) downloadToFile(ctx context.Context, key, dst string) error {
+	src, err := a.blobStore.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", key, err)
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("download %s: %w", key, err)
+	}
+	return out.Close()
+}
+
+// uploadFile copies a local file into the blob store
+func (a *App) uploadFile(ctx context.Context, src, key, contentType string) (*storage.Object, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	object, err := a.blobStore.Put(ctx, key, f, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("upload %s: %w", key, err)
+	}
+	return object, nil
+}
+
+func renditionObjectKey(videoID uuid.UUID, name, ext string) string {
+	return fmt.Sprintf("videos/%s/renditions/%s%s", videoID.String(), name, ext)
+}
@@ -0,0 +1,126 @@
+// rating.go
+
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// RateVideo likes or dislikes a video userID can watch, replacing any earlier rating of the user
+func (a *App) RateVideo(ctx context.Context, videoID, userID uuid.UUID, value string) (*models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWatch(video, userID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+
+	_, err = a.ratingRepository.Rate(ctx, models.Rating{
+		ID:         uuid.New(),
+		UserID:     userID,
+		TargetType: models.RatingTargetVideo,
+		TargetID:   video.ID,
+		Value:      value,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to rate video")
+		return nil, err
+	}
+	return a.ratedVideo(ctx, video.ID, userID)
+}
+
+// ClearVideoRating removes the like or dislike of userID from a video userID can watch
+func (a *App) ClearVideoRating(ctx context.Context, videoID, userID uuid.UUID) (*models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if !canWatch(video, userID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+
+	if err := a.ratingRepository.Remove(ctx, userID, models.RatingTargetVideo, video.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to clear video rating")
+		return nil, err
+	}
+	return a.ratedVideo(ctx, video.ID, userID)
+}
+
+// RateComment likes or dislikes a comment on a video userID can see the comments of
+func (a *App) RateComment(ctx context.Context, videoID, commentID, userID uuid.UUID, value string) (*models.Comment, error) {
+	video, err := a.getCommentableVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := a.getVideoComment(ctx, video.ID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = a.ratingRepository.Rate(ctx, models.Rating{
+		ID:         uuid.New(),
+		UserID:     userID,
+		TargetType: models.RatingTargetComment,
+		TargetID:   comment.ID,
+		Value:      value,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to rate comment")
+		return nil, err
+	}
+	return a.commentRepository.GetByID(ctx, comment.ID)
+}
+
+// ClearCommentRating removes the like or dislike of userID from a comment on a video userID can see the comments of
+func (a *App) ClearCommentRating(ctx context.Context, videoID, commentID, userID uuid.UUID) (*models.Comment, error) {
+	video, err := a.getCommentableVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	comment, err := a.getVideoComment(ctx, video.ID, commentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.ratingRepository.Remove(ctx, userID, models.RatingTargetComment, comment.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to clear comment rating")
+		return nil, err
+	}
+	return a.commentRepository.GetByID(ctx, comment.ID)
+}
+
+// ratedVideo reloads a video after a rating change, so the response carries the new counters
+func (a *App) ratedVideo(ctx context.Context, videoID, userID uuid.UUID) (*models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.withViewerRating(ctx, video, userID); err != nil {
+		return nil, err
+	}
+	withThumbnails(video)
+	return video, nil
+}
+
+// withViewerRating attaches the rating viewerID gave to video
+func (a *App) withViewerRating(ctx context.Context, video *models.Video, viewerID uuid.UUID) error {
+	if viewerID == uuid.Nil {
+		return nil
+	}
+	rating, err := a.ratingRepository.Get(ctx, viewerID, models.RatingTargetVideo, video.ID)
+	if err != nil {
+		if errors.Is(err, helpers.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	video.ViewerRating = rating.Value
+	return nil
+}
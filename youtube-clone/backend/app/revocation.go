@@ -0,0 +1,229 @@
+// revocation.go
+
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// revocationCache mirrors the revoked access tokens of the database, so authenticating a request
+// never has to query it. RunRevocationSync reloads it to pick up revocations made by other instances.
+type revocationCache struct {
+	mu sync.RWMutex
+	// tokens maps revoked token IDs to their expiry
+	tokens revocationSet
+	// users maps users who signed out of all devices to the time they did
+	users revocationSet
+	// families maps the refresh token families of revoked sessions to the time they were revoked
+	families revocationSet
+}
+
+// revocationSet maps IDs to a time and remembers when this instance added them, so a snapshot of
+// the database read before an addition was written does not drop it
+type revocationSet struct {
+	entries map[uuid.UUID]time.Time
+	added   map[uuid.UUID]time.Time
+}
+
+func newRevocationSet() revocationSet {
+	return revocationSet{entries: map[uuid.UUID]time.Time{}, added: map[uuid.UUID]time.Time{}}
+}
+
+// add keeps the later of the times for ID, rc.mu must be held
+func (s *revocationSet) add(ID uuid.UUID, at, now time.Time) {
+	if at.After(s.entries[ID]) {
+		s.entries[ID] = at
+	}
+	s.added[ID] = now
+}
+
+// merge swaps in a snapshot read from the database at readAt. Additions made after readAt may be
+// missing from it and are kept, the snapshot covers the older ones. rc.mu must be held.
+func (s *revocationSet) merge(snapshot map[uuid.UUID]time.Time, readAt time.Time) {
+	for ID, addedAt := range s.added {
+		if addedAt.Before(readAt) {
+			delete(s.added, ID)
+			continue
+		}
+		if s.entries[ID].After(snapshot[ID]) {
+			snapshot[ID] = s.entries[ID]
+		}
+	}
+	s.entries = snapshot
+}
+
+func newRevocationCache() *revocationCache {
+	return &revocationCache{
+		tokens:   newRevocationSet(),
+		users:    newRevocationSet(),
+		families: newRevocationSet(),
+	}
+}
+
+// revoked tells whether the token is revoked by itself, by the revocation of its session or by a sign
+// out of all devices. Tokens carry their issue time in whole seconds, so a token issued within the
+// second of the sign out is revoked too.
+func (rc *revocationCache) revoked(tokenID, familyID, userID uuid.UUID, issuedAt time.Time) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	if _, ok := rc.tokens.entries[tokenID]; ok && tokenID != uuid.Nil {
+		return true
+	}
+	if _, ok := rc.families.entries[familyID]; ok && familyID != uuid.Nil {
+		return true
+	}
+	cutoff, ok := rc.users.entries[userID]
+	return ok && issuedAt.Unix() <= cutoff.Unix()
+}
+
+func (rc *revocationCache) addToken(tokenID uuid.UUID, expiresAt time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.tokens.add(tokenID, expiresAt, time.Now())
+}
+
+func (rc *revocationCache) addUser(userID uuid.UUID, issuedBefore time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.users.add(userID, issuedBefore, time.Now())
+}
+
+func (rc *revocationCache) addFamily(familyID uuid.UUID, revokedAt time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.families.add(familyID, revokedAt, time.Now())
+}
+
+// merge takes in the revocations read from the database at readAt, keeping what this instance
+// revoked while they were read
+func (rc *revocationCache) merge(tokens, users, families map[uuid.UUID]time.Time, readAt time.Time) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.tokens.merge(tokens, readAt)
+	rc.users.merge(users, readAt)
+	rc.families.merge(families, readAt)
+}
+
+// IsTokenRevoked tells whether an access token was signed out, it only reads the in-memory cache.
+// Tokens issued before revocation existed have no ID and are only revoked by a sign out of all devices.
+func (a *App) IsTokenRevoked(ctx context.Context, tokenID, familyID uuid.UUID, userID string, issuedAt time.Time) bool {
+	uID, err := uuid.Parse(userID)
+	if err != nil {
+		return true
+	}
+	return a.revocations.revoked(tokenID, familyID, uID, issuedAt)
+}
+
+// Logout signs out a single session, it revokes the access token, the session and the refresh token
+// family issued with it
+func (a *App) Logout(ctx context.Context, userID, tokenID, familyID uuid.UUID, expiresAt time.Time) error {
+	if tokenID != uuid.Nil {
+		err := a.revocationRepository.RevokeToken(ctx, models.RevokedToken{ID: tokenID, UserID: userID, ExpiresAt: expiresAt})
+		if err != nil {
+			a.logger.Error().Err(err).Msg("Failed to revoke access token")
+			return err
+		}
+		a.revocations.addToken(tokenID, expiresAt)
+	}
+
+	if familyID != uuid.Nil {
+		if err := a.revokeSessionFamily(ctx, familyID); err != nil {
+			return err
+		}
+		if err := a.refreshTokenRepository.RevokeFamily(ctx, familyID); err != nil {
+			a.logger.Error().Err(err).Msg("Failed to revoke refresh token family")
+			return err
+		}
+	}
+	return nil
+}
+
+// LogoutEverywhere signs the user out of all devices, revoking every access and refresh token issued so far
+func (a *App) LogoutEverywhere(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	if err := a.revocationRepository.RevokeUserTokens(ctx, userID, now); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to revoke access tokens of user")
+		return err
+	}
+	a.revocations.addUser(userID, now)
+
+	if err := a.refreshTokenRepository.RevokeByUserID(ctx, userID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to revoke refresh tokens of user")
+		return err
+	}
+	if err := a.sessionRepository.RevokeByUserID(ctx, userID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to revoke sessions of user")
+		return err
+	}
+	return nil
+}
+
+// SyncRevocations reloads the revocation cache from the database
+func (a *App) SyncRevocations(ctx context.Context) error {
+	// revocations this instance adds from now on may be missing from the snapshot
+	now := time.Now()
+	revokedTokens, err := a.revocationRepository.GetRevokedTokens(ctx, now)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to load revoked tokens")
+		return err
+	}
+	revokedUsers, err := a.revocationRepository.GetRevokedUsers(ctx)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to load revoked users")
+		return err
+	}
+
+	// access tokens of sessions revoked longer ago have all expired
+	revokedSessions, err := a.sessionRepository.GetRevokedFamilies(ctx, now.Add(-a.env.AccessTokenExpiry()))
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to load revoked sessions")
+		return err
+	}
+
+	tokens := make(map[uuid.UUID]time.Time, len(revokedTokens))
+	for _, token := range revokedTokens {
+		tokens[token.ID] = token.ExpiresAt
+	}
+	users := make(map[uuid.UUID]time.Time, len(revokedUsers))
+	for _, user := range revokedUsers {
+		users[user.UserID] = user.IssuedBefore
+	}
+	families := make(map[uuid.UUID]time.Time, len(revokedSessions))
+	for _, session := range revokedSessions {
+		families[session.FamilyID] = *session.RevokedAt
+	}
+	a.revocations.merge(tokens, users, families, now)
+	return nil
+}
+
+// RunRevocationSync reloads the revocation cache every interval until ctx is cancelled, dropping
+// the revoked tokens and the sessions that have expired in the meantime
+func (a *App) RunRevocationSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		if _, err := a.revocationRepository.DeleteExpired(ctx, now); err != nil {
+			a.logger.Error().Err(err).Msg("Failed to delete expired revoked tokens")
+		}
+		// revoked sessions are kept while access tokens issued in them may still be valid
+		if _, err := a.sessionRepository.DeleteExpired(ctx, now.Add(-a.env.AccessTokenExpiry())); err != nil {
+			a.logger.Error().Err(err).Msg("Failed to delete expired sessions")
+		}
+		a.sessions.prune(now)
+		_ = a.SyncRevocations(ctx)
+	}
+}
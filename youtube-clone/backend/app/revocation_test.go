@@ -0,0 +1,83 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRevocationCacheRevoked(t *testing.T) {
+	signedOutAt := time.Date(2024, 5, 1, 12, 0, 0, 500_000_000, time.UTC)
+	revokedToken, revokedFamily, signedOutUser := uuid.New(), uuid.New(), uuid.New()
+
+	rc := newRevocationCache()
+	rc.addToken(revokedToken, signedOutAt.Add(time.Hour))
+	rc.addToken(uuid.Nil, signedOutAt.Add(time.Hour))
+	rc.addFamily(revokedFamily, signedOutAt)
+	rc.addFamily(uuid.Nil, signedOutAt)
+	rc.addUser(signedOutUser, signedOutAt)
+	// an older sign out must not move the cutoff back
+	rc.addUser(signedOutUser, signedOutAt.Add(-time.Hour))
+
+	tests := []struct {
+		name     string
+		tokenID  uuid.UUID
+		familyID uuid.UUID
+		userID   uuid.UUID
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "valid token", tokenID: uuid.New(), familyID: uuid.New(), userID: uuid.New(), issuedAt: signedOutAt},
+		{name: "revoked token", tokenID: revokedToken, familyID: uuid.New(), userID: uuid.New(), want: true},
+		{name: "revoked session", tokenID: uuid.New(), familyID: revokedFamily, userID: uuid.New(), want: true},
+		{name: "token without IDs", userID: uuid.New(), issuedAt: signedOutAt},
+		{name: "issued before signing out everywhere", tokenID: uuid.New(), userID: signedOutUser, issuedAt: signedOutAt.Add(-time.Minute), want: true},
+		{name: "issued within the second of signing out", tokenID: uuid.New(), userID: signedOutUser, issuedAt: signedOutAt.Truncate(time.Second), want: true},
+		{name: "issued after signing out everywhere", tokenID: uuid.New(), userID: signedOutUser, issuedAt: signedOutAt.Add(time.Second)},
+		{name: "token without IDs issued before signing out", userID: signedOutUser, issuedAt: signedOutAt.Add(-time.Minute), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rc.revoked(tt.tokenID, tt.familyID, tt.userID, tt.issuedAt); got != tt.want {
+				t.Errorf("revoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevocationCacheMerge(t *testing.T) {
+	stored, addedBefore, addedDuring := uuid.New(), uuid.New(), uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	rc := newRevocationCache()
+	rc.addToken(addedBefore, expiresAt)
+	readAt := time.Now()
+	rc.addToken(addedDuring, expiresAt)
+	// the snapshot misses the token added while it was read, and the one before it has expired since
+	rc.merge(map[uuid.UUID]time.Time{stored: expiresAt}, map[uuid.UUID]time.Time{}, map[uuid.UUID]time.Time{}, readAt)
+
+	tests := []struct {
+		name    string
+		tokenID uuid.UUID
+		want    bool
+	}{
+		{name: "in the snapshot", tokenID: stored, want: true},
+		{name: "added while reading the snapshot", tokenID: addedDuring, want: true},
+		{name: "added before reading the snapshot", tokenID: addedBefore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rc.revoked(tt.tokenID, uuid.Nil, uuid.New(), time.Now()); got != tt.want {
+				t.Errorf("revoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the next snapshot is read after the addition was written, so it takes over
+	rc.merge(map[uuid.UUID]time.Time{}, map[uuid.UUID]time.Time{}, map[uuid.UUID]time.Time{}, time.Now().Add(time.Second))
+	if rc.revoked(addedDuring, uuid.Nil, uuid.New(), time.Now()) {
+		t.Error("revoked() after a later snapshot = true, want false")
+	}
+}
@@ -0,0 +1,164 @@
+// search.go
+
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/search"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// searchRebuildBatch is the number of videos read from the database per index batch on a rebuild
+const searchRebuildBatch = 500
+
+// SearchVideos finds the listed videos matching req, most relevant first. Relevance is boosted by
+// views and recency, so the requested sorting is ignored. The searcher, viewerID or the client at
+// clientIP, is counted for the query suggestions.
+func (a *App) SearchVideos(ctx context.Context, req models.SearchRequest, viewerID uuid.UUID, clientIP string, page helpers.Page) ([]*models.SearchResult, helpers.PageInfo, error) {
+	number, size := helpers.PageDefaultNumber, helpers.PageDefaultSize
+	if page.Number != nil {
+		number = *page.Number
+	}
+	if page.Size != nil {
+		size = *page.Size
+	}
+
+	pageInfo := helpers.PageInfo{Page: number, Size: size, HasPreviousPage: number > 1}
+	// only the best candidates are ranked, this also keeps the offset from overflowing
+	if number > (search.CandidateLimit+size-1)/size {
+		return []*models.SearchResult{}, pageInfo, nil
+	}
+
+	now := time.Now()
+	q := search.Query{
+		Text:          req.Query,
+		UploadedAfter: search.UploadedSince(req.Uploaded, now),
+		Tags:          req.Tags,
+		Offset:        (number - 1) * size,
+		Limit:         size,
+	}
+	q.MinDuration, q.MaxDuration = search.DurationRange(req.Duration)
+	if req.ChannelID != "" {
+		q.ChannelID, _ = uuid.Parse(req.ChannelID)
+	}
+
+	found, err := a.searchIndex.Search(ctx, q)
+	if err != nil {
+		if errors.Is(err, search.ErrInvalidQuery) {
+			return nil, helpers.PageInfo{}, err
+		}
+		a.logger.Error().Err(err).Msg("Failed to search videos")
+		return nil, helpers.PageInfo{}, err
+	}
+
+	videoIDs := make([]uuid.UUID, 0, len(found.Hits))
+	for _, hit := range found.Hits {
+		videoIDs = append(videoIDs, hit.ID)
+	}
+	videos, err := a.videoRepository.GetByIDs(ctx, videoIDs)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get searched videos")
+		return nil, helpers.PageInfo{}, err
+	}
+	withThumbnails(videos...)
+
+	byID := make(map[uuid.UUID]*models.Video, len(videos))
+	for _, video := range videos {
+		byID[video.ID] = video
+	}
+	results := make([]*models.SearchResult, 0, len(found.Hits))
+	var missing, stale []uuid.UUID
+	for _, hit := range found.Hits {
+		// the index filters by status and visibility, but may lag behind the database. What is not
+		// listed anymore is left out of the page and the count, and the index is corrected.
+		video, ok := byID[hit.ID]
+		switch {
+		case !ok:
+			missing = append(missing, hit.ID)
+		case (video.DeletedAt != nil && video.DeletedAt.Valid) || video.Status != models.VideoStatusReady || !video.IsPublic(now):
+			stale = append(stale, hit.ID)
+		default:
+			results = append(results, &models.SearchResult{Video: video, Score: hit.Score, Highlights: hit.Highlights})
+		}
+	}
+	if len(missing) > 0 {
+		if err := a.searchIndex.Delete(ctx, missing...); err != nil {
+			a.logger.Error().Err(err).Msg("Failed to remove missing videos from search index")
+		}
+	}
+	if len(stale) > 0 {
+		a.reindexVideos(ctx, stale...)
+	}
+	total := max(found.Total-int64(len(missing)+len(stale)), 0)
+
+	// only first pages with results count, paging through one search or a typo is not a popular query
+	if number == 1 && len(results) > 0 {
+		a.recordQuery(req.Query, viewerID, clientIP)
+	}
+
+	pageInfo.HasNextPage = int64(number*size) < total
+	pageInfo.TotalCount = total
+	return results, pageInfo, nil
+}
+
+// SyncSearchIndex rebuilds the search index when it holds fewer videos than the database, as a new
+// or lost index does, or one that missed changes while the index was unavailable
+func (a *App) SyncSearchIndex(ctx context.Context) (int, error) {
+	indexed, err := a.searchIndex.Count()
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to count indexed videos")
+		return 0, err
+	}
+	stored, err := a.videoRepository.CountVideos(ctx)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to count videos")
+		return 0, err
+	}
+	if int64(indexed) >= stored {
+		return 0, nil
+	}
+	a.logger.Info().Uint64("indexed", indexed).Int64("videos", stored).Msg("Search index is behind, rebuilding it")
+	return a.RebuildSearchIndex(ctx)
+}
+
+// RebuildSearchIndex indexes every video again, for a new or lost index
+func (a *App) RebuildSearchIndex(ctx context.Context) (int, error) {
+	indexed := 0
+	afterID := uuid.Nil
+	for {
+		videos, err := a.videoRepository.GetAfterID(ctx, afterID, searchRebuildBatch)
+		if err != nil {
+			a.logger.Error().Err(err).Msg("Failed to read videos to index")
+			return indexed, err
+		}
+		if len(videos) == 0 {
+			a.logger.Info().Int("videos", indexed).Msg("Rebuilt search index")
+			return indexed, nil
+		}
+		if err := a.searchIndex.Index(ctx, videos...); err != nil {
+			a.logger.Error().Err(err).Msg("Failed to rebuild search index")
+			return indexed, err
+		}
+		indexed += len(videos)
+		afterID = videos[len(videos)-1].ID
+	}
+}
+
+// reindexVideos brings the search index up to date with the stored videos. The index is only a
+// view of the database, so failures are logged and never fail the change that triggered them.
+func (a *App) reindexVideos(ctx context.Context, videoIDs ...uuid.UUID) {
+	videos, err := a.videoRepository.GetByIDs(ctx, videoIDs)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to read videos to index")
+		return
+	}
+	if err := a.searchIndex.Index(ctx, videos...); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to index videos")
+	}
+}
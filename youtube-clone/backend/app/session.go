@@ -0,0 +1,141 @@
+// session.go
+
+package app
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// sessionTouchInterval is how often the last seen time of a session is written at most
+const sessionTouchInterval = time.Minute
+
+// maxUserAgentLength is the size of the user agent column
+const maxUserAgentLength = 500
+
+// sessionTracker remembers when the last seen time of each session was written, so an active
+// session costs a database write a minute rather than one per request. It is per process, with
+// several instances a session is written at most once a minute by each.
+type sessionTracker struct {
+	mu   sync.Mutex
+	seen map[uuid.UUID]time.Time // refresh token family -> last write
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{seen: map[uuid.UUID]time.Time{}}
+}
+
+// touch tells whether the last seen time of the session is due to be written
+func (st *sessionTracker) touch(familyID uuid.UUID, now time.Time) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if last, ok := st.seen[familyID]; ok && now.Sub(last) < sessionTouchInterval {
+		return false
+	}
+	st.seen[familyID] = now
+	return true
+}
+
+// prune forgets the sessions that were not written within the last interval
+func (st *sessionTracker) prune(now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for familyID, last := range st.seen {
+		if now.Sub(last) >= sessionTouchInterval {
+			delete(st.seen, familyID)
+		}
+	}
+}
+
+// StartSession records the device a user just logged in from, the session is tied to the refresh
+// token family issued on that login
+func (a *App) StartSession(ctx context.Context, userID, familyID uuid.UUID, userAgent, ip string, expiresAt time.Time) error {
+	now := time.Now()
+	if len(userAgent) > maxUserAgentLength {
+		// cut on a character boundary, half a character is not valid UTF-8
+		cut := maxUserAgentLength
+		for cut > 0 && !utf8.RuneStart(userAgent[cut]) {
+			cut--
+		}
+		userAgent = userAgent[:cut]
+	}
+	session := models.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		FamilyID:   familyID,
+		DeviceName: helpers.DeviceName(userAgent),
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastSeenAt: now,
+		ExpiresAt:  expiresAt,
+	}
+	if _, err := a.sessionRepository.Create(ctx, session); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create session")
+		return err
+	}
+	a.sessions.touch(familyID, now)
+	return nil
+}
+
+// GetSessions lists the devices the user is signed in on, marking the one of currentFamilyID as current
+func (a *App) GetSessions(ctx context.Context, userID, currentFamilyID uuid.UUID) ([]*models.Session, error) {
+	sessions, err := a.sessionRepository.GetActiveByUserID(ctx, userID, time.Now())
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get sessions")
+		return nil, err
+	}
+	for _, session := range sessions {
+		session.Current = currentFamilyID != uuid.Nil && session.FamilyID == currentFamilyID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs a device of the user out, its access and refresh tokens stop working right away
+func (a *App) RevokeSession(ctx context.Context, sessionID, userID uuid.UUID) error {
+	session, err := a.sessionRepository.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// sessions of other users do not exist as far as the caller is concerned
+	if session.UserID != userID || session.RevokedAt != nil {
+		return helpers.ErrRecordNotFound
+	}
+
+	if err := a.revokeSessionFamily(ctx, session.FamilyID); err != nil {
+		return err
+	}
+	if err := a.refreshTokenRepository.RevokeFamily(ctx, session.FamilyID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to revoke refresh token family")
+		return err
+	}
+	return nil
+}
+
+// revokeSessionFamily revokes the session of a refresh token family and every access token issued in it
+func (a *App) revokeSessionFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := a.sessionRepository.RevokeByFamilyID(ctx, familyID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to revoke session")
+		return err
+	}
+	a.revocations.addFamily(familyID, time.Now())
+	return nil
+}
+
+// TouchSession records that the session of familyID was just used from ip, at most once a minute.
+// Tokens issued before sessions existed have no family and are not tracked.
+func (a *App) TouchSession(ctx context.Context, familyID uuid.UUID, ip string) {
+	now := time.Now()
+	if familyID == uuid.Nil || !a.sessions.touch(familyID, now) {
+		return
+	}
+	if err := a.sessionRepository.Touch(ctx, familyID, ip, now); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update session last seen")
+	}
+}
@@ -0,0 +1,106 @@
+// storyboard.go
+
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/streaming"
+	"github.com/joshua468/youtube-clone/backend/transcode"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// storyboardSpec is the default sprite layout with the configured interval (seconds) between tiles
+func (a *App) storyboardSpec() transcode.StoryboardSpec {
+	spec := transcode.DefaultStoryboardSpec
+	interval, err := strconv.Atoi(a.env.StoryboardInterval)
+	if err == nil && interval > 0 {
+		spec.Interval = time.Duration(interval) * time.Second
+	}
+	return spec
+}
+
+// GenerateStoryboard is the job handler rendering the seek preview sprite sheets and their WebVTT track
+func (a *App) GenerateStoryboard(ctx context.Context, job *models.Job) error {
+	video, err := a.videoRepository.GetByID(ctx, job.VideoID)
+	if err != nil {
+		return err
+	}
+
+	// the smallest rendition is plenty for 160x90 tiles and the quickest to decode
+	renditions, err := a.renditionRepository.GetByVideoID(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+	var source *models.Rendition
+	for _, rendition := range renditions {
+		if rendition.Kind == models.RenditionKindVideo {
+			source = rendition
+			break
+		}
+	}
+	if source == nil {
+		return fmt.Errorf("video %s has no renditions", video.ID)
+	}
+	if source.Duration <= 0 {
+		return fmt.Errorf("video %s has no known duration", video.ID)
+	}
+
+	workDir, err := os.MkdirTemp("", "storyboard-"+video.ID.String()+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "source"+path.Ext(source.ObjectKey))
+	if err := a.downloadToFile(ctx, source.ObjectKey, input); err != nil {
+		return err
+	}
+
+	spec := a.storyboardSpec()
+	sheets, err := a.storyboarder.Storyboard(ctx, input, filepath.Join(workDir, "sprites"), spec)
+	if err != nil {
+		return err
+	}
+	for i, sheet := range sheets {
+		if _, err := a.uploadFile(ctx, sheet, storyboardObjectKey(video.ID, streaming.StoryboardSheet(i)), "image/jpeg"); err != nil {
+			return err
+		}
+	}
+
+	// the track goes last so it never references sheets that are not uploaded yet
+	duration := time.Duration(source.Duration * float64(time.Second))
+	track := streaming.StoryboardVTT(duration, spec)
+	_, err = a.blobStore.Put(ctx, storyboardObjectKey(video.ID, streaming.StoryboardTrack), strings.NewReader(track), streaming.WebVTTContentType)
+	return err
+}
+
+// GetStoryboardFile opens the WebVTT track or a sprite sheet of a ready video. name is relative to the track
+func (a *App) GetStoryboardFile(ctx context.Context, videoID uuid.UUID, name string) (io.ReadSeekCloser, *storage.Object, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if video.Status != models.VideoStatusReady {
+		return nil, nil, helpers.ErrVideoNotReady
+	}
+
+	return a.openBlob(ctx, storyboardObjectKey(video.ID, name))
+}
+
+// storyboardObjectKey keeps the track and the sheets side by side, as StoryboardSheet names are relative
+func storyboardObjectKey(videoID uuid.UUID, name string) string {
+	return fmt.Sprintf("videos/%s/%s", videoID.String(), name)
+}
@@ -0,0 +1,72 @@
+// subscription.go
+
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// Subscribe subscribes userID to a channel, or changes the notification preference of an existing subscription
+func (a *App) Subscribe(ctx context.Context, channelID, userID uuid.UUID, notifications string) (*models.Subscription, error) {
+	channel, err := a.channelRepository.GetByID(ctx, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if channel.UserID == userID {
+		return nil, helpers.ErrSelfSubscription
+	}
+	if notifications == "" {
+		notifications = models.SubscriptionNotifyPersonalized
+	}
+
+	subscription, err := a.subscriptionRepository.Subscribe(ctx, models.Subscription{
+		ID:            uuid.New(),
+		UserID:        userID,
+		ChannelID:     channel.ID,
+		Notifications: notifications,
+	})
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to subscribe")
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// Unsubscribe removes the subscription of userID to a channel, if there is one
+func (a *App) Unsubscribe(ctx context.Context, channelID, userID uuid.UUID) error {
+	if err := a.subscriptionRepository.Unsubscribe(ctx, userID, channelID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to unsubscribe")
+		return err
+	}
+	return nil
+}
+
+// GetSubscriptionFeed retrieves the newest public videos of the channels userID subscribed to
+func (a *App) GetSubscriptionFeed(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+	videos, pageInfo, err := a.videoRepository.GetSubscriptionFeed(ctx, userID, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get subscription feed")
+		return nil, helpers.PageInfo{}, err
+	}
+	withThumbnails(videos...)
+	return videos, pageInfo, nil
+}
+
+// withSubscription attaches the subscription of viewerID to channel
+func (a *App) withSubscription(ctx context.Context, channel *models.Channel, viewerID uuid.UUID) error {
+	if viewerID == uuid.Nil {
+		return nil
+	}
+	subscription, err := a.subscriptionRepository.Get(ctx, viewerID, channel.ID)
+	if err != nil && !errors.Is(err, helpers.ErrRecordNotFound) {
+		return err
+	}
+	channel.Subscription = subscription
+	return nil
+}
@@ -0,0 +1,207 @@
+// suggest.go
+
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/search"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	// popularQueryMinSearchers keeps rare, possibly personal, queries out of the suggestions
+	popularQueryMinSearchers = 3
+	// popularQueryLimit bounds the number of past queries loaded into the suggestions
+	popularQueryLimit = 50000
+	// queryWeight favours what people actually searched for over titles and tags
+	queryWeight = 2
+	// maxPendingSearches bounds the searches buffered between two flushes, the ones beyond are not counted
+	maxPendingSearches = 100_000
+	// videoSuggestionsMaxAge is how long the titles and tags read for the suggestions are reused, the
+	// past queries are read on every refresh
+	videoSuggestionsMaxAge = time.Hour
+)
+
+// queryCounter buffers who searched each query, so searching never writes to the database.
+// RunSuggestionRefresher writes the buffered searchers in batches.
+type queryCounter struct {
+	mu      sync.Mutex
+	pending map[string]map[string]struct{} // query -> searchers
+	size    int
+}
+
+func newQueryCounter() *queryCounter {
+	return &queryCounter{pending: map[string]map[string]struct{}{}}
+}
+
+// add records that searcher searched query, once per flush
+func (qc *queryCounter) add(query, searcher string) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	searchers, ok := qc.pending[query]
+	if _, seen := searchers[searcher]; seen || qc.size >= maxPendingSearches {
+		return
+	}
+	if !ok {
+		searchers = map[string]struct{}{}
+		qc.pending[query] = searchers
+	}
+	searchers[searcher] = struct{}{}
+	qc.size++
+}
+
+// take hands over the buffered searchers per query
+func (qc *queryCounter) take() map[string][]string {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	taken := make(map[string][]string, len(qc.pending))
+	for query, searchers := range qc.pending {
+		for searcher := range searchers {
+			taken[query] = append(taken[query], searcher)
+		}
+	}
+	qc.pending = map[string]map[string]struct{}{}
+	qc.size = 0
+	return taken
+}
+
+// restore puts searchers that could not be written back into the buffer
+func (qc *queryCounter) restore(taken map[string][]string) {
+	for query, searchers := range taken {
+		for _, searcher := range searchers {
+			qc.add(query, searcher)
+		}
+	}
+}
+
+// videoSuggestions caches the suggestion weights of the titles and tags of the listed videos
+type videoSuggestions struct {
+	mu       sync.Mutex
+	weights  map[string]float64
+	loadedAt time.Time
+}
+
+// SuggestQueries completes prefix from the last built suggestions, it never touches the database
+func (a *App) SuggestQueries(ctx context.Context, prefix string, limit int) []string {
+	return a.suggester.Load().Suggest(prefix, limit)
+}
+
+// recordQuery counts a search of viewerID or an anonymous client, the counts are written by FlushSearchQueries
+func (a *App) recordQuery(query string, viewerID uuid.UUID, clientIP string) {
+	if query = search.NormalizeQuery(query); query != "" {
+		a.queries.add(query, searcherHash(viewerID, clientIP))
+	}
+}
+
+// searcherHash identifies a searcher the way views are deduplicated, hashed once more as it is stored
+func searcherHash(viewerID uuid.UUID, clientIP string) string {
+	sum := sha256.Sum256([]byte(viewerFingerprint(viewerID, clientIP)))
+	return hex.EncodeToString(sum[:])
+}
+
+// FlushSearchQueries writes the buffered searchers, returning the number of distinct queries written
+func (a *App) FlushSearchQueries(ctx context.Context) (int, error) {
+	searchers := a.queries.take()
+	if len(searchers) == 0 {
+		return 0, nil
+	}
+
+	if err := a.searchQueryRepository.Record(ctx, searchers, time.Now()); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to flush search queries")
+		a.queries.restore(searchers)
+		return 0, err
+	}
+	return len(searchers), nil
+}
+
+// RefreshSuggestions rebuilds the suggestions from the titles and tags of the listed videos,
+// weighted by their views, and from the popular past queries that pass moderation
+func (a *App) RefreshSuggestions(ctx context.Context) error {
+	videoWeights, err := a.videoSuggestionWeights(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	weights := maps.Clone(videoWeights)
+
+	queries, err := a.searchQueryRepository.GetPopular(ctx, popularQueryMinSearchers, popularQueryLimit)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to read popular search queries")
+		return err
+	}
+	denied := search.ParseDenylist(a.env.SearchSuggestDenylist)
+	for _, query := range queries {
+		if !search.Suggestable(query.Query, denied) {
+			continue
+		}
+		weights[query.Query] += queryWeight * math.Log10(1+float64(query.Searchers))
+	}
+
+	a.suggester.Store(search.BuildSuggester(weights))
+	return nil
+}
+
+// videoSuggestionWeights weighs the titles and tags of the listed videos by their views. Reading
+// them walks every video, so the weights are reused for videoSuggestionsMaxAge.
+func (a *App) videoSuggestionWeights(ctx context.Context, now time.Time) (map[string]float64, error) {
+	a.videoSuggestions.mu.Lock()
+	defer a.videoSuggestions.mu.Unlock()
+	if a.videoSuggestions.weights != nil && now.Sub(a.videoSuggestions.loadedAt) < videoSuggestionsMaxAge {
+		return a.videoSuggestions.weights, nil
+	}
+
+	weights := map[string]float64{}
+	afterID := uuid.Nil
+	for {
+		videos, err := a.videoRepository.GetAfterID(ctx, afterID, searchRebuildBatch)
+		if err != nil {
+			a.logger.Error().Err(err).Msg("Failed to read videos for suggestions")
+			return nil, err
+		}
+		if len(videos) == 0 {
+			break
+		}
+		for _, video := range videos {
+			if video.Status != models.VideoStatusReady || !video.IsPublic(now) {
+				continue
+			}
+			weight := 1 + math.Log10(1+float64(video.ViewCount))
+			weights[video.Title] += weight
+			for _, tag := range video.Tags {
+				weights[tag] += weight
+			}
+		}
+		afterID = videos[len(videos)-1].ID
+	}
+
+	a.videoSuggestions.weights = weights
+	a.videoSuggestions.loadedAt = now
+	return weights, nil
+}
+
+// RunSuggestionRefresher writes the buffered query counts and rebuilds the suggestions right away
+// and then every interval until ctx is cancelled, then writes the counts once more.
+func (a *App) RunSuggestionRefresher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = a.FlushSearchQueries(ctx)
+		_ = a.RefreshSuggestions(ctx)
+
+		select {
+		case <-ctx.Done():
+			_, _ = a.FlushSearchQueries(context.Background())
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestQueryCounter(t *testing.T) {
+	qc := newQueryCounter()
+	qc.add("golang", "a")
+	qc.add("golang", "a")
+	qc.add("golang", "b")
+	qc.add("rust", "a")
+
+	taken := qc.take()
+	if got := len(taken["golang"]); got != 2 {
+		t.Errorf("searchers of golang = %d, want 2", got)
+	}
+	if got := len(taken["rust"]); got != 1 {
+		t.Errorf("searchers of rust = %d, want 1", got)
+	}
+	if got := len(qc.take()); got != 0 {
+		t.Errorf("take() after take() = %d queries, want 0", got)
+	}
+
+	for i := 0; i < maxPendingSearches+10; i++ {
+		qc.add(fmt.Sprintf("query %d", i), "a")
+	}
+	if got := len(qc.take()); got != maxPendingSearches {
+		t.Errorf("buffered %d queries, want at most %d", got, maxPendingSearches)
+	}
+
+	qc.restore(taken)
+	if got := len(qc.take()["golang"]); got != 2 {
+		t.Errorf("searchers of golang after restore() = %d, want 2", got)
+	}
+}
@@ -0,0 +1,253 @@
+// thumbnail.go
+
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/transcode"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	maxThumbnailSize = 2 << 20 // 2 MiB
+	// defaultThumbnailPosition is the candidate selected until the owner picks one
+	defaultThumbnailPosition = 50
+)
+
+// ExtractThumbnails is the job handler grabbing candidate thumbnails from a packaged video
+func (a *App) ExtractThumbnails(ctx context.Context, job *models.Job) error {
+	video, err := a.videoRepository.GetByID(ctx, job.VideoID)
+	if err != nil {
+		return err
+	}
+
+	source, err := a.bestRendition(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+	if source.Duration <= 0 {
+		return fmt.Errorf("video %s has no known duration", video.ID)
+	}
+
+	workDir, err := os.MkdirTemp("", "thumbnails-"+video.ID.String()+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "source"+path.Ext(source.ObjectKey))
+	if err := a.downloadToFile(ctx, source.ObjectKey, input); err != nil {
+		return err
+	}
+
+	offsets := make([]time.Duration, 0, len(transcode.ThumbnailPositions))
+	for _, position := range transcode.ThumbnailPositions {
+		offsets = append(offsets, time.Duration(source.Duration*float64(position)/100*float64(time.Second)))
+	}
+	files, err := a.thumbnailer.Thumbnails(ctx, input, workDir, offsets)
+	if err != nil {
+		return err
+	}
+
+	previous, err := a.thumbnailRepository.GetByVideoID(ctx, video.ID)
+	if err != nil {
+		return err
+	}
+
+	thumbnails := make([]models.Thumbnail, 0, len(files))
+	var selected uuid.UUID
+	for i, file := range files {
+		thumbnail := models.Thumbnail{
+			ID:       uuid.New(),
+			VideoID:  video.ID,
+			Position: transcode.ThumbnailPositions[i],
+			MimeType: "image/jpeg",
+		}
+		object, err := a.uploadFile(ctx, file, thumbnailObjectKey(video.ID, thumbnail.ID, ".jpg"), thumbnail.MimeType)
+		if err != nil {
+			return err
+		}
+		thumbnail.ObjectKey = object.Key
+		thumbnails = append(thumbnails, thumbnail)
+
+		if thumbnail.Position == defaultThumbnailPosition {
+			selected = thumbnail.ID
+		}
+	}
+
+	if err := a.thumbnailRepository.ReplaceCandidates(ctx, video.ID, thumbnails); err != nil {
+		return err
+	}
+
+	// keep a custom thumbnail chosen by the owner, otherwise point at the new default candidate
+	keepSelection := false
+	for _, thumbnail := range previous {
+		if thumbnail.Custom && video.ThumbnailID != nil && *video.ThumbnailID == thumbnail.ID {
+			keepSelection = true
+		}
+		if !thumbnail.Custom {
+			if err := a.blobStore.Delete(ctx, thumbnail.ObjectKey); err != nil {
+				a.logger.Error().Err(err).Str("key", thumbnail.ObjectKey).Msg("Failed to delete replaced thumbnail")
+			}
+		}
+	}
+	if keepSelection || selected == uuid.Nil {
+		return nil
+	}
+	return a.videoRepository.UpdateThumbnail(ctx, video.ID, selected)
+}
+
+// SelectThumbnail makes one of the thumbnails of a video the one shown to viewers
+func (a *App) SelectThumbnail(ctx context.Context, videoID, userID, thumbnailID uuid.UUID) (*models.Thumbnail, error) {
+	video, err := a.getOwnedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbnail, err := a.thumbnailRepository.GetByID(ctx, thumbnailID)
+	if err != nil {
+		return nil, err
+	}
+	if thumbnail.VideoID != video.ID {
+		return nil, helpers.ErrRecordNotFound
+	}
+
+	if err := a.videoRepository.UpdateThumbnail(ctx, video.ID, thumbnail.ID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to select thumbnail")
+		return nil, err
+	}
+	thumbnail.URL = models.ThumbnailURL(video.ID, thumbnail.ID)
+	return thumbnail, nil
+}
+
+// UploadThumbnail stores a custom JPEG or PNG thumbnail and selects it
+func (a *App) UploadThumbnail(ctx context.Context, videoID, userID uuid.UUID, file io.Reader) (*models.Thumbnail, error) {
+	video, err := a.getOwnedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, maxThumbnailSize+1))
+	if err != nil {
+		return nil, helpers.ErrUploadFailed
+	}
+	if len(content) > maxThumbnailSize {
+		return nil, helpers.ErrUploadTooLarge
+	}
+
+	var ext string
+	mimeType := http.DetectContentType(content)
+	switch mimeType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/png":
+		ext = ".png"
+	default:
+		return nil, helpers.ErrUnsupportedImageType
+	}
+
+	thumbnail := models.Thumbnail{
+		ID:       uuid.New(),
+		VideoID:  video.ID,
+		Custom:   true,
+		MimeType: mimeType,
+	}
+	thumbnail.ObjectKey = thumbnailObjectKey(video.ID, thumbnail.ID, ext)
+	if _, err := a.blobStore.Put(ctx, thumbnail.ObjectKey, bytes.NewReader(content), mimeType); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to store thumbnail")
+		return nil, helpers.ErrUploadFailed
+	}
+
+	newThumbnail, err := a.thumbnailRepository.Create(ctx, thumbnail)
+	if err != nil {
+		_ = a.blobStore.Delete(ctx, thumbnail.ObjectKey)
+		return nil, err
+	}
+	if err := a.videoRepository.UpdateThumbnail(ctx, video.ID, newThumbnail.ID); err != nil {
+		return nil, err
+	}
+	newThumbnail.URL = models.ThumbnailURL(video.ID, newThumbnail.ID)
+	return newThumbnail, nil
+}
+
+// GetThumbnail opens the image of a thumbnail of a video viewerID can watch
+func (a *App) GetThumbnail(ctx context.Context, videoID, thumbnailID, viewerID uuid.UUID) (io.ReadSeekCloser, *storage.Object, *models.Thumbnail, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if !canWatch(video, viewerID) {
+		return nil, nil, nil, helpers.ErrRecordNotFound
+	}
+
+	thumbnail, err := a.thumbnailRepository.GetByID(ctx, thumbnailID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if thumbnail.VideoID != video.ID {
+		return nil, nil, nil, helpers.ErrRecordNotFound
+	}
+
+	reader, object, err := a.openBlob(ctx, thumbnail.ObjectKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return reader, object, thumbnail, nil
+}
+
+// withThumbnails fills in the thumbnail URL of each video
+func withThumbnails(videos ...*models.Video) {
+	for _, video := range videos {
+		if video.ThumbnailID != nil {
+			video.ThumbnailURL = models.ThumbnailURL(video.ID, *video.ThumbnailID)
+		}
+	}
+}
+
+// getOwnedVideo loads a video and makes sure userID is its owner
+func (a *App) getOwnedVideo(ctx context.Context, videoID, userID uuid.UUID) (*models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+	if video.UserID != userID {
+		return nil, helpers.ErrForbidden
+	}
+	return video, nil
+}
+
+// bestRendition returns the highest resolution rendition that has a progressive file
+func (a *App) bestRendition(ctx context.Context, videoID uuid.UUID) (*models.Rendition, error) {
+	renditions, err := a.renditionRepository.GetByVideoID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *models.Rendition
+	for _, rendition := range renditions {
+		if rendition.Kind == models.RenditionKindVideo && rendition.ObjectKey != "" {
+			best = rendition
+		}
+	}
+	if best == nil {
+		return nil, helpers.ErrRecordNotFound
+	}
+	return best, nil
+}
+
+func thumbnailObjectKey(videoID, thumbnailID uuid.UUID, ext string) string {
+	return fmt.Sprintf("videos/%s/thumbnails/%s%s", videoID.String(), thumbnailID.String(), ext)
+}
@@ -0,0 +1,48 @@
+// token.go
+
+package app
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// SaveRefreshToken records a refresh token issued on login, it starts a new token family
+func (a *App) SaveRefreshToken(ctx context.Context, token models.RefreshToken) error {
+	if _, err := a.refreshTokenRepository.Create(ctx, token); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to save refresh token")
+		return err
+	}
+	return nil
+}
+
+// RotateRefreshToken exchanges the refresh token currentID for next. Every refresh token is single
+// use, a second use revokes its whole family and session and fails with helpers.ErrRefreshTokenReused.
+func (a *App) RotateRefreshToken(ctx context.Context, currentID uuid.UUID, next models.RefreshToken) error {
+	_, err := a.refreshTokenRepository.Rotate(ctx, currentID, next)
+	switch {
+	case errors.Is(err, helpers.ErrRefreshTokenReused):
+		// the access tokens of the family are revoked too, whoever holds them may have stolen the token
+		if revokeErr := a.revokeSessionFamily(ctx, next.FamilyID); revokeErr != nil {
+			return revokeErr
+		}
+		a.logger.Warn().Str("user", next.UserID.String()).Msg("Refresh token reused, signed out the token family")
+		return err
+	case errors.Is(err, helpers.ErrInvalidRefreshToken):
+		return err
+	case err != nil:
+		a.logger.Error().Err(err).Msg("Failed to rotate refresh token")
+		return err
+	}
+
+	// the session lives on with the new refresh token
+	if err := a.sessionRepository.Extend(ctx, next.FamilyID, next.ExpiresAt); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to extend session")
+	}
+	return nil
+}
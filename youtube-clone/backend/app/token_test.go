@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/repository"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// rotatingRefreshTokens fails every rotation with err
+type rotatingRefreshTokens struct {
+	repository.RefreshTokenRepo
+	err error
+}
+
+func (r rotatingRefreshTokens) Rotate(ctx context.Context, currentID uuid.UUID, next models.RefreshToken) (*models.RefreshToken, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &next, nil
+}
+
+// recordingSessions records the revoked session families
+type recordingSessions struct {
+	repository.SessionRepo
+	revoked []uuid.UUID
+}
+
+func (s *recordingSessions) Extend(ctx context.Context, familyID uuid.UUID, expiresAt time.Time) error {
+	return nil
+}
+
+func (s *recordingSessions) RevokeByFamilyID(ctx context.Context, familyID uuid.UUID) error {
+	s.revoked = append(s.revoked, familyID)
+	return nil
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	tests := []struct {
+		name        string
+		rotateErr   error
+		wantErr     error
+		wantRevoked bool
+	}{
+		{name: "first use"},
+		{name: "reuse revokes the family", rotateErr: helpers.ErrRefreshTokenReused, wantErr: helpers.ErrRefreshTokenReused, wantRevoked: true},
+		{name: "invalid token", rotateErr: helpers.ErrInvalidRefreshToken, wantErr: helpers.ErrInvalidRefreshToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := &recordingSessions{}
+			a := &App{
+				logger:                 zerolog.Nop(),
+				refreshTokenRepository: rotatingRefreshTokens{err: tt.rotateErr},
+				sessionRepository:      sessions,
+				revocations:            newRevocationCache(),
+			}
+			userID, familyID, accessTokenID := uuid.New(), uuid.New(), uuid.New()
+			issuedAt := time.Now()
+
+			err := a.RotateRefreshToken(context.Background(), uuid.New(), models.RefreshToken{
+				ID:        uuid.New(),
+				UserID:    userID,
+				FamilyID:  familyID,
+				ExpiresAt: issuedAt.Add(time.Hour),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RotateRefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+
+			// the access token issued with the family is rejected once the family is revoked
+			if got := a.IsTokenRevoked(context.Background(), accessTokenID, familyID, userID.String(), issuedAt); got != tt.wantRevoked {
+				t.Errorf("IsTokenRevoked() = %v, want %v", got, tt.wantRevoked)
+			}
+			if got := len(sessions.revoked) == 1 && sessions.revoked[0] == familyID; got != tt.wantRevoked {
+				t.Errorf("revoked sessions = %v, want the family revoked: %v", sessions.revoked, tt.wantRevoked)
+			}
+		})
+	}
+}
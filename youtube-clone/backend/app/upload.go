@@ -0,0 +1,376 @@
+// upload.go
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	defaultUploadMaxSize   = int64(4 << 30) // 4 GiB
+	defaultUploadExpiry    = 24 * time.Hour
+	expiredUploadBatchSize = 100
+)
+
+// UploadMaxSize returns the largest upload accepted, configured through UPLOAD_MAX_SIZE in bytes
+func (a *App) UploadMaxSize() int64 {
+	size, err := strconv.ParseInt(a.env.UploadMaxSize, 10, 64)
+	if err != nil || size <= 0 {
+		return defaultUploadMaxSize
+	}
+	return size
+}
+
+// uploadExpiry returns how long an unfinished upload is kept, configured through UPLOAD_EXPIRY in hours
+func (a *App) uploadExpiry() time.Duration {
+	ttl, err := strconv.Atoi(a.env.UploadExpiry)
+	if err != nil || ttl <= 0 {
+		return defaultUploadExpiry
+	}
+	return time.Hour * time.Duration(ttl)
+}
+
+// CreateUpload registers a new resumable upload
+func (a *App) CreateUpload(ctx context.Context, upload models.Upload) (*models.Upload, error) {
+	if upload.Length > a.UploadMaxSize() {
+		return nil, helpers.ErrUploadTooLarge
+	}
+	// fail before any bytes are sent rather than when the upload is finalized
+	if upload.ChannelID != nil {
+		if _, err := a.getOwnedChannel(ctx, *upload.ChannelID, upload.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	upload.ID = uuid.New()
+	upload.Offset = 0
+	upload.ExpiresAt = time.Now().Add(a.uploadExpiry())
+
+	newUpload, err := a.uploadRepository.Create(ctx, upload)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create upload")
+		return nil, err
+	}
+	return newUpload, nil
+}
+
+// GetUpload retrieves an upload owned by userID
+func (a *App) GetUpload(ctx context.Context, uploadID, userID uuid.UUID) (*models.Upload, error) {
+	upload, err := a.uploadRepository.GetByID(ctx, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.UserID != userID {
+		return nil, helpers.ErrRecordNotFound
+	}
+	if time.Now().After(upload.ExpiresAt) {
+		return nil, helpers.ErrUploadExpired
+	}
+	return upload, nil
+}
+
+// WriteUploadChunk appends chunk to the upload at offset. length is the declared size of chunk, or -1
+// when unknown. A chunk reaching past the length of the upload is rejected as a whole. Once the last
+// byte has been received the upload is assembled and turned into a video through CreateVideo.
+func (a *App) WriteUploadChunk(ctx context.Context, uploadID, userID uuid.UUID, offset, length int64, chunk io.Reader) (*models.Upload, error) {
+	unlock, ok := a.lockUpload(uploadID)
+	if !ok {
+		return nil, helpers.ErrUploadLocked
+	}
+	defer unlock()
+
+	upload, err := a.GetUpload(ctx, uploadID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Offset != offset {
+		return nil, helpers.ErrUploadOffsetMismatch
+	}
+	remaining := upload.Length - upload.Offset
+	if length > remaining {
+		return nil, helpers.ErrUploadTooLarge
+	}
+
+	if !upload.IsComplete() {
+		// keep whatever arrived before the client went away, the next PATCH resumes from there.
+		// One byte more than remaining is read to notice a chunk without a declared length overflowing.
+		received := &partialReader{r: io.LimitReader(chunk, remaining+1)}
+		key := uploadPartKey(upload.ID, upload.Parts+1)
+		object, err := a.blobStore.Put(context.WithoutCancel(ctx), key, received, "application/octet-stream")
+		if err != nil {
+			a.logger.Error().Err(err).Str("key", key).Msg("Failed to store upload chunk")
+			return nil, helpers.ErrUploadFailed
+		}
+
+		if object.Size > remaining {
+			_ = a.blobStore.Delete(ctx, key)
+			return nil, helpers.ErrUploadTooLarge
+		}
+		if object.Size == 0 {
+			_ = a.blobStore.Delete(ctx, key)
+		} else {
+			if err := a.uploadRepository.UpdateOffset(ctx, upload.ID, upload.Offset, upload.Offset+object.Size, upload.Parts+1); err != nil {
+				_ = a.blobStore.Delete(ctx, key)
+				return nil, err
+			}
+			upload.Offset += object.Size
+			upload.Parts++
+		}
+
+		if received.err != nil {
+			a.logger.Warn().Err(received.err).Str("upload", upload.ID.String()).Msg("Upload chunk interrupted")
+			return upload, nil
+		}
+	}
+
+	if upload.IsComplete() && upload.VideoID == nil {
+		if err := a.finalizeUpload(context.WithoutCancel(ctx), upload); err != nil {
+			return nil, err
+		}
+	}
+	return upload, nil
+}
+
+// finalizeUpload streams every stored part into the final video object
+func (a *App) finalizeUpload(ctx context.Context, upload *models.Upload) error {
+	parts := &partsReader{ctx: ctx, app: a, upload: upload}
+	defer parts.Close()
+
+	video, err := a.UploadVideo(ctx, models.Video{
+		UserID:      upload.UserID,
+		Title:       upload.Title,
+		Description: upload.Description,
+		Visibility:  upload.Visibility,
+		PublishAt:   upload.PublishAt,
+		ChannelID:   upload.ChannelID,
+	}, parts, upload.FileName, upload.ContentType)
+	if err != nil {
+		if errors.Is(err, helpers.ErrUnsupportedMediaType) {
+			// the content will never become acceptable, so there is nothing worth resuming
+			_ = a.removeUpload(ctx, upload)
+		}
+		return err
+	}
+
+	if err := a.uploadRepository.Complete(ctx, upload.ID, video.ID); err != nil {
+		a.logger.Error().Err(err).Str("upload", upload.ID.String()).Msg("Failed to mark upload as complete")
+		return err
+	}
+	a.deleteUploadParts(ctx, upload)
+
+	upload.VideoID = &video.ID
+	upload.Parts = 0
+	return nil
+}
+
+// TerminateUpload discards an upload and all the bytes received so far
+func (a *App) TerminateUpload(ctx context.Context, uploadID, userID uuid.UUID) error {
+	unlock, ok := a.lockUpload(uploadID)
+	if !ok {
+		return helpers.ErrUploadLocked
+	}
+	defer unlock()
+
+	upload, err := a.GetUpload(ctx, uploadID, userID)
+	if err != nil {
+		return err
+	}
+	return a.removeUpload(ctx, upload)
+}
+
+// ExpireUploads removes every upload whose expiry is before now and returns how many were removed.
+// Uploads a request is still writing to are left for the next run.
+func (a *App) ExpireUploads(ctx context.Context, now time.Time) (int, error) {
+	removed, busy := 0, 0
+	for {
+		uploads, err := a.uploadRepository.GetExpired(ctx, now, busy+expiredUploadBatchSize)
+		if err != nil {
+			return removed, err
+		}
+		busy = 0
+		for _, upload := range uploads {
+			ok, err := a.expireUpload(ctx, upload.ID, now)
+			if err != nil {
+				return removed, err
+			}
+			if ok {
+				removed++
+			} else {
+				busy++
+			}
+		}
+		if len(uploads)-busy < expiredUploadBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+// expireUpload removes an expired upload unless a request holds its lock
+func (a *App) expireUpload(ctx context.Context, uploadID uuid.UUID, now time.Time) (bool, error) {
+	unlock, ok := a.uploadLocks.lock(uploadID)
+	if !ok {
+		return false, nil
+	}
+	defer unlock()
+
+	// the parts stored while the batch was read are only known from the current row
+	upload, err := a.uploadRepository.GetByID(ctx, uploadID)
+	if errors.Is(err, helpers.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !upload.ExpiresAt.Before(now) {
+		return false, nil
+	}
+	return true, a.removeUpload(ctx, upload)
+}
+
+// RunUploadJanitor expires stale uploads every interval until ctx is cancelled
+func (a *App) RunUploadJanitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			removed, err := a.ExpireUploads(ctx, now)
+			if err != nil {
+				a.logger.Error().Err(err).Msg("Failed to expire uploads")
+				continue
+			}
+			if removed > 0 {
+				a.logger.Info().Int("removed", removed).Msg("Expired stale uploads")
+			}
+		}
+	}
+}
+
+func (a *App) removeUpload(ctx context.Context, upload *models.Upload) error {
+	a.deleteUploadParts(ctx, upload)
+	if err := a.uploadRepository.DeleteByID(ctx, upload.ID); err != nil {
+		a.logger.Error().Err(err).Str("upload", upload.ID.String()).Msg("Failed to delete upload")
+		return err
+	}
+	return nil
+}
+
+func (a *App) deleteUploadParts(ctx context.Context, upload *models.Upload) {
+	for part := 1; part <= upload.Parts; part++ {
+		key := uploadPartKey(upload.ID, part)
+		if err := a.blobStore.Delete(ctx, key); err != nil {
+			a.logger.Error().Err(err).Str("key", key).Msg("Failed to delete upload part")
+		}
+	}
+}
+
+// lockUpload makes sure only one request writes to an upload at a time
+func (a *App) lockUpload(uploadID uuid.UUID) (func(), bool) {
+	return a.uploadLocks.lock(uploadID)
+}
+
+// uploadLocks holds the uploads somebody is working on. An upload is only in the map while it is
+// locked, so removing an upload leaves nothing behind and never hands out a second lock.
+type uploadLocks struct {
+	mu     sync.Mutex
+	locked map[uuid.UUID]struct{}
+}
+
+// lock locks uploadID without waiting, ok is false while somebody else holds the lock
+func (l *uploadLocks) lock(uploadID uuid.UUID) (unlock func(), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, locked := l.locked[uploadID]; locked {
+		return nil, false
+	}
+	if l.locked == nil {
+		l.locked = map[uuid.UUID]struct{}{}
+	}
+	l.locked[uploadID] = struct{}{}
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.locked, uploadID)
+	}, true
+}
+
+func uploadPartKey(uploadID uuid.UUID, part int) string {
+	return fmt.Sprintf("uploads/%s/part-%06d", uploadID.String(), part)
+}
+
+// partialReader turns a read error into EOF so the bytes received before it can still be stored.
+// The original error is kept in err.
+type partialReader struct {
+	r   io.Reader
+	err error
+}
+
+func (p *partialReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if err != nil && !errors.Is(err, io.EOF) {
+		p.err = err
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// partsReader reads the parts of an upload back to back, opening one part at a time
+type partsReader struct {
+	ctx     context.Context
+	app     *App
+	upload  *models.Upload
+	part    int
+	current io.ReadCloser
+}
+
+func (p *partsReader) Read(b []byte) (int, error) {
+	for {
+		if p.current == nil {
+			if p.part >= p.upload.Parts {
+				return 0, io.EOF
+			}
+			p.part++
+			current, err := p.app.blobStore.Get(p.ctx, uploadPartKey(p.upload.ID, p.part))
+			if err != nil {
+				return 0, err
+			}
+			p.current = current
+		}
+
+		n, err := p.current.Read(b)
+		if errors.Is(err, io.EOF) {
+			_ = p.current.Close()
+			p.current = nil
+			if n == 0 {
+				continue
+			}
+			return n, nil
+		}
+		return n, err
+	}
+}
+
+func (p *partsReader) Close() error {
+	if p.current == nil {
+		return nil
+	}
+	err := p.current.Close()
+	p.current = nil
+	return err
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUploadLocks(t *testing.T) {
+	var locks uploadLocks
+	first, second := uuid.New(), uuid.New()
+
+	unlock, ok := locks.lock(first)
+	if !ok {
+		t.Fatal("lock() of an unlocked upload failed")
+	}
+	if _, ok := locks.lock(first); ok {
+		t.Error("lock() of a locked upload succeeded")
+	}
+	unlockSecond, ok := locks.lock(second)
+	if !ok {
+		t.Error("lock() of another upload failed")
+	}
+
+	unlock()
+	if len(locks.locked) != 1 {
+		t.Errorf("%d uploads left locked, want 1", len(locks.locked))
+	}
+	relock, ok := locks.lock(first)
+	if !ok {
+		t.Error("lock() after unlock() failed")
+	}
+
+	relock()
+	unlockSecond()
+	if len(locks.locked) != 0 {
+		t.Errorf("%d uploads left locked after unlocking all, want 0", len(locks.locked))
+	}
+}
@@ -4,9 +4,12 @@ package app
 
 import (
 	"context"
+	"errors"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/joshua468/youtube-clone/backend/repository"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
 )
 
@@ -21,21 +24,63 @@ func (a *App) GetUserByID(ctx *gin.Context, userID uuid.UUID) (*models.User, err
 }
 
 // CreateUser creates a new user
-func (a *App) CreateUser(ctx *gin.Context, userRequest models.CreateUserRequest) (*models.User, error) {
-	user, err := a.userRepository.CreateUser(ctx, userRequest)
+func (a *App) CreateUser(ctx *gin.Context, userRequest models.SignupRequest) (*models.User, error) {
+	user, err := a.userRepository.CreateUser(ctx, models.User{
+		ID:       uuid.New(),
+		Username: userRequest.Username,
+		Email:    userRequest.Email,
+		Password: helpers.Password(userRequest.Password).Hash().String(),
+	})
 	if err != nil {
 		a.logger.Error().Err(err).Msg("Failed to create user")
 		return nil, err
 	}
+
+	// not fatal, EnsureWatchLater creates it on first use
+	if err := a.createWatchLater(ctx, user.ID); err != nil {
+		a.logger.Warn().Err(err).Str("userID", user.ID.String()).Msg("Watch later playlist not created at signup")
+	}
 	return user, nil
 }
 
 // Login performs user login
 func (a *App) Login(ctx *gin.Context, loginReq models.LoginRequest) (*models.User, error) {
-	user, err := a.userRepository.Login(ctx, loginReq)
+	user, err := a.userRepository.GetUserByEmail(ctx, loginReq.Email)
 	if err != nil {
 		a.logger.Error().Err(err).Msg("Failed to login")
 		return nil, err
 	}
+	if !helpers.Password(user.Password).Check(helpers.Password(loginReq.Password)) {
+		return nil, helpers.ErrRecordNotFound
+	}
 	return user, nil
 }
+
+// UserExistsByEmail reports whether a user already signed up with email
+func (a *App) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
+	_, err := a.userRepository.GetUserByEmail(ctx, email)
+	if errors.Is(err, helpers.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetUsers lists every user
+func (a *App) GetUsers(ctx context.Context, page helpers.Page) ([]*models.User, helpers.PageInfo, error) {
+	users, pageInfo, err := a.userRepository.GetAllUsers(ctx, models.User{}, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get users")
+		return nil, helpers.PageInfo{}, err
+	}
+	return users, pageInfo, nil
+}
+
+// SearchUsers lists the users whose username starts with query
+func (a *App) SearchUsers(ctx context.Context, query string, page helpers.Page) ([]*models.User, helpers.PageInfo, error) {
+	users, pageInfo, err := a.userRepository.SearchUsers(ctx, query, page)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to search users")
+		return nil, helpers.PageInfo{}, err
+	}
+	return users, pageInfo, nil
+}
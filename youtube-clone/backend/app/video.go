@@ -3,39 +3,219 @@
 package app
 
 import (
+	"bufio"
 	"context"
-	"github.com/gin-gonic/gin"
-	"github.com/joshua468/youtube-clone/backend/repository"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/transcode"
 	"github.com/joshua468/youtube-clone/backend/utils/helpers"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
 )
 
 // CreateVideo creates a new video
 func (a *App) CreateVideo(ctx context.Context, video models.Video) (*models.Video, error) {
-	newVideo, err := a.videoRepository.CreateVideo(ctx, video)
+	newVideo, err := a.videoRepository.Create(ctx, video)
 	if err != nil {
 		a.logger.Error().Err(err).Msg("Failed to create video")
 		return nil, err
 	}
+	a.reindexVideos(ctx, newVideo.ID)
 	return newVideo, nil
 }
 
-// GetVideos retrieves a list of videos
-func (a *App) GetVideos(ctx context.Context, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
-	videos, pageInfo, err := a.videoRepository.GetVideos(ctx, page)
+// UpdateVideo changes the title, description and tags of a video
+func (a *App) UpdateVideo(ctx context.Context, videoID uuid.UUID, req models.UpdateVideoRequest) (*models.Video, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	video.Title = req.Title
+	video.Description = req.Content
+	if req.Tags != nil {
+		video.Tags = req.Tags
+	}
+	video.UpdatedAt = time.Now()
+	if _, err := a.videoRepository.UpdateVideo(ctx, *video); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update video")
+		return nil, err
+	}
+	a.reindexVideos(ctx, video.ID)
+
+	withThumbnails(video)
+	return video, nil
+}
+
+// UploadVideo streams an uploaded media file into the blob store and records it as a new video
+func (a *App) UploadVideo(ctx context.Context, video models.Video, file io.Reader, fileName, contentType string) (*models.Video, error) {
+	// sniff the first bytes instead of trusting the client supplied content type
+	reader := bufio.NewReaderSize(file, sniffLength)
+	head, err := reader.Peek(sniffLength)
+	if err != nil && !errors.Is(err, io.EOF) {
+		a.logger.Error().Err(err).Msg("Failed to read upload header")
+		return nil, helpers.ErrUploadFailed
+	}
+	mimeType, ok := detectVideoMimeType(head, contentType)
+	if !ok {
+		return nil, helpers.ErrUnsupportedMediaType
+	}
+
+	// keep a local copy while storing the upload so it can be probed before it is accepted
+	probeFile, err := os.CreateTemp("", "probe-*"+filepath.Ext(fileName))
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to create probe file")
+		return nil, helpers.ErrUploadFailed
+	}
+	defer os.Remove(probeFile.Name())
+	defer probeFile.Close()
+
+	if video.Visibility == "" {
+		video.Visibility = models.VideoVisibilityPublic
+	}
+	video.ChannelID, err = a.uploadChannel(ctx, video.UserID, video.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	video.ID = uuid.New()
+	key := storage.VideoObjectKey(video.ID, filepath.Ext(fileName))
+	object, err := a.blobStore.Put(ctx, key, io.TeeReader(reader, probeFile), mimeType)
+	if err != nil {
+		a.logger.Error().Err(err).Str("key", key).Msg("Failed to store video file")
+		return nil, helpers.ErrUploadFailed
+	}
+
+	info, err := a.probeVideo(ctx, probeFile.Name())
+	if err != nil {
+		if delErr := a.blobStore.Delete(ctx, key); delErr != nil {
+			a.logger.Error().Err(delErr).Str("key", key).Msg("Failed to remove rejected video file")
+		}
+		return nil, err
+	}
+	applyMediaInfo(&video, info)
+
+	video.ObjectKey = object.Key
+	video.Size = object.Size
+	video.MimeType = mimeType
+	video.Checksum = object.Checksum
+	video.Status = models.VideoStatusProcessing
+
+	newVideo, err := a.CreateVideo(ctx, video)
+	if err != nil {
+		// do not leave orphaned bytes behind when the row could not be written
+		if delErr := a.blobStore.Delete(ctx, key); delErr != nil {
+			a.logger.Error().Err(delErr).Str("key", key).Msg("Failed to remove orphaned video file")
+		}
+		return nil, err
+	}
+
+	if err := a.enqueueJob(ctx, newVideo.ID, models.JobTypeTranscode); err != nil {
+		a.markVideoFailed(ctx, newVideo.ID)
+		newVideo.Status = models.VideoStatusFailed
+	}
+	return newVideo, nil
+}
+
+// GetVideoByID retrieves a single video if viewerID may see it, together with the rating viewerID
+// gave it and where they stopped watching
+func (a *App) GetVideoByID(ctx context.Context, videoID, viewerID uuid.UUID) (*models.Video, error) {
+	video, err := a.videoRepository.GetVisibleByID(ctx, videoID, viewerID)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get video by ID")
+		return nil, err
+	}
+
+	thumbnails, err := a.thumbnailRepository.GetByVideoID(ctx, video.ID)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get video thumbnails")
+		return nil, err
+	}
+	for _, thumbnail := range thumbnails {
+		thumbnail.URL = models.ThumbnailURL(video.ID, thumbnail.ID)
+	}
+	video.Thumbnails = thumbnails
+	withThumbnails(video)
+
+	if err := a.withViewerRating(ctx, video, viewerID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get viewer rating")
+		return nil, err
+	}
+	if err := a.withResumePosition(ctx, video, viewerID); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to get resume position")
+		return nil, err
+	}
+	return video, nil
+}
+
+// GetVideos retrieves the videos listed for viewerID
+func (a *App) GetVideos(ctx context.Context, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+	videos, pageInfo, err := a.videoRepository.GetAllVideos(ctx, models.Video{}, viewerID, page)
 	if err != nil {
 		a.logger.Error().Err(err).Msg("Failed to get videos")
 		return nil, helpers.PageInfo{}, err
 	}
+	withThumbnails(videos...)
 	return videos, pageInfo, nil
 }
 
-// GetUserVideos retrieves videos for a specific user
-func (a *App) GetUserVideos(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
-	videos, pageInfo, err := a.videoRepository.GetUserVideos(ctx, userID, page)
+// GetUserVideos retrieves the videos of a specific user listed for viewerID
+func (a *App) GetUserVideos(ctx context.Context, userID, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+	videos, pageInfo, err := a.videoRepository.GetAllVideos(ctx, models.Video{UserID: userID}, viewerID, page)
 	if err != nil {
 		a.logger.Error().Err(err).Msg("Failed to get user videos")
 		return nil, helpers.PageInfo{}, err
 	}
+	withThumbnails(videos...)
 	return videos, pageInfo, nil
 }
+
+// probeVideo reads the technical metadata of an upload, mapping rejections to the helpers errors
+func (a *App) probeVideo(ctx context.Context, file string) (*transcode.MediaInfo, error) {
+	info, err := a.prober.Probe(ctx, file)
+	switch {
+	case errors.Is(err, transcode.ErrUnsupportedMedia):
+		a.logger.Info().Err(err).Msg("Rejected unsupported video")
+		return nil, helpers.ErrUnsupportedMediaType
+	case errors.Is(err, transcode.ErrInvalidMedia):
+		a.logger.Info().Err(err).Msg("Rejected corrupt video")
+		return nil, helpers.ErrUnsupportedMediaType
+	case err != nil:
+		a.logger.Error().Err(err).Msg("Failed to probe video")
+		return nil, helpers.ErrUploadFailed
+	}
+	return info, nil
+}
+
+func applyMediaInfo(video *models.Video, info *transcode.MediaInfo) {
+	video.Duration = info.Duration
+	video.Width = info.Width
+	video.Height = info.Height
+	video.FrameRate = info.FrameRate
+	video.VideoCodec = info.VideoCodec
+	video.AudioCodec = info.AudioCodec
+	video.Bitrate = info.Bitrate
+	video.Container = info.Container
+}
+
+const sniffLength = 512
+
+// detectVideoMimeType picks the MIME type of an upload from its content, falling back to the
+// declared type for containers net/http cannot sniff (e.g. QuickTime, Matroska)
+func detectVideoMimeType(head []byte, declared string) (string, bool) {
+	detected := http.DetectContentType(head)
+	if strings.HasPrefix(detected, "video/") {
+		return detected, true
+	}
+	if detected == "application/octet-stream" && strings.HasPrefix(declared, "video/") {
+		return declared, true
+	}
+	return "", false
+}
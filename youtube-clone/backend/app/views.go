@@ -0,0 +1,202 @@
+// views.go
+
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// maxViewDedupEntries bounds the memory held for deduplication. Once reached, views are still
+// counted but their viewers are not remembered, so a flood of fresh viewers cannot exhaust memory.
+const maxViewDedupEntries = 1_000_000
+
+// viewCounter deduplicates views and buffers the increments in memory, so recording a view
+// never writes to the database. RunViewFlusher writes the buffered counts in batches.
+// Deduplication is per process, a viewer hitting two instances may be counted twice.
+type viewCounter struct {
+	mu sync.Mutex
+	// viewers are remembered in two generations of at least one dedup window each. The older
+	// generation is dropped as a whole once all of its windows have passed, nothing is scanned.
+	current   map[string]time.Time // video and viewer -> end of the dedup window
+	previous  map[string]time.Time
+	rotatedAt time.Time
+	pending   map[uuid.UUID]int64
+}
+
+func newViewCounter() *viewCounter {
+	return &viewCounter{
+		current:  map[string]time.Time{},
+		previous: map[string]time.Time{},
+		pending:  map[uuid.UUID]int64{},
+	}
+}
+
+// add counts a view of videoID by viewer unless the viewer was already counted within window
+func (vc *viewCounter) add(videoID uuid.UUID, viewer string, now time.Time, window time.Duration) bool {
+	key := videoID.String() + "|" + viewer
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	vc.rotate(now, window)
+	if until, ok := vc.current[key]; ok && now.Before(until) {
+		return false
+	}
+	if until, ok := vc.previous[key]; ok && now.Before(until) {
+		return false
+	}
+	if len(vc.current)+len(vc.previous) < maxViewDedupEntries {
+		vc.current[key] = now.Add(window)
+	}
+	vc.pending[videoID]++
+	return true
+}
+
+// rotate starts a new generation once the current one is a window old. The entries of the
+// previous generation were added at least a window ago, so their windows have passed. vc.mu must be held.
+func (vc *viewCounter) rotate(now time.Time, window time.Duration) {
+	age := now.Sub(vc.rotatedAt)
+	if age < window {
+		return
+	}
+	if age < 2*window {
+		vc.previous = vc.current
+	} else {
+		vc.previous = map[string]time.Time{}
+	}
+	vc.current = map[string]time.Time{}
+	vc.rotatedAt = now
+}
+
+// take hands over the buffered counts
+func (vc *viewCounter) take() map[uuid.UUID]int64 {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	pending := vc.pending
+	vc.pending = map[uuid.UUID]int64{}
+	return pending
+}
+
+// restore puts counts that could not be written back into the buffer
+func (vc *viewCounter) restore(counts map[uuid.UUID]int64) {
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	for videoID, count := range counts {
+		vc.pending[videoID] += count
+	}
+}
+
+// viewMinWatch is how much of a video has to be played before it counts as a view, configured in seconds
+func (a *App) viewMinWatch() time.Duration {
+	seconds, err := strconv.Atoi(a.env.ViewMinWatch)
+	if err != nil || seconds <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// viewDedupWindow is how long repeated views of the same viewer are ignored, configured in minutes
+func (a *App) viewDedupWindow() time.Duration {
+	minutes, err := strconv.Atoi(a.env.ViewDedupWindow)
+	if err != nil || minutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// RecordView counts a view of a video once the player has played enough of it. Signed in viewers
+// are recognised by their user ID, anonymous ones by a hash of their network (see viewerFingerprint).
+// It reports whether the view was counted, repeated views within the dedup window are not.
+func (a *App) RecordView(ctx context.Context, videoID, viewerID uuid.UUID, clientIP string, watchedSeconds float64) (bool, error) {
+	video, err := a.videoRepository.GetByID(ctx, videoID)
+	if err != nil {
+		return false, err
+	}
+	if !canWatch(video, viewerID) {
+		return false, helpers.ErrRecordNotFound
+	}
+	if video.Status != models.VideoStatusReady {
+		return false, helpers.ErrVideoNotReady
+	}
+
+	// videos shorter than the minimum count once they have been played to the end
+	// the player reports the watched time, it cannot be more than the video is long
+	required := a.viewMinWatch().Seconds()
+	if video.Duration > 0 {
+		required = min(required, video.Duration)
+		watchedSeconds = min(watchedSeconds, video.Duration)
+	}
+	if watchedSeconds < required {
+		return false, helpers.ErrViewTooShort
+	}
+
+	return a.views.add(video.ID, viewerFingerprint(viewerID, clientIP), time.Now(), a.viewDedupWindow()), nil
+}
+
+// FlushViews writes the buffered views to the view counters, they are kept for the next flush on failure
+func (a *App) FlushViews(ctx context.Context) (int, error) {
+	counts := a.views.take()
+	if len(counts) == 0 {
+		return 0, nil
+	}
+
+	if err := a.videoRepository.IncrementViews(ctx, counts); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to flush views")
+		a.views.restore(counts)
+		return 0, err
+	}
+
+	// keep the popularity boost of the search ranking current
+	videoIDs := make([]uuid.UUID, 0, len(counts))
+	for videoID := range counts {
+		videoIDs = append(videoIDs, videoID)
+	}
+	a.reindexVideos(ctx, videoIDs...)
+	return len(counts), nil
+}
+
+// RunViewFlusher flushes the buffered views every interval until ctx is cancelled, then once more
+// so views recorded just before shutdown are not lost.
+func (a *App) RunViewFlusher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_, _ = a.FlushViews(context.Background())
+			return
+		case <-ticker.C:
+			_, _ = a.FlushViews(ctx)
+		}
+	}
+}
+
+// viewerFingerprint identifies a viewer for deduplication without keeping their IP address around.
+// Anonymous viewers are keyed by IP only, the user agent is chosen by the client and would let it
+// count a view per request. IPv6 clients usually own a whole /64, so the address is cut to that.
+func viewerFingerprint(viewerID uuid.UUID, clientIP string) string {
+	if viewerID != uuid.Nil {
+		return "user:" + viewerID.String()
+	}
+	network := clientIP
+	if ip := net.ParseIP(clientIP); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			network = ip4.String()
+		} else {
+			network = ip.Mask(net.CIDRMask(64, 128)).String()
+		}
+	}
+	sum := sha256.Sum256([]byte(network))
+	return "anon:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestViewCounterAdd(t *testing.T) {
+	const window = time.Hour
+	start := time.Now()
+	videoID := uuid.New()
+
+	tests := []struct {
+		name   string
+		viewer string
+		after  time.Duration // since start
+		want   bool
+	}{
+		{name: "first view", viewer: "a", want: true},
+		{name: "repeated within the window", viewer: "a", after: 30 * time.Minute, want: false},
+		{name: "other viewer", viewer: "b", after: 50 * time.Minute, want: true},
+		{name: "remembered across a rotation", viewer: "b", after: 70 * time.Minute, want: false},
+		{name: "window passed", viewer: "a", after: 70 * time.Minute, want: true},
+		{name: "window passed after two rotations", viewer: "b", after: 3 * time.Hour, want: true},
+	}
+	vc := newViewCounter()
+	var counted int64
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := vc.add(videoID, tt.viewer, start.Add(tt.after), window); got != tt.want {
+				t.Errorf("add() = %v, want %v", got, tt.want)
+			}
+			if tt.want {
+				counted++
+			}
+		})
+	}
+
+	if got := vc.take()[videoID]; got != counted {
+		t.Errorf("take() = %d views, want %d", got, counted)
+	}
+	if got := len(vc.take()); got != 0 {
+		t.Errorf("take() after take() = %d videos, want 0", got)
+	}
+}
@@ -0,0 +1,78 @@
+// visibility.go
+
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// SetVisibility changes who can see a video. PublishAt is the scheduled time of scheduled videos and
+// the time public videos were published, it is cleared for the other visibilities.
+func (a *App) SetVisibility(ctx context.Context, videoID, userID uuid.UUID, req models.UpdateVisibilityRequest) (*models.Video, error) {
+	video, err := a.getOwnedVideo(ctx, videoID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	publishAt := req.PublishAt
+	switch req.Visibility {
+	case models.VideoVisibilityScheduled:
+	case models.VideoVisibilityPublic:
+		// the search ranking fades by the publish time, republishing a public video keeps it
+		publishAt = video.PublishAt
+		if !video.IsPublic(now) {
+			publishAt = &now
+		}
+	default:
+		publishAt = nil
+	}
+	if err := a.videoRepository.UpdateVisibility(ctx, video.ID, req.Visibility, publishAt); err != nil {
+		a.logger.Error().Err(err).Msg("Failed to update video visibility")
+		return nil, err
+	}
+	a.reindexVideos(ctx, video.ID)
+
+	video.Visibility = req.Visibility
+	video.PublishAt = publishAt
+	withThumbnails(video)
+	return video, nil
+}
+
+// PublishScheduledVideos makes the scheduled videos whose time has come public
+func (a *App) PublishScheduledVideos(ctx context.Context, now time.Time) (int64, error) {
+	published, err := a.videoRepository.PublishScheduled(ctx, now)
+	if err != nil {
+		a.logger.Error().Err(err).Msg("Failed to publish scheduled videos")
+		return 0, err
+	}
+	return published, nil
+}
+
+// RunPublishScheduler publishes due scheduled videos every interval until ctx is cancelled.
+// Visibility checks already treat due videos as public, so the interval only bounds how long
+// the stored visibility lags behind.
+func (a *App) RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			published, err := a.PublishScheduledVideos(ctx, now)
+			if err != nil {
+				continue
+			}
+			if published > 0 {
+				a.logger.Info().Int64("published", published).Msg("Published scheduled videos")
+			}
+		}
+	}
+}
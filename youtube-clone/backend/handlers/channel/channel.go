@@ -0,0 +1,247 @@
+package channel
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const handlerNameChannel = "channel"
+
+type channelHandler struct {
+	logger     *zerolog.Logger
+	app        *app.App
+	env        *models.Env
+	middleware middlewares.Middleware
+}
+
+func NewChannelHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+	channel := channelHandler{
+		logger:     l,
+		app:        a,
+		env:        e,
+		middleware: m,
+	}
+
+	channelGroup := r.Group("/channel")
+
+	channelGroup.POST("", m.AuthMiddleware(false), channel.create())
+	channelGroup.GET("/mine", m.AuthMiddleware(false), channel.getMyChannels())
+	// :id is a channel ID or an @handle
+	channelGroup.GET("/:id", m.OptionalAuthMiddleware(), channel.getChannel())
+	channelGroup.GET("/:id/videos", m.OptionalAuthMiddleware(), channel.getChannelVideos())
+	channelGroup.PUT("/:id", m.AuthMiddleware(false), channel.update())
+	channelGroup.DELETE("/:id", m.AuthMiddleware(false), channel.delete())
+	channelGroup.PUT("/:id/subscription", m.AuthMiddleware(false), channel.subscribe())
+	channelGroup.DELETE("/:id/subscription", m.AuthMiddleware(false), channel.unsubscribe())
+}
+
+func (ch *channelHandler) create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.ChannelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		channel, err := ch.app.CreateChannel(c, userUUID, req)
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"message": "Channel created successfully", "channel": channel})
+	}
+}
+
+func (ch *channelHandler) getMyChannels() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		channels, err := ch.app.GetUserChannels(c, userUUID)
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"channels": channels})
+	}
+}
+
+func (ch *channelHandler) getChannel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		channel, err := ch.app.GetChannel(c, c.Param("id"), viewerUUID)
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"channel": channel})
+	}
+}
+
+func (ch *channelHandler) getChannelVideos() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		channel, err := ch.app.GetChannel(c, c.Param("id"), viewerUUID)
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		videos, pageInfo, err := ch.app.GetChannelVideos(c, channel.ID, viewerUUID, helpers.ParsePage(c, models.VideoSortColumns...))
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"videos": videos, "pageInfo": pageInfo})
+	}
+}
+
+func (ch *channelHandler) update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channelUUID, userUUID, ok := channelParams(c)
+		if !ok {
+			return
+		}
+
+		var req models.ChannelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		channel, err := ch.app.UpdateChannel(c, channelUUID, userUUID, req)
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Channel updated successfully", "channel": channel})
+	}
+}
+
+func (ch *channelHandler) delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channelUUID, userUUID, ok := channelParams(c)
+		if !ok {
+			return
+		}
+
+		if err := ch.app.DeleteChannel(c, channelUUID, userUUID); err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// subscribe subscribes the caller to the channel, or updates their notification preference
+func (ch *channelHandler) subscribe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channelUUID, userUUID, ok := channelParams(c)
+		if !ok {
+			return
+		}
+
+		var req models.SubscribeRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		subscription, err := ch.app.Subscribe(c, channelUUID, userUUID, req.Notifications)
+		if err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"subscription": subscription})
+	}
+}
+
+func (ch *channelHandler) unsubscribe() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		channelUUID, userUUID, ok := channelParams(c)
+		if !ok {
+			return
+		}
+
+		if err := ch.app.Unsubscribe(c, channelUUID, userUUID); err != nil {
+			ch.errorResponse(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// channelParams reads the channel ID from the path and the caller from the context
+func channelParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	channelUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channel ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return channelUUID, userUUID, true
+}
+
+func (ch *channelHandler) errorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, helpers.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+	case errors.Is(err, helpers.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change a channel"})
+	case errors.Is(err, helpers.ErrInvalidChannelHandle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Handle may only contain letters, digits, '_', '-' and '.'"})
+	case errors.Is(err, helpers.ErrChannelHandleTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Handle is already taken"})
+	case errors.Is(err, helpers.ErrSelfSubscription):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot subscribe to your own channel"})
+	default:
+		ch.logger.Err(err).Str("handler", handlerNameChannel).Msg("channel request failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process channel request"})
+	}
+}
@@ -0,0 +1,60 @@
+package feed
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const handlerNameFeed = "feed"
+
+type feedHandler struct {
+	logger     *zerolog.Logger
+	app        *app.App
+	env        *models.Env
+	middleware middlewares.Middleware
+}
+
+func NewFeedHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+	feed := feedHandler{
+		logger:     l,
+		app:        a,
+		env:        e,
+		middleware: m,
+	}
+
+	feedGroup := r.Group("/feed")
+
+	feedGroup.GET("/subscriptions", m.AuthMiddleware(false), feed.getSubscriptions())
+}
+
+// getSubscriptions lists the newest public videos of the channels the caller subscribed to
+func (f *feedHandler) getSubscriptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		// newest first, whatever sorting was asked for
+		page := helpers.ParsePage(c)
+		page.SortDirectionDesc = nil
+
+		videos, pageInfo, err := f.app.GetSubscriptionFeed(c, userUUID, page)
+		if err != nil {
+			f.logger.Err(err).Str("handler", handlerNameFeed).Msg("error fetching subscription feed")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch feed"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"videos": videos, "pageInfo": pageInfo})
+	}
+}
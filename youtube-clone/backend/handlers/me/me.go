@@ -0,0 +1,194 @@
+package me
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const handlerNameMe = "me"
+
+type meHandler struct {
+	logger     *zerolog.Logger
+	app        *app.App
+	env        *models.Env
+	middleware middlewares.Middleware
+}
+
+// NewMeHandler registers the routes acting on the data of the signed in user
+func NewMeHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+	me := meHandler{
+		logger:     l,
+		app:        a,
+		env:        e,
+		middleware: m,
+	}
+
+	meGroup := r.Group("/me", m.AuthMiddleware(false))
+
+	meGroup.GET("/history", me.getHistory())
+	meGroup.DELETE("/history", me.clearHistory())
+	meGroup.DELETE("/history/:videoID", me.removeFromHistory())
+	meGroup.GET("/history/settings", me.getHistorySettings())
+	meGroup.PUT("/history/settings", me.setHistorySettings())
+	meGroup.GET("/sessions", me.getSessions())
+	meGroup.DELETE("/sessions/:id", me.revokeSession())
+}
+
+func (h *meHandler) getHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		// most recently watched first, whatever sorting was asked for
+		page := helpers.ParsePage(c)
+		page.SortDirectionDesc = nil
+
+		history, pageInfo, err := h.app.GetHistory(c, userUUID, page)
+		if err != nil {
+			h.logger.Err(err).Str("handler", handlerNameMe).Msg("error fetching watch history")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"history": history, "pageInfo": pageInfo})
+	}
+}
+
+func (h *meHandler) clearHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		if err := h.app.ClearHistory(c, userUUID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear history"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (h *meHandler) removeFromHistory() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+		videoUUID, err := uuid.Parse(c.Param("videoID"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+
+		if err := h.app.RemoveFromHistory(c, userUUID, videoUUID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove video from history"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (h *meHandler) getHistorySettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		settings, err := h.app.GetHistorySettings(c, userUUID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history settings"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"settings": settings})
+	}
+}
+
+// setHistorySettings pauses or resumes the recording of the watch history
+func (h *meHandler) setHistorySettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.HistorySettingsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		settings, err := h.app.SetHistoryPaused(c, userUUID, req.Paused)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update history settings"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "History settings updated successfully", "settings": settings})
+	}
+}
+
+// getSessions lists the devices the user is signed in on, the one making the request is marked current
+func (h *meHandler) getSessions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+		var currentFamilyID uuid.UUID
+		if claims, ok := c.MustGet(middlewares.AccessClaimsInContext).(*middlewares.AccessClaims); ok {
+			currentFamilyID = claims.FamilyID
+		}
+
+		sessions, err := h.app.GetSessions(c, userUUID, currentFamilyID)
+		if err != nil {
+			h.logger.Err(err).Str("handler", handlerNameMe).Msg("error fetching sessions")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sessions"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+	}
+}
+
+// revokeSession signs a device out, revoking the current session logs the caller out
+func (h *meHandler) revokeSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+		sessionUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+			return
+		}
+
+		if err := h.app.RevokeSession(c, sessionUUID, userUUID); err != nil {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session"})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,368 @@
+package playlist
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const handlerNamePlaylist = "playlist"
+
+// playlistSortColumns are the columns playlist listings can be sorted by
+var playlistSortColumns = []string{"created_at", "updated_at", "title"}
+
+type playlistHandler struct {
+	logger     *zerolog.Logger
+	app        *app.App
+	env        *models.Env
+	middleware middlewares.Middleware
+}
+
+func NewPlaylistHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+	playlist := playlistHandler{
+		logger:     l,
+		app:        a,
+		env:        e,
+		middleware: m,
+	}
+
+	playlistGroup := r.Group("/playlist")
+
+	playlistGroup.POST("", m.AuthMiddleware(false), playlist.create())
+	playlistGroup.GET("/mine", m.AuthMiddleware(false), playlist.getMyPlaylists())
+	playlistGroup.GET("/:id/user", m.OptionalAuthMiddleware(), playlist.getUserPlaylists())
+	// :id is a playlist ID, or "watch-later" for the watch later playlist of the caller
+	playlistGroup.GET("/:id", m.OptionalAuthMiddleware(), playlist.getPlaylist())
+	playlistGroup.PUT("/:id", m.AuthMiddleware(false), playlist.update())
+	playlistGroup.DELETE("/:id", m.AuthMiddleware(false), playlist.delete())
+	playlistGroup.GET("/:id/items", m.OptionalAuthMiddleware(), playlist.getItems())
+	playlistGroup.POST("/:id/items", m.AuthMiddleware(false), playlist.addItem())
+	playlistGroup.DELETE("/:id/items/:itemID", m.AuthMiddleware(false), playlist.removeItem())
+	playlistGroup.PUT("/:id/items/:itemID/position", m.AuthMiddleware(false), playlist.moveItem())
+	playlistGroup.PUT("/:id/collaborators/:userID", m.AuthMiddleware(false), playlist.addCollaborator())
+	playlistGroup.DELETE("/:id/collaborators/:userID", m.AuthMiddleware(false), playlist.removeCollaborator())
+}
+
+func (p *playlistHandler) create() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.CreatePlaylistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		playlist, err := p.app.CreatePlaylist(c, userUUID, req)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Playlist created successfully", "playlist": playlist})
+	}
+}
+
+func (p *playlistHandler) getMyPlaylists() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		playlists, pageInfo, err := p.app.GetUserPlaylists(c, userUUID, userUUID, helpers.ParsePage(c, playlistSortColumns...))
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"playlists": playlists, "pageInfo": pageInfo})
+	}
+}
+
+func (p *playlistHandler) getUserPlaylists() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		playlists, pageInfo, err := p.app.GetUserPlaylists(c, userUUID, viewerUUID, helpers.ParsePage(c, playlistSortColumns...))
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"playlists": playlists, "pageInfo": pageInfo})
+	}
+}
+
+func (p *playlistHandler) getPlaylist() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		playlistUUID, ok := p.playlistParam(c, viewerUUID, false)
+		if !ok {
+			return
+		}
+
+		playlist, err := p.app.GetPlaylist(c, playlistUUID, viewerUUID)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"playlist": playlist})
+	}
+}
+
+func (p *playlistHandler) update() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+
+		var req models.UpdatePlaylistRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		playlist, err := p.app.UpdatePlaylist(c, playlistUUID, userUUID, req)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Playlist updated successfully", "playlist": playlist})
+	}
+}
+
+func (p *playlistHandler) delete() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+
+		if err := p.app.DeletePlaylist(c, playlistUUID, userUUID); err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *playlistHandler) getItems() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		playlistUUID, ok := p.playlistParam(c, viewerUUID, false)
+		if !ok {
+			return
+		}
+
+		// items always come in playlist order
+		page := helpers.ParsePage(c)
+		page.SortDirectionDesc = nil
+
+		items, pageInfo, err := p.app.GetPlaylistItems(c, playlistUUID, viewerUUID, page)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"items": items, "pageInfo": pageInfo})
+	}
+}
+
+func (p *playlistHandler) addItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+
+		var req models.AddPlaylistItemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		item, err := p.app.AddPlaylistItem(c, playlistUUID, userUUID, req.VideoID)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Video added to playlist", "item": item})
+	}
+}
+
+func (p *playlistHandler) removeItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+		itemUUID, err := uuid.Parse(c.Param("itemID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Playlist item not found"})
+			return
+		}
+
+		if err := p.app.RemovePlaylistItem(c, playlistUUID, itemUUID, userUUID); err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (p *playlistHandler) moveItem() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+		itemUUID, err := uuid.Parse(c.Param("itemID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Playlist item not found"})
+			return
+		}
+
+		var req models.MovePlaylistItemRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		item, err := p.app.MovePlaylistItem(c, playlistUUID, itemUUID, userUUID, *req.Position)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"item": item})
+	}
+}
+
+func (p *playlistHandler) addCollaborator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+		collaboratorUUID, err := uuid.Parse(c.Param("userID"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		playlist, err := p.app.AddPlaylistCollaborator(c, playlistUUID, userUUID, collaboratorUUID)
+		if err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Collaborator added successfully", "playlist": playlist})
+	}
+}
+
+func (p *playlistHandler) removeCollaborator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playlistUUID, userUUID, ok := p.playlistParams(c)
+		if !ok {
+			return
+		}
+		collaboratorUUID, err := uuid.Parse(c.Param("userID"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+			return
+		}
+
+		if err := p.app.RemovePlaylistCollaborator(c, playlistUUID, userUUID, collaboratorUUID); err != nil {
+			p.errorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// playlistParams extracts the signed in caller and the playlist addressed by the URL
+func (p *playlistHandler) playlistParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	playlistUUID, ok := p.playlistParam(c, userUUID, true)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return playlistUUID, userUUID, true
+}
+
+// playlistParam resolves the :id parameter, "watch-later" stands for the watch later playlist of viewerID.
+// Changes ensure the watch later playlist exists, reads only derive its ID.
+func (p *playlistHandler) playlistParam(c *gin.Context, viewerID uuid.UUID, ensure bool) (uuid.UUID, bool) {
+	if c.Param("id") != models.WatchLaterRef {
+		playlistUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid playlist ID"})
+			return uuid.Nil, false
+		}
+		return playlistUUID, true
+	}
+
+	if viewerID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return uuid.Nil, false
+	}
+	if !ensure {
+		return app.WatchLaterPlaylistID(viewerID), true
+	}
+	watchLater, err := p.app.EnsureWatchLater(c, viewerID)
+	if err != nil {
+		p.errorResponse(c, err)
+		return uuid.Nil, false
+	}
+	return watchLater.ID, true
+}
+
+func (p *playlistHandler) errorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, helpers.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Playlist or video not found"})
+	case errors.Is(err, helpers.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to change this playlist"})
+	case errors.Is(err, helpers.ErrWatchLaterPlaylist):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The watch later playlist cannot be renamed, shared or deleted"})
+	case errors.Is(err, helpers.ErrPlaylistItemExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "Video is already in the playlist"})
+	default:
+		p.logger.Err(err).Str("handler", handlerNamePlaylist).Msg("playlist request failed")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process playlist request"})
+	}
+}
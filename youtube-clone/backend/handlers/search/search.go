@@ -0,0 +1,89 @@
+package search
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	videosearch "github.com/joshua468/youtube-clone/backend/search"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const handlerNameSearch = "search"
+
+const (
+	defaultSuggestionLimit = 8
+	maxSuggestionLimit     = 10
+)
+
+type searchHandler struct {
+	logger     *zerolog.Logger
+	app        *app.App
+	env        *models.Env
+	middleware middlewares.Middleware
+}
+
+func NewSearchHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+	search := searchHandler{
+		logger:     l,
+		app:        a,
+		env:        e,
+		middleware: m,
+	}
+
+	searchGroup := r.Group("/search")
+
+	searchGroup.GET("", m.OptionalAuthMiddleware(), search.searchVideos())
+	searchGroup.GET("/suggest", search.suggest())
+}
+
+// searchVideos lists the public videos matching the q parameter, narrowed by the uploaded,
+// duration, channelID and tag parameters
+func (s *searchHandler) searchVideos() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.SearchRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		results, pageInfo, err := s.app.SearchVideos(c, req, viewerUUID, c.ClientIP(), helpers.ParsePage(c))
+		if err != nil {
+			if errors.Is(err, videosearch.ErrInvalidQuery) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to search for"})
+				return
+			}
+			s.logger.Err(err).Str("handler", handlerNameSearch).Msg("error searching videos")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search videos"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"results": results, "pageInfo": pageInfo})
+	}
+}
+
+// suggest completes the q parameter while the user is typing, at most limit suggestions are returned
+func (s *searchHandler) suggest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSuggestionLimit)))
+		if err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+
+		suggestions := s.app.SuggestQueries(c, c.Query("q"), min(limit, maxSuggestionLimit))
+		c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+	}
+}
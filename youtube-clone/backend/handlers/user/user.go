@@ -3,27 +3,29 @@ package user
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/joshua468/youtube-clone/app"
-	"github.com/joshua468/youtube-clone/logger"
-	"github.com/joshua468/youtube-clone/models"
-	"github.com/joshua468/youtube-clone/utils/helpers" // Import helpers package
-	"github.com/joshua468/youtube-clone/utils/middlewares"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
 )
 
 const handlerNameUser = "user"
 
 type userHandler struct {
-	logger     *logger.Logger
+	logger     *zerolog.Logger
 	app        *app.App
 	env        *models.Env
 	middleware middlewares.Middleware
 }
 
-func NewUserHandler(r *gin.RouterGroup, l *logger.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+func NewUserHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
 	user := userHandler{
 		app:        a,
 		env:        e,
@@ -35,6 +37,9 @@ func NewUserHandler(r *gin.RouterGroup, l *logger.Logger, a *app.App, e *models.
 
 	userGroup.POST("/login", user.login())
 	userGroup.POST("/signup", user.signup())
+	userGroup.POST("/token/refresh", user.refreshToken())
+	userGroup.POST("/logout", user.logout())
+	userGroup.POST("/logout/all", user.logoutAll())
 
 	userGroup.GET("/me", m.AuthMiddleware(false), user.me())
 	userGroup.GET("/all", m.AuthMiddleware(true), user.getUsers())
@@ -52,6 +57,13 @@ func (u *userHandler) signup() gin.HandlerFunc {
 		var req models.SignupRequest
 		var err error
 
+		if !u.middleware.ValidOrigin(c) {
+			models.ErrorResponse(c, http.StatusForbidden, models.ErrorData{
+				PublicMessage: "Request origin is not allowed",
+			})
+			return
+		}
+
 		// Retrieve request body and bind it to SignupRequest struct
 		if err := c.ShouldBindJSON(&req); err != nil {
 			u.logger.Err(err).Msg("error binding request body")
@@ -71,7 +83,7 @@ func (u *userHandler) signup() gin.HandlerFunc {
 		}
 
 		// Check if the email is already registered
-		if exists, err := u.app.UserExistsByEmail(req.Email); err != nil {
+		if exists, err := u.app.UserExistsByEmail(c, req.Email); err != nil {
 			u.logger.Err(err).Msg("error checking if user exists by email")
 			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
 				PublicMessage: "Failed to check email availability",
@@ -98,7 +110,7 @@ func (u *userHandler) signup() gin.HandlerFunc {
 		user.Password = "********"
 
 		// Generate JWT token for the newly created user
-		token, err := u.middleware.CreateToken(c, u.env, user.ID.String(), user.IsAdmin)
+		token, err := u.middleware.CreateToken(u.env, user.ID.String(), user.IsAdmin, uuid.Nil)
 		if err != nil {
 			u.logger.Err(err).Msg("error generating JWT token")
 			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
@@ -106,14 +118,32 @@ func (u *userHandler) signup() gin.HandlerFunc {
 			})
 			return
 		}
+		if err := u.app.SaveRefreshToken(c, refreshTokenRecord(user.ID, token)); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				PublicMessage: "Failed to generate authentication token",
+			})
+			return
+		}
+		if err := u.startSession(c, user.ID, token); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				PublicMessage: "Failed to generate authentication token",
+			})
+			return
+		}
+		if err := u.middleware.SetAuthCookies(c, token); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				PublicMessage: "Failed to generate authentication token",
+			})
+			return
+		}
 
 		// Send success response with user details and JWT token
 		models.OkResponse(c, http.StatusCreated, "User created successfully", struct {
-			User  models.User        `json:"user"`
-			Token middlewares.Tokens `json:"token"`
+			User  models.User         `json:"user"`
+			Token *middlewares.Tokens `json:"token,omitempty"`
 		}{
 			User:  *user,
-			Token: *token,
+			Token: u.responseTokens(c, token, false),
 		})
 	}
 }
@@ -123,6 +153,15 @@ func (u *userHandler) login() gin.HandlerFunc {
 		var req models.LoginRequest
 		requestID := requestid.Get(c)
 
+		if !u.middleware.ValidOrigin(c) {
+			models.ErrorResponse(c, http.StatusForbidden, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Request origin is not allowed",
+			})
+			return
+		}
+
 		if err := c.ShouldBind(&req); err != nil {
 			u.logger.Err(err).Msg("bad request")
 			models.ErrorResponse(c, http.StatusBadRequest, models.ErrorData{
@@ -156,7 +195,7 @@ func (u *userHandler) login() gin.HandlerFunc {
 
 		user.Password = helpers.StarPassword
 
-		token, err := u.middleware.CreateToken(c, u.env, user.ID.String(), user.IsAdmin)
+		token, err := u.middleware.CreateToken(u.env, user.ID.String(), user.IsAdmin, uuid.Nil)
 		if err != nil {
 			u.logger.Err(err).Msg("token generation error")
 			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
@@ -166,23 +205,281 @@ func (u *userHandler) login() gin.HandlerFunc {
 			})
 			return
 		}
+		if err := u.app.SaveRefreshToken(c, refreshTokenRecord(user.ID, token)); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to generate token",
+			})
+			return
+		}
+		if err := u.startSession(c, user.ID, token); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to generate token",
+			})
+			return
+		}
+		if err := u.middleware.SetAuthCookies(c, token); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to generate token",
+			})
+			return
+		}
 
 		models.OkResponse(c, http.StatusCreated, "User logged in successfully", struct {
-			User  models.User        `json:"user"`
-			Token middlewares.Tokens `json:"token"`
+			User  models.User         `json:"user"`
+			Token *middlewares.Tokens `json:"token,omitempty"`
 		}{
 			User:  *user,
-			Token: *token,
+			Token: u.responseTokens(c, token, false),
 		})
 	}
 }
 
+// refreshToken exchanges a refresh token for a new access and refresh token pair. Refresh tokens
+// are single use, presenting one twice signs out every session descending from the same login.
+func (u *userHandler) refreshToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req models.RefreshTokenRequest
+		requestID := requestid.Get(c)
+
+		// browsers in cookie mode send the refresh token as a cookie and no body
+		cookieToken, fromCookie := u.middleware.RefreshTokenFromCookie(c)
+		fromCookie = fromCookie && c.Request.ContentLength <= 0
+		if fromCookie {
+			if !middlewares.ValidCSRF(c) {
+				models.ErrorResponse(c, http.StatusForbidden, models.ErrorData{
+					ID:            requestID,
+					Handler:       handlerNameUser,
+					PublicMessage: "CSRF token is missing or invalid",
+				})
+				return
+			}
+			req.RefreshToken = cookieToken
+		} else {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				models.ErrorResponse(c, http.StatusBadRequest, models.ErrorData{
+					ID:            requestID,
+					Handler:       handlerNameUser,
+					PublicMessage: "Bad request",
+				})
+				return
+			}
+			if err := helpers.ValidateRequest(req); err != nil {
+				models.ErrorResponse(c, http.StatusBadRequest, models.ErrorData{
+					ID:            requestID,
+					Handler:       handlerNameUser,
+					PublicMessage: "Invalid refresh request",
+				})
+				return
+			}
+		}
+
+		claims, err := u.middleware.ValidateRefreshToken(*u.logger, u.env, req.RefreshToken)
+		if err != nil {
+			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "refresh token supplied is invalid/expired",
+			})
+			return
+		}
+
+		// the user may have been removed or demoted since the token was issued
+		user, err := u.app.GetUserByID(c, claims.UserID)
+		if err != nil {
+			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "no user found for this refresh token",
+			})
+			return
+		}
+
+		token, err := u.middleware.CreateToken(u.env, user.ID.String(), user.IsAdmin, claims.FamilyID)
+		if err != nil {
+			u.logger.Err(err).Msg("token generation error")
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to generate token",
+			})
+			return
+		}
+
+		// the new pair is only handed out once the old refresh token has been consumed
+		if err := u.app.RotateRefreshToken(c, claims.TokenID, refreshTokenRecord(user.ID, token)); err != nil {
+			status, message := http.StatusInternalServerError, "Failed to refresh token"
+			switch {
+			case errors.Is(err, helpers.ErrRefreshTokenReused):
+				status, message = http.StatusUnauthorized, "refresh token was already used, please log in again"
+			case errors.Is(err, helpers.ErrInvalidRefreshToken):
+				status, message = http.StatusUnauthorized, "refresh token supplied is invalid/expired"
+			}
+			models.ErrorResponse(c, status, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: message,
+			})
+			return
+		}
+
+		if err := u.middleware.SetAuthCookies(c, token); err != nil {
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to refresh token",
+			})
+			return
+		}
+		models.OkResponse(c, http.StatusOK, "Token refreshed successfully", struct {
+			Token *middlewares.Tokens `json:"token,omitempty"`
+		}{
+			Token: u.responseTokens(c, token, fromCookie),
+		})
+	}
+}
+
+// responseTokens is the token pair to put in a response body. Browsers refreshing by cookie, or
+// asking for cookies only with middlewares.CookieOnlyHeader, get the tokens only as cookies so scripts
+// of the page never get to see them. Every other client keeps receiving them in the body.
+func (u *userHandler) responseTokens(c *gin.Context, tokens *middlewares.Tokens, fromCookie bool) *middlewares.Tokens {
+	if fromCookie || u.middleware.CookieOnly(c) {
+		return nil
+	}
+	return tokens
+}
+
+// refreshTokenRecord is the server side record of the refresh token in tokens
+func refreshTokenRecord(userID uuid.UUID, tokens *middlewares.Tokens) models.RefreshToken {
+	return models.RefreshToken{
+		ID:        tokens.RefreshTokenID,
+		FamilyID:  tokens.RefreshFamilyID,
+		UserID:    userID,
+		ExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+// startSession records the device of the request as a session of the refresh token family just issued
+func (u *userHandler) startSession(c *gin.Context, userID uuid.UUID, tokens *middlewares.Tokens) error {
+	return u.app.StartSession(c, userID, tokens.RefreshFamilyID, c.Request.UserAgent(), c.ClientIP(), tokens.RefreshExpiresAt)
+}
+
+// logoutSession is the session a logout request was made from
+type logoutSession struct {
+	userID    uuid.UUID
+	tokenID   uuid.UUID // access token, uuid.Nil when identified by the refresh token cookie
+	familyID  uuid.UUID
+	expiresAt time.Time
+}
+
+// sessionToLogOut identifies the session of a logout request by its access token or, when that is
+// missing or expired, by the refresh token cookie, so a browser can always log out.
+func (u *userHandler) sessionToLogOut(c *gin.Context) (*logoutSession, error) {
+	claims, err := u.middleware.RequestAccessClaims(c)
+	if err == nil {
+		userID, err := uuid.Parse(claims.UserID)
+		if err != nil {
+			return nil, middlewares.ErrInvalidToken
+		}
+		return &logoutSession{userID: userID, tokenID: claims.TokenID, familyID: claims.FamilyID, expiresAt: claims.ExpiresAt}, nil
+	}
+	if errors.Is(err, middlewares.ErrInvalidCSRFToken) {
+		return nil, err
+	}
+
+	cookieToken, ok := u.middleware.RefreshTokenFromCookie(c)
+	if !ok {
+		return nil, err
+	}
+	if !middlewares.ValidCSRF(c) {
+		return nil, middlewares.ErrInvalidCSRFToken
+	}
+	refreshClaims, err := u.middleware.ValidateRefreshToken(*u.logger, u.env, cookieToken)
+	if err != nil {
+		return nil, middlewares.ErrInvalidToken
+	}
+	return &logoutSession{userID: refreshClaims.UserID, familyID: refreshClaims.FamilyID}, nil
+}
+
+// logoutErrorResponse answers a logout request without a session to sign out. The auth cookies are
+// cleared all the same unless the request may come from another site.
+func (u *userHandler) logoutErrorResponse(c *gin.Context, err error) {
+	status, message := http.StatusUnauthorized, "token supplied is invalid/expired"
+	if errors.Is(err, middlewares.ErrInvalidCSRFToken) {
+		status, message = http.StatusForbidden, "CSRF token is missing or invalid"
+	} else {
+		u.middleware.ClearAuthCookies(c)
+	}
+	models.ErrorResponse(c, status, models.ErrorData{
+		ID:            requestid.Get(c),
+		Handler:       handlerNameUser,
+		PublicMessage: message,
+	})
+}
+
+// logout signs out the session of the request, the tokens of the session stop working
+func (u *userHandler) logout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := requestid.Get(c)
+
+		session, err := u.sessionToLogOut(c)
+		if err != nil {
+			u.logoutErrorResponse(c, err)
+			return
+		}
+
+		if err := u.app.Logout(c, session.userID, session.tokenID, session.familyID, session.expiresAt); err != nil {
+			u.logger.Err(err).Msg("error logging out")
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to log out",
+			})
+			return
+		}
+
+		u.middleware.ClearAuthCookies(c)
+		models.OkResponse(c, http.StatusOK, "Logged out successfully", nil)
+	}
+}
+
+// logoutAll signs the user out of all devices
+func (u *userHandler) logoutAll() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := requestid.Get(c)
+
+		session, err := u.sessionToLogOut(c)
+		if err != nil {
+			u.logoutErrorResponse(c, err)
+			return
+		}
+
+		if err := u.app.LogoutEverywhere(c, session.userID); err != nil {
+			u.logger.Err(err).Msg("error logging out of all devices")
+			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Failed to log out of all devices",
+			})
+			return
+		}
+
+		u.middleware.ClearAuthCookies(c)
+		models.OkResponse(c, http.StatusOK, "Logged out of all devices successfully", nil)
+	}
+}
+
 func (u *userHandler) getUsers() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := requestid.Get(c)
 
 		// Retrieve users from the application layer
-		users, err := u.app.GetUsers(c)
+		users, pageInfo, err := u.app.GetUsers(c, helpers.ParsePage(c, "created_at", "username"))
 		if err != nil {
 			u.logger.Err(err).Msg("error getting users")
 			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
@@ -191,16 +488,27 @@ func (u *userHandler) getUsers() gin.HandlerFunc {
 				PublicMessage: "Failed to fetch users",
 			})
 			return
-			}
+		}
 
-		models.OkResponse(c, http.StatusOK, "Users fetched successfully", users)
+		for _, user := range users {
+			user.Password = helpers.StarPassword
+		}
+		models.OkResponse(c, http.StatusOK, "Users fetched successfully", gin.H{"users": users, "pageInfo": pageInfo})
 	}
 }
 
 func (u *userHandler) me() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := requestid.Get(c)
-		userID := c.GetString("userId")
+		userID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
+				ID:            requestID,
+				Handler:       handlerNameUser,
+				PublicMessage: "Invalid user ID",
+			})
+			return
+		}
 
 		// Retrieve user details from the application layer
 		user, err := u.app.GetUserByID(c, userID)
@@ -214,6 +522,7 @@ func (u *userHandler) me() gin.HandlerFunc {
 			return
 		}
 
+		user.Password = helpers.StarPassword
 		models.OkResponse(c, http.StatusOK, "User fetched successfully", user)
 	}
 }
@@ -235,7 +544,7 @@ func (u *userHandler) searchUsers() gin.HandlerFunc {
 		}
 
 		// Search users with the provided query
-		users, err := u.app.SearchUsers(c, query)
+		users, pageInfo, err := u.app.SearchUsers(c, query, helpers.ParsePage(c, "created_at", "username"))
 		if err != nil {
 			u.logger.Err(err).Msg("error searching users")
 			models.ErrorResponse(c, http.StatusInternalServerError, models.ErrorData{
@@ -246,7 +555,10 @@ func (u *userHandler) searchUsers() gin.HandlerFunc {
 			return
 		}
 
-		models.OkResponse(c, http.StatusOK, "User search successful", users)
+		for _, user := range users {
+			user.Password = helpers.StarPassword
+		}
+		models.OkResponse(c, http.StatusOK, "User search successful", gin.H{"users": users, "pageInfo": pageInfo})
 	}
 }
 
@@ -254,19 +566,19 @@ func (u *userHandler) getUserByID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := requestid.Get(c)
 
-		userID := c.Param("id")
-		if userID == "" {
+		userID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
 			models.ErrorResponse(c, http.StatusBadRequest, models.ErrorData{
 				ID:            requestID,
 				Handler:       handlerNameUser,
-				PublicMessage: "User ID is required",
+				PublicMessage: "Invalid user ID",
 			})
 			return
 		}
 
 		user, err := u.app.GetUserByID(c, userID)
 		if err != nil {
-			if errors.Is(err, models.ErrNotFound) {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
 				models.ErrorResponse(c, http.StatusNotFound, models.ErrorData{
 					ID:            requestID,
 					Handler:       handlerNameUser,
@@ -283,6 +595,7 @@ func (u *userHandler) getUserByID() gin.HandlerFunc {
 			return
 		}
 
+		user.Password = helpers.StarPassword
 		models.OkResponse(c, http.StatusOK, "User fetched successfully", user)
 	}
 }
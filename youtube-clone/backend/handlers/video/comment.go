@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func (v *videoHandler) registerCommentRoutes(videoGroup *gin.RouterGroup, m middlewares.Middleware) {
+	commentGroup := videoGroup.Group("/:id/comments")
+
+	commentGroup.GET("", m.OptionalAuthMiddleware(), v.getComments())
+	commentGroup.POST("", m.AuthMiddleware(false), v.createComment())
+	commentGroup.PUT("/settings", m.AuthMiddleware(false), v.setCommentSettings())
+	commentGroup.GET("/:commentID/replies", m.OptionalAuthMiddleware(), v.getReplies())
+	commentGroup.PUT("/:commentID", m.AuthMiddleware(false), v.updateComment())
+	commentGroup.DELETE("/:commentID", m.AuthMiddleware(false), v.deleteComment())
+	commentGroup.PUT("/:commentID/pin", m.AuthMiddleware(false), v.pinComment(true))
+	commentGroup.DELETE("/:commentID/pin", m.AuthMiddleware(false), v.pinComment(false))
+	commentGroup.PUT("/:commentID/rating", m.AuthMiddleware(false), v.rateComment())
+	commentGroup.DELETE("/:commentID/rating", m.AuthMiddleware(false), v.clearCommentRating())
+}
+
+func (v *videoHandler) getComments() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		sort := c.DefaultQuery("sort", models.CommentSortNewest)
+		if sort != models.CommentSortNewest && sort != models.CommentSortTop {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be newest or top"})
+			return
+		}
+
+		comments, pageInfo, err := v.app.GetComments(c, videoUUID, viewerUUID, sort, helpers.ParsePage(c))
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"comments": comments, "pageInfo": pageInfo})
+	}
+}
+
+func (v *videoHandler) getReplies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		commentUUID, err := uuid.Parse(c.Param("commentID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+			return
+		}
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		replies, pageInfo, err := v.app.GetReplies(c, videoUUID, commentUUID, viewerUUID, helpers.ParsePage(c))
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"comments": replies, "pageInfo": pageInfo})
+	}
+}
+
+func (v *videoHandler) createComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.CreateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		comment, err := v.app.CreateComment(c, videoUUID, userUUID, req)
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "Comment created successfully", "comment": comment})
+	}
+}
+
+func (v *videoHandler) updateComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, commentUUID, userUUID, ok := commentParams(c)
+		if !ok {
+			return
+		}
+
+		var req models.UpdateCommentRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		comment, err := v.app.UpdateComment(c, videoUUID, commentUUID, userUUID, req)
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Comment updated successfully", "comment": comment})
+	}
+}
+
+func (v *videoHandler) deleteComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, commentUUID, userUUID, ok := commentParams(c)
+		if !ok {
+			return
+		}
+
+		if err := v.app.DeleteComment(c, videoUUID, commentUUID, userUUID); err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (v *videoHandler) pinComment(pinned bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, commentUUID, userUUID, ok := commentParams(c)
+		if !ok {
+			return
+		}
+
+		comment, err := v.app.SetCommentPinned(c, videoUUID, commentUUID, userUUID, pinned)
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"comment": comment})
+	}
+}
+
+func (v *videoHandler) setCommentSettings() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.CommentSettingsRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		video, err := v.app.SetCommentsDisabled(c, videoUUID, userUUID, req.Disabled)
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Comment settings updated successfully", "video": video})
+	}
+}
+
+// commentParams extracts the video, the comment and the caller addressed by the request
+func commentParams(c *gin.Context) (uuid.UUID, uuid.UUID, uuid.UUID, bool) {
+	userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	videoUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	commentUUID, err := uuid.Parse(c.Param("commentID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Comment not found"})
+		return uuid.Nil, uuid.Nil, uuid.Nil, false
+	}
+	return videoUUID, commentUUID, userUUID, true
+}
+
+func commentErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, helpers.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Video or comment not found"})
+	case errors.Is(err, helpers.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not allowed to change this comment"})
+	case errors.Is(err, helpers.ErrCommentsDisabled):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Comments are disabled for this video"})
+	case errors.Is(err, helpers.ErrCommentNotTopLevel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only top-level comments can be pinned"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process comment request"})
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/streaming"
+)
+
+func (v *videoHandler) getDASHManifest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+
+		manifest, video, err := v.app.GetDASHManifest(c, videoUUID)
+		if err != nil {
+			streamErrorResponse(c, err)
+			return
+		}
+
+		c.Header("Content-Type", streaming.DASHContentType)
+		c.Header("Cache-Control", "no-cache")
+		http.ServeContent(c.Writer, c.Request, streaming.DASHManifest, video.UpdatedAt, bytes.NewReader(manifest))
+	}
+}
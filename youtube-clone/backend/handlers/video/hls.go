@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/streaming"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+)
+
+// hlsFilePattern matches the only files a packaged video exposes below /hls
+var hlsFilePattern = regexp.MustCompile(`^(master\.m3u8|[a-z0-9]+/(index\.m3u8|init\.mp4|seg_[0-9]+\.m4s))$`)
+
+func (v *videoHandler) getHLSFile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+
+		name := strings.TrimPrefix(c.Param("file"), "/")
+		if !hlsFilePattern.MatchString(name) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+
+		reader, object, err := v.app.GetHLSFile(c, videoUUID, name)
+		if err != nil {
+			streamErrorResponse(c, err)
+			return
+		}
+		defer reader.Close()
+
+		switch path.Ext(name) {
+		case ".m3u8":
+			c.Header("Content-Type", streaming.HLSContentType)
+			// playlists are rewritten when a video is reprocessed
+			c.Header("Cache-Control", "no-cache")
+		case ".m4s":
+			c.Header("Content-Type", "video/iso.segment")
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		default:
+			c.Header("Content-Type", "video/mp4")
+			c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		}
+		http.ServeContent(c.Writer, c.Request, path.Base(name), object.ModTime, reader)
+	}
+}
+
+func streamErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, helpers.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+	case errors.Is(err, helpers.ErrVideoNotReady):
+		c.JSON(http.StatusConflict, gin.H{"error": "Video is still processing"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stream video"})
+	}
+}
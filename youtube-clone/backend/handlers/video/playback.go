@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// createPlaybackURL mints the signed URLs the stream, HLS, DASH and storyboard routes require
+func (v *videoHandler) createPlaybackURL() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		// anonymous viewers can get URLs for public and unlisted videos
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		var req models.PlaybackURLRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		if req.BindViewer && viewerUUID == uuid.Nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Binding a playback URL requires a logged in viewer"})
+			return
+		}
+
+		playback, err := v.app.CreatePlaybackURL(c, videoUUID, viewerUUID, req.BindViewer)
+		if err != nil {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			v.logger.Err(err).Msg("error creating playback URL")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create playback URL"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"playback": playback})
+	}
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func (v *videoHandler) rateVideo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.RateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		video, err := v.app.RateVideo(c, videoUUID, userUUID, req.Rating)
+		if err != nil {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rate video"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"video": video})
+	}
+}
+
+func (v *videoHandler) clearVideoRating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		video, err := v.app.ClearVideoRating(c, videoUUID, userUUID)
+		if err != nil {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear rating"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"video": video})
+	}
+}
+
+func (v *videoHandler) rateComment() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, commentUUID, userUUID, ok := commentParams(c)
+		if !ok {
+			return
+		}
+
+		var req models.RateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		comment, err := v.app.RateComment(c, videoUUID, commentUUID, userUUID, req.Rating)
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"comment": comment})
+	}
+}
+
+func (v *videoHandler) clearCommentRating() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, commentUUID, userUUID, ok := commentParams(c)
+		if !ok {
+			return
+		}
+
+		comment, err := v.app.ClearCommentRating(c, videoUUID, commentUUID, userUUID)
+		if err != nil {
+			commentErrorResponse(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"comment": comment})
+	}
+}
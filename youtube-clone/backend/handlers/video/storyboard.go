@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/streaming"
+)
+
+// storyboardSheetPattern matches the sprite sheet names referenced by the storyboard track
+var storyboardSheetPattern = regexp.MustCompile(`^sprite_[0-9]{3}\.jpg$`)
+
+// getStoryboardTrack serves the WebVTT thumbnail track players use for seek previews
+func (v *videoHandler) getStoryboardTrack() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v.serveStoryboardFile(c, streaming.StoryboardTrack, streaming.WebVTTContentType)
+	}
+}
+
+func (v *videoHandler) getStoryboardSheet() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sheet := c.Param("sheet")
+		if !storyboardSheetPattern.MatchString(sheet) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		v.serveStoryboardFile(c, "storyboard/"+sheet, "image/jpeg")
+	}
+}
+
+func (v *videoHandler) serveStoryboardFile(c *gin.Context, name, contentType string) {
+	videoUUID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+		return
+	}
+
+	reader, object, err := v.app.GetStoryboardFile(c, videoUUID, name)
+	if err != nil {
+		streamErrorResponse(c, err)
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Type", contentType)
+	// regenerated storyboards replace the files in place
+	c.Header("Cache-Control", "public, max-age=3600")
+	http.ServeContent(c.Writer, c.Request, name, object.ModTime, reader)
+}
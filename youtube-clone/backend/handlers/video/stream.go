@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+)
+
+// stream serves the original upload, or the rendition named by ?rendition=, with HTTP range
+// support. http.ServeContent takes care of Range, If-Range, multipart/byteranges, 206/416
+// responses and conditional requests against the ETag and Last-Modified headers set here.
+func (v *videoHandler) stream() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		stream, err := v.app.OpenVideoStream(c, videoUUID, viewerUUID, c.Query("rendition"))
+		if err != nil {
+			streamErrorResponse(c, err)
+			return
+		}
+		defer stream.Reader.Close()
+
+		c.Header("Content-Type", stream.ContentType)
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", stream.Name))
+		c.Header("Accept-Ranges", "bytes")
+		c.Header("ETag", stream.ETag)
+		c.Header("Cache-Control", "private, max-age=3600")
+		http.ServeContent(c.Writer, c.Request, stream.Name, stream.Object.ModTime, stream.Reader)
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// setThumbnail selects one of the extracted thumbnails when called with JSON,
+// or stores a custom JPEG/PNG sent as the "file" field of a multipart form
+func (v *videoHandler) setThumbnail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var thumbnail *models.Thumbnail
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			file, _, err := c.Request.FormFile("file")
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Missing thumbnail file"})
+				return
+			}
+			defer file.Close()
+
+			thumbnail, err = v.app.UploadThumbnail(c, videoUUID, userUUID, file)
+		} else {
+			var req models.SelectThumbnailRequest
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if err := helpers.ValidateRequest(req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			thumbnail, err = v.app.SelectThumbnail(c, videoUUID, userUUID, req.ThumbnailID)
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, helpers.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video or thumbnail not found"})
+			case errors.Is(err, helpers.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change the thumbnail"})
+			case errors.Is(err, helpers.ErrUploadTooLarge):
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Thumbnail is too large"})
+			case errors.Is(err, helpers.ErrUnsupportedImageType):
+				c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Thumbnail must be a JPEG or PNG image"})
+			default:
+				v.logger.Err(err).Msg("error setting thumbnail")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set thumbnail"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Thumbnail updated successfully", "thumbnail": thumbnail})
+	}
+}
+
+func (v *videoHandler) getThumbnail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		thumbnailUUID, err := uuid.Parse(c.Param("thumbnailID"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Thumbnail not found"})
+			return
+		}
+
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		reader, object, thumbnail, err := v.app.GetThumbnail(c, videoUUID, thumbnailUUID, viewerUUID)
+		if err != nil {
+			streamErrorResponse(c, err)
+			return
+		}
+		defer reader.Close()
+
+		c.Header("Content-Type", thumbnail.MimeType)
+		c.Header("Cache-Control", "public, max-age=86400")
+		http.ServeContent(c.Writer, c.Request, thumbnail.ID.String(), object.ModTime, reader)
+	}
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// tus 1.0 protocol, see https://tus.io/protocols/resumable-upload
+const (
+	tusVersion         = "1.0.0"
+	tusExtensions      = "creation,termination,expiration"
+	tusChunkMediaType  = "application/offset+octet-stream"
+	headerTusResumable = "Tus-Resumable"
+	headerTusVersion   = "Tus-Version"
+	headerTusExtension = "Tus-Extension"
+	headerTusMaxSize   = "Tus-Max-Size"
+	headerUploadLength = "Upload-Length"
+	headerUploadOffset = "Upload-Offset"
+	headerUploadMeta   = "Upload-Metadata"
+	headerUploadExpiry = "Upload-Expires"
+	headerVideoID      = "X-Video-ID"
+)
+
+func (v *videoHandler) registerUploadRoutes(videoGroup *gin.RouterGroup, m middlewares.Middleware) {
+	uploadGroup := videoGroup.Group("/uploads")
+
+	uploadGroup.OPTIONS("", v.tusOptions())
+	uploadGroup.POST("", m.AuthMiddleware(false), tusResumable(), v.tusCreate())
+	uploadGroup.HEAD("/:uploadID", m.AuthMiddleware(false), tusResumable(), v.tusOffset())
+	uploadGroup.PATCH("/:uploadID", m.AuthMiddleware(false), tusResumable(), v.tusPatch())
+	uploadGroup.DELETE("/:uploadID", m.AuthMiddleware(false), tusResumable(), v.tusTerminate())
+}
+
+// tusResumable rejects requests made with a protocol version we do not speak
+func tusResumable() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(headerTusResumable, tusVersion)
+		if c.GetHeader(headerTusResumable) != tusVersion {
+			c.Header(headerTusVersion, tusVersion)
+			c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (v *videoHandler) tusOptions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(headerTusResumable, tusVersion)
+		c.Header(headerTusVersion, tusVersion)
+		c.Header(headerTusExtension, tusExtensions)
+		c.Header(headerTusMaxSize, strconv.FormatInt(v.app.UploadMaxSize(), 10))
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (v *videoHandler) tusCreate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		length, err := strconv.ParseInt(c.GetHeader(headerUploadLength), 10, 64)
+		if err != nil || length <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Length header"})
+			return
+		}
+
+		metadata, err := parseTusMetadata(c.GetHeader(headerUploadMeta))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Metadata header"})
+			return
+		}
+
+		title := metadata["title"]
+		if title == "" {
+			title = metadata["filename"]
+		}
+		publishAt, err := parsePublishAt(metadata["publishAt"])
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid publishAt metadata"})
+			return
+		}
+		channelID, err := parseChannelID(metadata["channelID"])
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid channelID metadata"})
+			return
+		}
+		req := models.UploadVideoRequest{Title: title, Visibility: metadata["visibility"], PublishAt: publishAt, ChannelID: channelID}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		upload, err := v.app.CreateUpload(c, models.Upload{
+			UserID:      userUUID,
+			Length:      length,
+			Metadata:    c.GetHeader(headerUploadMeta),
+			Title:       title,
+			Description: metadata["description"],
+			FileName:    metadata["filename"],
+			ContentType: metadata["filetype"],
+			Visibility:  req.Visibility,
+			PublishAt:   req.PublishAt,
+			ChannelID:   req.ChannelID,
+		})
+		if err != nil {
+			if errors.Is(err, helpers.ErrUploadTooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload exceeds maximum size"})
+				return
+			}
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+				return
+			}
+			if errors.Is(err, helpers.ErrForbidden) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Channel belongs to another user"})
+				return
+			}
+			v.logger.Err(err).Msg("error creating upload")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+			return
+		}
+
+		c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+upload.ID.String())
+		c.Header(headerUploadExpiry, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		c.Status(http.StatusCreated)
+	}
+}
+
+func (v *videoHandler) tusOffset() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, uploadUUID, ok := uploadParams(c)
+		if !ok {
+			return
+		}
+
+		upload, err := v.app.GetUpload(c, uploadUUID, userUUID)
+		if err != nil {
+			uploadErrorResponse(c, err)
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.Header(headerUploadOffset, strconv.FormatInt(upload.Offset, 10))
+		c.Header(headerUploadLength, strconv.FormatInt(upload.Length, 10))
+		c.Header(headerUploadExpiry, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		if upload.Metadata != "" {
+			c.Header(headerUploadMeta, upload.Metadata)
+		}
+		if upload.VideoID != nil {
+			c.Header(headerVideoID, upload.VideoID.String())
+		}
+		c.Status(http.StatusOK)
+	}
+}
+
+func (v *videoHandler) tusPatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, uploadUUID, ok := uploadParams(c)
+		if !ok {
+			return
+		}
+
+		if c.ContentType() != tusChunkMediaType {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + tusChunkMediaType})
+			return
+		}
+
+		offset, err := strconv.ParseInt(c.GetHeader(headerUploadOffset), 10, 64)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Offset header"})
+			return
+		}
+
+		upload, err := v.app.WriteUploadChunk(c, uploadUUID, userUUID, offset, c.Request.ContentLength, c.Request.Body)
+		if err != nil {
+			uploadErrorResponse(c, err)
+			return
+		}
+
+		c.Header(headerUploadOffset, strconv.FormatInt(upload.Offset, 10))
+		c.Header(headerUploadExpiry, upload.ExpiresAt.UTC().Format(http.TimeFormat))
+		if upload.VideoID != nil {
+			c.Header(headerVideoID, upload.VideoID.String())
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func (v *videoHandler) tusTerminate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userUUID, uploadUUID, ok := uploadParams(c)
+		if !ok {
+			return
+		}
+
+		if err := v.app.TerminateUpload(c, uploadUUID, userUUID); err != nil {
+			uploadErrorResponse(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// uploadParams extracts the caller and the upload addressed by the URL
+func uploadParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	uploadUUID, err := uuid.Parse(c.Param("uploadID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userUUID, uploadUUID, true
+}
+
+func uploadErrorResponse(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, helpers.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+	case errors.Is(err, helpers.ErrUploadExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "Upload has expired"})
+	case errors.Is(err, helpers.ErrUploadOffsetMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+	case errors.Is(err, helpers.ErrUploadLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload is in use by another request"})
+	case errors.Is(err, helpers.ErrUploadTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk reaches past Upload-Length"})
+	case errors.Is(err, helpers.ErrUnsupportedMediaType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File is not a supported video format"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process upload"})
+	}
+}
+
+// parseTusMetadata decodes the comma separated "key base64value" pairs of Upload-Metadata
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("empty metadata key")
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
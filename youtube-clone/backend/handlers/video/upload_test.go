@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "empty", header: "", want: map[string]string{}},
+		{name: "blank", header: "  ", want: map[string]string{}},
+		{
+			name:   "pairs",
+			header: "filename bXkgdmlkZW8ubXA0,filetype dmlkZW8vbXA0",
+			want:   map[string]string{"filename": "my video.mp4", "filetype": "video/mp4"},
+		},
+		{
+			name:   "spaces around pairs",
+			header: " filename bXkgdmlkZW8ubXA0 , filetype dmlkZW8vbXA0 ",
+			want:   map[string]string{"filename": "my video.mp4", "filetype": "video/mp4"},
+		},
+		{name: "key without value", header: "is_private", want: map[string]string{"is_private": ""}},
+		{name: "unicode value", header: "title w6l0w6k=", want: map[string]string{"title": "été"}},
+		{name: "empty key", header: "filename bXkgdmlkZW8ubXA0,,filetype dmlkZW8vbXA0", wantErr: true},
+		{name: "value not base64", header: "filename my video.mp4", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTusMetadata(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTusMetadata() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTusMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,17 +1,28 @@
 package handlers
 
 import (
+	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/joshua468/youtube-clone/backend/app"
-	"github.com/joshua468/youtube-clone/backend/utils/middlewares"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
 	"github.com/rs/zerolog"
 )
 
-const handlerNameVideo = "video"
+const (
+	handlerNameVideo = "video"
+
+	// maxFormFieldSize caps the size of the non file fields of an upload
+	maxFormFieldSize = 64 << 10
+)
 
 type videoHandler struct {
 	logger     *zerolog.Logger
@@ -33,13 +44,45 @@ func NewVideoHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *model
 	videoGroup.POST("", m.AuthMiddleware(false), video.create())
 	videoGroup.PUT("/update/:id", m.AuthMiddleware(false), video.update()) // Added :id param
 	videoGroup.GET("/mine", m.AuthMiddleware(false), video.getMyVideos())
-	videoGroup.GET("/:id/user", m.AuthMiddleware(true), video.getUserVideos())
-	videoGroup.GET("/:id", m.AuthMiddleware(false), video.getVideoByID())
-	videoGroup.GET("/all", m.AuthMiddleware(true), video.getAllVideos())
+	videoGroup.GET("/:id/user", m.OptionalAuthMiddleware(), video.getUserVideos())
+	videoGroup.GET("/:id", m.OptionalAuthMiddleware(), video.getVideoByID())
+	videoGroup.GET("/all", m.OptionalAuthMiddleware(), video.getAllVideos())
+	videoGroup.PUT("/:id/visibility", m.AuthMiddleware(false), video.setVisibility())
+	videoGroup.POST("/:id/views", m.OptionalAuthMiddleware(), video.recordView())
+	videoGroup.PUT("/:id/progress", m.AuthMiddleware(false), video.recordProgress())
+	videoGroup.PUT("/:id/rating", m.AuthMiddleware(false), video.rateVideo())
+	videoGroup.DELETE("/:id/rating", m.AuthMiddleware(false), video.clearVideoRating())
+	videoGroup.PUT("/:id/thumbnail", m.AuthMiddleware(false), video.setThumbnail())
+	videoGroup.GET("/:id/thumbnails/:thumbnailID", m.OptionalAuthMiddleware(), video.getThumbnail())
+	videoGroup.POST("/:id/playback", m.OptionalAuthMiddleware(), video.createPlaybackURL())
+
+	// media is only served below a signed playback URL, relative playlist and manifest URIs keep the token
+	playback := videoGroup.Group("/:id/play/:token", m.PlaybackMiddleware())
+	playback.GET("/stream", video.stream())
+	playback.GET("/hls/*file", video.getHLSFile())
+	playback.GET("/dash/manifest.mpd", video.getDASHManifest())
+	playback.GET("/storyboard.vtt", video.getStoryboardTrack())
+	playback.GET("/storyboard/:sheet", video.getStoryboardSheet())
+
+	video.registerUploadRoutes(videoGroup, m)
+	video.registerCommentRoutes(videoGroup, m)
 }
 
 func (v *videoHandler) create() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// media uploads come in as multipart, metadata only requests as JSON
+		if strings.HasPrefix(c.ContentType(), "multipart/") {
+			v.upload(c)
+			return
+		}
+
+		// the owner is always the signed in user, never taken from the request
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
 		var req models.CreateVideoRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
 			// Handle validation errors
@@ -48,7 +91,12 @@ func (v *videoHandler) create() gin.HandlerFunc {
 		}
 
 		// Call app.CreateVideo method passing req
-		video, err := v.app.CreateVideo(c, req)
+		video, err := v.app.CreateVideo(c, models.Video{
+			ID:          uuid.New(),
+			UserID:      userUUID,
+			Title:       req.Title,
+			Description: req.Content,
+		})
 		if err != nil {
 			// Handle error from app.CreateVideo
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create video"})
@@ -60,6 +108,93 @@ func (v *videoHandler) create() gin.HandlerFunc {
 	}
 }
 
+// upload streams a multipart/form-data request straight into the blob store.
+// The title, description, visibility, publishAt and channelID fields must be sent before the file part.
+func (v *videoHandler) upload(c *gin.Context) {
+	userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart request"})
+		return
+	}
+
+	var req models.UploadVideoRequest
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart request"})
+			return
+		}
+
+		switch part.FormName() {
+		case "title":
+			req.Title, err = readFormField(part)
+		case "description":
+			req.Description, err = readFormField(part)
+		case "visibility":
+			req.Visibility, err = readFormField(part)
+		case "channelID":
+			var channelID string
+			if channelID, err = readFormField(part); err == nil {
+				req.ChannelID, err = parseChannelID(channelID)
+			}
+		case "publishAt":
+			var publishAt string
+			if publishAt, err = readFormField(part); err == nil {
+				req.PublishAt, err = parsePublishAt(publishAt)
+			}
+		case "file":
+			if err := helpers.ValidateRequest(req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+
+			video, err := v.app.UploadVideo(c, models.Video{
+				UserID:      userUUID,
+				Title:       req.Title,
+				Description: req.Description,
+				Visibility:  req.Visibility,
+				PublishAt:   req.PublishAt,
+				ChannelID:   req.ChannelID,
+			}, part, part.FileName(), part.Header.Get("Content-Type"))
+			if err != nil {
+				if errors.Is(err, helpers.ErrUnsupportedMediaType) {
+					c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "File is not a supported video format"})
+					return
+				}
+				if errors.Is(err, helpers.ErrRecordNotFound) {
+					c.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+					return
+				}
+				if errors.Is(err, helpers.ErrForbidden) {
+					c.JSON(http.StatusForbidden, gin.H{"error": "Channel belongs to another user"})
+					return
+				}
+				v.logger.Err(err).Msg("error uploading video")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload video"})
+				return
+			}
+
+			c.JSON(http.StatusCreated, gin.H{"message": "Video uploaded successfully", "video": video})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form field"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": "Missing video file"})
+}
+
 func (v *videoHandler) update() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req models.UpdateVideoRequest
@@ -77,9 +212,18 @@ func (v *videoHandler) update() gin.HandlerFunc {
 			return
 		}
 
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Call app.UpdateVideo method passing req
 		updatedVideo, err := v.app.UpdateVideo(c, videoUUID, req)
 		if err != nil {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update video"})
 			return
 		}
@@ -105,15 +249,15 @@ func (v *videoHandler) getMyVideos() gin.HandlerFunc {
 			return
 		}
 
-		// Call the app method to get videos by user ID
-		videos, err := v.app.GetVideosByUserID(c, userUUID)
+		// The owner sees all of their videos, whatever their visibility
+		videos, pageInfo, err := v.app.GetUserVideos(c, userUUID, userUUID, helpers.ParsePage(c, models.VideoSortColumns...))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch videos"})
 			return
 		}
 
 		// Return the videos in the response
-		c.JSON(http.StatusOK, gin.H{"videos": videos})
+		c.JSON(http.StatusOK, gin.H{"videos": videos, "pageInfo": pageInfo})
 	}
 }
 
@@ -128,16 +272,17 @@ func (v *videoHandler) getUserVideos() gin.HandlerFunc {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 			return
 		}
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
 
-		// Call the app method to get videos by user ID
-		videos, err := v.app.GetVideosByUserID(c, userUUID)
+		// Call the app method to get the videos of the user listed for the viewer
+		videos, pageInfo, err := v.app.GetUserVideos(c, userUUID, viewerUUID, helpers.ParsePage(c, models.VideoSortColumns...))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user's videos"})
 			return
 		}
 
 		// Return the videos in the response
-		c.JSON(http.StatusOK, gin.H{"videos": videos})
+		c.JSON(http.StatusOK, gin.H{"videos": videos, "pageInfo": pageInfo})
 	}
 }
 
@@ -146,6 +291,8 @@ func (v *videoHandler) getVideoByID() gin.HandlerFunc {
 		// Extract video ID from the URL parameters
 		videoID := c.Param("id")
 
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
 		// Convert the videoID string to a UUID
 		uuid, err := uuid.Parse(videoID)
 		if err != nil {
@@ -153,9 +300,13 @@ func (v *videoHandler) getVideoByID() gin.HandlerFunc {
 			return
 		}
 
-		// Call the app method to get the video by ID
-		video, err := v.app.GetVideoByID(c, uuid)
+		// Call the app method to get the video by ID, private videos are not found for other viewers
+		video, err := v.app.GetVideoByID(c, uuid, viewerUUID)
 		if err != nil {
+			if errors.Is(err, helpers.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch video"})
 			return
 		}
@@ -167,14 +318,89 @@ func (v *videoHandler) getVideoByID() gin.HandlerFunc {
 
 func (v *videoHandler) getAllVideos() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Call the app method to get all videos
-		videos, err := v.app.GetAllVideos(c)
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		// Call the app method to get the public videos, plus the viewer's own
+		videos, pageInfo, err := v.app.GetVideos(c, viewerUUID, helpers.ParsePage(c, models.VideoSortColumns...))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch videos"})
 			return
 		}
 
 		// Return the videos in the response
-		c.JSON(http.StatusOK, gin.H{"videos": videos})
+		c.JSON(http.StatusOK, gin.H{"videos": videos, "pageInfo": pageInfo})
+	}
+}
+
+func (v *videoHandler) setVisibility() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		userUUID, err := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in context"})
+			return
+		}
+
+		var req models.UpdateVisibilityRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		video, err := v.app.SetVisibility(c, videoUUID, userUUID, req)
+		if err != nil {
+			switch {
+			case errors.Is(err, helpers.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+			case errors.Is(err, helpers.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": "Only the owner can change the visibility of a video"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update visibility"})
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Visibility updated successfully", "video": video})
+	}
+}
+
+// readFormField reads a small text field of a multipart request
+func readFormField(part *multipart.Part) (string, error) {
+	value, err := io.ReadAll(io.LimitReader(part, maxFormFieldSize))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(value)), nil
+}
+
+// parsePublishAt reads an optional RFC 3339 publish time
+func parsePublishAt(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	publishAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &publishAt, nil
+}
+
+// parseChannelID reads an optional channel ID
+func parseChannelID(value string) (*uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+	channelID, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
 	}
+	return &channelID, nil
 }
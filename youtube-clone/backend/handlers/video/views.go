@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// recordView is called by the player once it has played enough of the video to count a view
+func (v *videoHandler) recordView() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		videoUUID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid video ID"})
+			return
+		}
+		viewerUUID, _ := uuid.Parse(c.GetString(middlewares.UserIDInContext))
+
+		var req models.RecordViewRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := helpers.ValidateRequest(req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		counted, err := v.app.RecordView(c, videoUUID, viewerUUID, c.ClientIP(), req.WatchedSeconds)
+		if err != nil {
+			switch {
+			case errors.Is(err, helpers.ErrRecordNotFound):
+				c.JSON(http.StatusNotFound, gin.H{"error": "Video not found"})
+			case errors.Is(err, helpers.ErrVideoNotReady):
+				c.JSON(http.StatusConflict, gin.H{"error": "Video is still processing"})
+			case errors.Is(err, helpers.ErrViewTooShort):
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Not enough of the video was watched to count a view"})
+			default:
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record view"})
+			}
+			return
+		}
+
+		// the count itself is written asynchronously
+		c.JSON(http.StatusAccepted, gin.H{"counted": counted})
+	}
+}
@@ -0,0 +1,44 @@
+package wellknown
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/app"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// jwksMaxAge lets verifiers cache the key set, short enough for a scheduled key to be seen before it signs
+const jwksMaxAge = "public, max-age=300"
+
+type wellKnownHandler struct {
+	logger     *zerolog.Logger
+	app        *app.App
+	env        *models.Env
+	middleware middlewares.Middleware
+}
+
+// NewWellKnownHandler registers the /.well-known routes, served outside /api for other services to discover
+func NewWellKnownHandler(r *gin.RouterGroup, l *zerolog.Logger, a *app.App, e *models.Env, m middlewares.Middleware) {
+	wellKnown := wellKnownHandler{
+		logger:     l,
+		app:        a,
+		env:        e,
+		middleware: m,
+	}
+
+	wellKnownGroup := r.Group("/.well-known")
+
+	wellKnownGroup.GET("/jwks.json", wellKnown.jwks())
+}
+
+// jwks serves the public keys access and refresh tokens are verified with
+func (h *wellKnownHandler) jwks() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", jwksMaxAge)
+		c.JSON(http.StatusOK, h.middleware.JWKS())
+	}
+}
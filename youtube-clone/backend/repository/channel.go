@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type ChannelRepo interface {
+	Create(ctx context.Context, channel models.Channel) (*models.Channel, error)
+	GetByID(ctx context.Context, ID uuid.UUID) (*models.Channel, error)
+	GetByHandle(ctx context.Context, handle string) (*models.Channel, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Channel, error)
+	Update(ctx context.Context, channel models.Channel) (*models.Channel, error)
+	DeleteByID(ctx context.Context, ID uuid.UUID) error
+}
+
+type Channel struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewChannel creates a new reference to the Channel storage entity
+func NewChannel(s *Store) ChannelRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "channel").Logger()
+	channel := &Channel{
+		logger:  l,
+		storage: s,
+	}
+	channelDatabase := ChannelRepo(channel)
+	return channelDatabase
+}
+
+func (ch *Channel) Create(ctx context.Context, channel models.Channel) (*models.Channel, error) {
+	log := ch.logger.With().Str(helpers.LogStrRequestIDLevel, ch.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.channel.Create").Logger()
+
+	db := ch.storage.DB.WithContext(ctx).Model(&models.Channel{}).Create(&channel)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		if isDuplicateKey(db.Error) {
+			return nil, helpers.ErrChannelHandleTaken
+		}
+		return nil, helpers.ErrRecordCreationFailed
+	}
+
+	return &channel, nil
+}
+
+func (ch *Channel) GetByID(ctx context.Context, ID uuid.UUID) (*models.Channel, error) {
+	log := ch.logger.With().Str(helpers.LogStrRequestIDLevel, ch.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.channel.GetByID").Logger()
+
+	var channel models.Channel
+	db := ch.storage.DB.WithContext(ctx).Where("id = ?", ID.String()).Find(&channel)
+	if db.Error != nil || strings.EqualFold(channel.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &channel, nil
+}
+
+func (ch *Channel) GetByHandle(ctx context.Context, handle string) (*models.Channel, error) {
+	log := ch.logger.With().Str(helpers.LogStrRequestIDLevel, ch.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.channel.GetByHandle").Logger()
+
+	var channel models.Channel
+	db := ch.storage.DB.WithContext(ctx).Where("handle = ?", handle).Find(&channel)
+	if db.Error != nil || strings.EqualFold(channel.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &channel, nil
+}
+
+// GetByUserID returns the channels of a user, oldest first
+func (ch *Channel) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*models.Channel, error) {
+	log := ch.logger.With().Str(helpers.LogStrRequestIDLevel, ch.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.channel.GetByUserID").Logger()
+
+	var channels []*models.Channel
+	db := ch.storage.DB.WithContext(ctx).Where("user_id = ?", userID.String()).
+		Order("created_at asc").Find(&channels)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch channels")
+		return nil, helpers.ErrEmptyResult
+	}
+	return channels, nil
+}
+
+func (ch *Channel) Update(ctx context.Context, channel models.Channel) (*models.Channel, error) {
+	log := ch.logger.With().Str(helpers.LogStrRequestIDLevel, ch.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.channel.Update").Logger()
+
+	channel.UpdatedAt = time.Now()
+	// a map so emptied fields are written as well
+	db := ch.storage.DB.WithContext(ctx).Model(&models.Channel{}).Where("id = ?", channel.ID.String()).
+		Updates(map[string]interface{}{
+			"handle":       channel.Handle,
+			"display_name": channel.DisplayName,
+			"description":  channel.Description,
+			"banner_url":   channel.BannerURL,
+			"avatar_url":   channel.AvatarURL,
+			"updated_at":   channel.UpdatedAt,
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update channel")
+		if isDuplicateKey(db.Error) {
+			return nil, helpers.ErrChannelHandleTaken
+		}
+		return nil, helpers.ErrRecordUpdateFail
+	}
+	return &channel, nil
+}
+
+// DeleteByID removes a channel and its subscriptions, its videos stay with their uploader without a channel
+func (ch *Channel) DeleteByID(ctx context.Context, ID uuid.UUID) error {
+	log := ch.logger.With().Str(helpers.LogStrRequestIDLevel, ch.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.channel.DeleteByID").Logger()
+
+	err := ch.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Video{}).Where("channel_id = ?", ID.String()).
+			Update("channel_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("channel_id = ?", ID.String()).Delete(&models.Subscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", ID.String()).Delete(&models.Channel{}).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to delete channel")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
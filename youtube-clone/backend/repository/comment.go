@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type CommentRepo interface {
+	Create(ctx context.Context, comment models.Comment) (*models.Comment, error)
+	GetByID(ctx context.Context, ID uuid.UUID) (*models.Comment, error)
+	GetTopLevel(ctx context.Context, videoID uuid.UUID, sort string, p helpers.Page) ([]*models.Comment, helpers.PageInfo, error)
+	GetReplies(ctx context.Context, parentID uuid.UUID, p helpers.Page) ([]*models.Comment, helpers.PageInfo, error)
+	UpdateBody(ctx context.Context, ID uuid.UUID, body string) error
+	SoftDeleteByID(ctx context.Context, ID uuid.UUID) error
+	SetPinned(ctx context.Context, videoID, ID uuid.UUID, pinned bool) error
+}
+
+type Comment struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewComment creates a new reference to the Comment storage entity
+func NewComment(s *Store) CommentRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "comment").Logger()
+	comment := &Comment{
+		logger:  l,
+		storage: s,
+	}
+	commentDatabase := CommentRepo(comment)
+	return commentDatabase
+}
+
+// Create stores the comment and, for a reply, bumps the reply count of its parent
+func (cm *Comment) Create(ctx context.Context, comment models.Comment) (*models.Comment, error) {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.Create").Logger()
+
+	err := cm.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if comment.ParentID == nil {
+			return nil
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", comment.ParentID.String()).
+			Update("reply_count", gorm.Expr("reply_count + 1")).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &comment, nil
+}
+
+func (cm *Comment) GetByID(ctx context.Context, ID uuid.UUID) (*models.Comment, error) {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.GetByID").Logger()
+
+	var comment models.Comment
+	db := cm.storage.DB.WithContext(ctx).Where("id = ? AND deleted_at IS NULL", ID.String()).Find(&comment)
+	if db.Error != nil || strings.EqualFold(comment.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &comment, nil
+}
+
+// GetTopLevel lists the threads of a video, the pinned comment first, then by sort
+func (cm *Comment) GetTopLevel(ctx context.Context, videoID uuid.UUID, sort string, page helpers.Page) ([]*models.Comment, helpers.PageInfo, error) {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.GetTopLevel").Logger()
+
+	queryDraft := cm.storage.DB.WithContext(ctx).Model(&models.Comment{}).
+		Where("video_id = ? AND parent_id IS NULL AND deleted_at IS NULL", videoID.String()).
+		Order("pinned desc")
+	if sort == models.CommentSortTop {
+		queryDraft = queryDraft.Order("like_count desc").Order("created_at desc")
+	}
+
+	comments, pageInfo, err := paginate[models.Comment](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch list of comments")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return comments, pageInfo, nil
+}
+
+// GetReplies lists the replies of a thread, oldest first so they read as a conversation
+func (cm *Comment) GetReplies(ctx context.Context, parentID uuid.UUID, page helpers.Page) ([]*models.Comment, helpers.PageInfo, error) {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.GetReplies").Logger()
+
+	queryDraft := cm.storage.DB.WithContext(ctx).Model(&models.Comment{}).
+		Where("parent_id = ? AND deleted_at IS NULL", parentID.String()).
+		Order("created_at asc")
+
+	comments, pageInfo, err := paginate[models.Comment](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch list of replies")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return comments, pageInfo, nil
+}
+
+// UpdateBody replaces the text of a comment and flags it as edited
+func (cm *Comment) UpdateBody(ctx context.Context, ID uuid.UUID, body string) error {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.UpdateBody").Logger()
+
+	db := cm.storage.DB.WithContext(ctx).Model(&models.Comment{}).Where("id = ?", ID.String()).
+		Updates(map[string]interface{}{
+			"body":       body,
+			"edited":     true,
+			"updated_at": time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update comment")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// SoftDeleteByID hides a comment together with its replies. Deleting a reply
+// lowers the reply count of its thread.
+func (cm *Comment) SoftDeleteByID(ctx context.Context, id uuid.UUID) error {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.SoftDeleteByID").Logger()
+
+	err := cm.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := tx.Where("id = ? AND deleted_at IS NULL", id.String()).Find(&comment).Error; err != nil {
+			return err
+		}
+		if strings.EqualFold(comment.ID.String(), helpers.ZeroUUID) {
+			return nil
+		}
+
+		err := tx.Model(models.Comment{}).Where("(id = ? OR parent_id = ?) AND deleted_at IS NULL", id.String(), id.String()).
+			UpdateColumns(models.Comment{
+				DeletedAt: &gorm.DeletedAt{
+					Time:  time.Now(),
+					Valid: true,
+				},
+			}).Error
+		if err != nil || comment.ParentID == nil {
+			return err
+		}
+		return tx.Model(&models.Comment{}).Where("id = ? AND reply_count > 0", comment.ParentID.String()).
+			Update("reply_count", gorm.Expr("reply_count - 1")).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("soft delete failed")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+// SetPinned pins or unpins a comment of a video. A video has at most one pinned comment,
+// pinning another one unpins the previous.
+func (cm *Comment) SetPinned(ctx context.Context, videoID, ID uuid.UUID, pinned bool) error {
+	log := cm.logger.With().Str(helpers.LogStrRequestIDLevel, cm.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.comment.SetPinned").Logger()
+
+	err := cm.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pinned {
+			err := tx.Model(&models.Comment{}).Where("video_id = ? AND pinned = ?", videoID.String(), true).
+				Update("pinned", false).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Comment{}).Where("id = ? AND video_id = ?", ID.String(), videoID.String()).
+			Update("pinned", pinned).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to pin comment")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
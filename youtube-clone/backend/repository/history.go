@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type WatchHistoryRepo interface {
+	Save(ctx context.Context, entry models.WatchHistory) (*models.WatchHistory, error)
+	Get(ctx context.Context, userID, videoID uuid.UUID) (*models.WatchHistory, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, p helpers.Page) ([]*models.WatchHistory, helpers.PageInfo, error)
+	DeleteEntry(ctx context.Context, userID, videoID uuid.UUID) error
+	DeleteByUserID(ctx context.Context, userID uuid.UUID) error
+	GetSettings(ctx context.Context, userID uuid.UUID) (*models.HistorySettings, error)
+	SaveSettings(ctx context.Context, settings models.HistorySettings) (*models.HistorySettings, error)
+}
+
+type WatchHistory struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewWatchHistory creates a new reference to the WatchHistory storage entity
+func NewWatchHistory(s *Store) WatchHistoryRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "watch_history").Logger()
+	watchHistory := &WatchHistory{
+		logger:  l,
+		storage: s,
+	}
+	watchHistoryDatabase := WatchHistoryRepo(watchHistory)
+	return watchHistoryDatabase
+}
+
+// Save records the progress of a user on a video, replacing the earlier progress on the same video
+func (w *WatchHistory) Save(ctx context.Context, entry models.WatchHistory) (*models.WatchHistory, error) {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.Save").Logger()
+
+	db := w.storage.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "video_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"position", "percent_watched", "completed", "watched_at", "updated_at"}),
+	}).Create(&entry)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to save watch progress")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+
+	// on a conflict entry still holds the ID and creation time generated for the insert
+	var saved models.WatchHistory
+	db = w.storage.DB.WithContext(ctx).
+		Where("user_id = ? AND video_id = ?", entry.UserID.String(), entry.VideoID.String()).Find(&saved)
+	if db.Error != nil || strings.EqualFold(saved.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("unable to reload watch progress")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &saved, nil
+}
+
+func (w *WatchHistory) Get(ctx context.Context, userID, videoID uuid.UUID) (*models.WatchHistory, error) {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.Get").Logger()
+
+	var entry models.WatchHistory
+	db := w.storage.DB.WithContext(ctx).
+		Where("user_id = ? AND video_id = ?", userID.String(), videoID.String()).Find(&entry)
+	if db.Error != nil || strings.EqualFold(entry.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &entry, nil
+}
+
+// GetByUserID lists the history of a user, most recently watched first, limited to the videos they can still see
+func (w *WatchHistory) GetByUserID(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.WatchHistory, helpers.PageInfo, error) {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.GetByUserID").Logger()
+
+	// videos deleted or made private since they were watched drop out of the history
+	visible := w.storage.DB.Model(&models.Video{}).Select("id").Where("deleted_at IS NULL").
+		Where(w.storage.DB.Where("visibility = ?", models.VideoVisibilityUnlisted).
+			Or(publicVideos(w.storage.DB, time.Now())).
+			Or(ownVideos(w.storage.DB, userID)))
+	queryDraft := w.storage.DB.WithContext(ctx).Model(&models.WatchHistory{}).
+		Where("user_id = ? AND video_id IN (?)", userID.String(), visible).
+		Order("watched_at desc")
+
+	entries, pageInfo, err := paginate[models.WatchHistory](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch watch history")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return entries, pageInfo, nil
+}
+
+func (w *WatchHistory) DeleteEntry(ctx context.Context, userID, videoID uuid.UUID) error {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.DeleteEntry").Logger()
+
+	db := w.storage.DB.WithContext(ctx).
+		Where("user_id = ? AND video_id = ?", userID.String(), videoID.String()).Delete(&models.WatchHistory{})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to delete watch history entry")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+func (w *WatchHistory) DeleteByUserID(ctx context.Context, userID uuid.UUID) error {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.DeleteByUserID").Logger()
+
+	db := w.storage.DB.WithContext(ctx).Where("user_id = ?", userID.String()).Delete(&models.WatchHistory{})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to clear watch history")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+func (w *WatchHistory) GetSettings(ctx context.Context, userID uuid.UUID) (*models.HistorySettings, error) {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.GetSettings").Logger()
+
+	var settings models.HistorySettings
+	db := w.storage.DB.WithContext(ctx).Where("user_id = ?", userID.String()).Find(&settings)
+	if db.Error != nil || strings.EqualFold(settings.UserID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &settings, nil
+}
+
+func (w *WatchHistory) SaveSettings(ctx context.Context, settings models.HistorySettings) (*models.HistorySettings, error) {
+	log := w.logger.With().Str(helpers.LogStrRequestIDLevel, w.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.watch_history.SaveSettings").Logger()
+
+	db := w.storage.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"paused", "updated_at"}),
+	}).Create(&settings)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to save history settings")
+		return nil, helpers.ErrRecordUpdateFail
+	}
+	return &settings, nil
+}
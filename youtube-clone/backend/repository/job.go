@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type JobRepo interface {
+	Enqueue(ctx context.Context, job models.Job) (*models.Job, error)
+	ClaimNext(ctx context.Context, jobTypes []string) (*models.Job, error)
+	Complete(ctx context.Context, ID uuid.UUID) error
+	Retry(ctx context.Context, ID uuid.UUID, lastError string, runAt time.Time) error
+	Fail(ctx context.Context, ID uuid.UUID, lastError string) error
+	Heartbeat(ctx context.Context, ID uuid.UUID) error
+	ReleaseStale(ctx context.Context, lockedBefore time.Time) (int64, error)
+}
+
+type Job struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewJob creates a new reference to the Job storage entity
+func NewJob(s *Store) JobRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "job").Logger()
+	job := &Job{
+		logger:  l,
+		storage: s,
+	}
+	jobDatabase := JobRepo(job)
+	return jobDatabase
+}
+
+func (j *Job) Enqueue(ctx context.Context, job models.Job) (*models.Job, error) {
+	log := j.logger.With().Str(helpers.LogStrRequestIDLevel, j.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.job.Enqueue").Logger()
+
+	job.ID = uuid.New()
+	job.Status = models.JobStatusPending
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+
+	db := j.storage.DB.WithContext(ctx).Model(&models.Job{}).Create(&job)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+
+	return &job, nil
+}
+
+// ClaimNext marks the oldest due pending job of one of jobTypes as running and returns it.
+// Rows locked by other workers are skipped so several workers never claim the same job.
+func (j *Job) ClaimNext(ctx context.Context, jobTypes []string) (*models.Job, error) {
+	log := j.logger.With().Str(helpers.LogStrRequestIDLevel, j.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.job.ClaimNext").Logger()
+
+	var job models.Job
+	err := j.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND type IN ? AND run_at <= ?", models.JobStatusPending, jobTypes, time.Now()).
+			Order("run_at asc").
+			Limit(1).
+			Find(&job)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return helpers.ErrRecordNotFound
+		}
+
+		now := time.Now()
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedAt = &now
+		return tx.Model(&models.Job{}).Where("id = ?", job.ID.String()).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"locked_at":  now,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		if !errors.Is(err, helpers.ErrRecordNotFound) {
+			log.Err(err).Msg("unable to claim job")
+		}
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (j *Job) Complete(ctx context.Context, ID uuid.UUID) error {
+	return j.setStatus(ctx, "repository.job.Complete", ID, map[string]interface{}{
+		"status":     models.JobStatusDone,
+		"last_error": "",
+		"locked_at":  nil,
+		"updated_at": time.Now(),
+	})
+}
+
+// Retry puts a failed job back in the queue to be picked up again at runAt
+func (j *Job) Retry(ctx context.Context, ID uuid.UUID, lastError string, runAt time.Time) error {
+	return j.setStatus(ctx, "repository.job.Retry", ID, map[string]interface{}{
+		"status":     models.JobStatusPending,
+		"last_error": lastError,
+		"run_at":     runAt,
+		"locked_at":  nil,
+		"updated_at": time.Now(),
+	})
+}
+
+// Fail marks a job as permanently failed
+func (j *Job) Fail(ctx context.Context, ID uuid.UUID, lastError string) error {
+	return j.setStatus(ctx, "repository.job.Fail", ID, map[string]interface{}{
+		"status":     models.JobStatusFailed,
+		"last_error": lastError,
+		"locked_at":  nil,
+		"updated_at": time.Now(),
+	})
+}
+
+// Heartbeat renews the lock of a running job so ReleaseStale leaves it to its worker
+func (j *Job) Heartbeat(ctx context.Context, ID uuid.UUID) error {
+	now := time.Now()
+	return j.setStatus(ctx, "repository.job.Heartbeat", ID, map[string]interface{}{
+		"locked_at":  now,
+		"updated_at": now,
+	})
+}
+
+// ReleaseStale returns jobs left running by a worker that died before finishing them to the queue
+func (j *Job) ReleaseStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	log := j.logger.With().Str(helpers.LogStrRequestIDLevel, j.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.job.ReleaseStale").Logger()
+
+	db := j.storage.DB.WithContext(ctx).Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", models.JobStatusRunning, lockedBefore).
+		Updates(map[string]interface{}{
+			"status":     models.JobStatusPending,
+			"locked_at":  nil,
+			"updated_at": time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to release stale jobs")
+		return 0, helpers.ErrRecordUpdateFail
+	}
+	return db.RowsAffected, nil
+}
+
+func (j *Job) setStatus(ctx context.Context, method string, ID uuid.UUID, columns map[string]interface{}) error {
+	log := j.logger.With().Str(helpers.LogStrRequestIDLevel, j.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, method).Logger()
+
+	db := j.storage.DB.WithContext(ctx).Model(&models.Job{}).Where("id = ?", ID.String()).Updates(columns)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update job")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
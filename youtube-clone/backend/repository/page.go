@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+)
+
+// paginate counts the rows matched by queryDraft and fetches the requested page of them.
+// Orderings already on queryDraft take precedence over page.SortBy.
+func paginate[T any](queryDraft *gorm.DB, page helpers.Page) ([]*T, helpers.PageInfo, error) {
+	var rows []*T
+	offset := 0
+	// load defaults
+	if page.Number == nil {
+		tmpPageNumber := helpers.PageDefaultNumber
+		page.Number = &tmpPageNumber
+	}
+	if page.Size == nil {
+		tmpPageSize := helpers.PageDefaultSize
+		page.Size = &tmpPageSize
+	}
+	if page.SortBy == nil {
+		tmpPageSortBy := helpers.PageDefaultSortBy
+		page.SortBy = &tmpPageSortBy
+	}
+	if page.SortDirectionDesc == nil {
+		tmpPageSortDirectionDesc := helpers.PageDefaultSortDirectionDesc
+		page.SortDirectionDesc = &tmpPageSortDirectionDesc
+	}
+
+	if *page.Number > 1 {
+		offset = *page.Size * (*page.Number - 1)
+	}
+	sortDirection := helpers.PageSortDirectionDescending
+	if !*page.SortDirectionDesc {
+		sortDirection = helpers.PageSortDirectionAscending
+	}
+
+	// then do counting
+	var count int64
+	if err := queryDraft.Session(&gorm.Session{}).Count(&count).Error; err != nil {
+		return nil, helpers.PageInfo{}, err
+	}
+
+	db := queryDraft.Offset(offset).Limit(*page.Size).
+		Order(fmt.Sprintf("%s %s", *page.SortBy, sortDirection)).
+		Find(&rows)
+	if db.Error != nil {
+		return nil, helpers.PageInfo{}, db.Error
+	}
+
+	return rows, helpers.PageInfo{
+		Page:            *page.Number,
+		Size:            *page.Size,
+		HasNextPage:     int64(offset+*page.Size) < count,
+		HasPreviousPage: *page.Number > 1,
+		TotalCount:      count,
+	}, nil
+}
@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type PlaylistRepo interface {
+	Create(ctx context.Context, playlist models.Playlist) (*models.Playlist, error)
+	CreateIfMissing(ctx context.Context, playlist models.Playlist) error
+	GetUserIDsWithoutKind(ctx context.Context, kind string, limit int) ([]uuid.UUID, error)
+	GetByID(ctx context.Context, ID uuid.UUID) (*models.Playlist, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID, publicOnly bool, p helpers.Page) ([]*models.Playlist, helpers.PageInfo, error)
+	Update(ctx context.Context, playlist models.Playlist) (*models.Playlist, error)
+	DeleteByID(ctx context.Context, ID uuid.UUID) error
+	GetItems(ctx context.Context, playlistID uuid.UUID, p helpers.Page) ([]*models.PlaylistItem, helpers.PageInfo, error)
+	GetItem(ctx context.Context, playlistID, itemID uuid.UUID) (*models.PlaylistItem, error)
+	AddItem(ctx context.Context, item models.PlaylistItem) (*models.PlaylistItem, error)
+	RemoveItem(ctx context.Context, playlistID, itemID uuid.UUID) error
+	MoveItem(ctx context.Context, playlistID, itemID uuid.UUID, position int) (*models.PlaylistItem, error)
+	GetCollaborators(ctx context.Context, playlistID uuid.UUID) ([]*models.PlaylistCollaborator, error)
+	AddCollaborator(ctx context.Context, collaborator models.PlaylistCollaborator) error
+	RemoveCollaborator(ctx context.Context, playlistID, userID uuid.UUID) error
+}
+
+type Playlist struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewPlaylist creates a new reference to the Playlist storage entity
+func NewPlaylist(s *Store) PlaylistRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "playlist").Logger()
+	playlist := &Playlist{
+		logger:  l,
+		storage: s,
+	}
+	playlistDatabase := PlaylistRepo(playlist)
+	return playlistDatabase
+}
+
+func (p *Playlist) Create(ctx context.Context, playlist models.Playlist) (*models.Playlist, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.Create").Logger()
+
+	db := p.storage.DB.WithContext(ctx).Create(&playlist)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &playlist, nil
+}
+
+// CreateIfMissing creates the playlist unless a playlist with its ID already exists
+func (p *Playlist) CreateIfMissing(ctx context.Context, playlist models.Playlist) error {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.CreateIfMissing").Logger()
+
+	db := p.storage.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&playlist)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return helpers.ErrRecordCreationFailed
+	}
+	return nil
+}
+
+// GetUserIDsWithoutKind lists up to limit users that have no playlist of the given kind
+func (p *Playlist) GetUserIDsWithoutKind(ctx context.Context, kind string, limit int) ([]uuid.UUID, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.GetUserIDsWithoutKind").Logger()
+
+	db := p.storage.DB.WithContext(ctx)
+	var userIDs []uuid.UUID
+	err := db.Model(&models.User{}).
+		Where("NOT EXISTS (?)", db.Model(&models.Playlist{}).Select("1").
+			Where("playlists.user_id = users.id AND playlists.kind = ?", kind)).
+		Order("id").Limit(limit).Pluck("id", &userIDs).Error
+	if err != nil {
+		log.Err(err).Msg("could not fetch users without playlist")
+		return nil, helpers.ErrEmptyResult
+	}
+	return userIDs, nil
+}
+
+func (p *Playlist) GetByID(ctx context.Context, ID uuid.UUID) (*models.Playlist, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.GetByID").Logger()
+
+	var playlist models.Playlist
+	db := p.storage.DB.WithContext(ctx).Where("id = ?", ID.String()).Find(&playlist)
+	if db.Error != nil || strings.EqualFold(playlist.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &playlist, nil
+}
+
+// GetByUserID lists the playlists of a user, only the public ones when publicOnly is set
+func (p *Playlist) GetByUserID(ctx context.Context, userID uuid.UUID, publicOnly bool, page helpers.Page) ([]*models.Playlist, helpers.PageInfo, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.GetByUserID").Logger()
+
+	queryDraft := p.storage.DB.WithContext(ctx).Model(&models.Playlist{}).Where("user_id = ?", userID.String())
+	if publicOnly {
+		queryDraft = queryDraft.Where("visibility = ?", models.VideoVisibilityPublic)
+	}
+
+	playlists, pageInfo, err := paginate[models.Playlist](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch list of playlists")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return playlists, pageInfo, nil
+}
+
+func (p *Playlist) Update(ctx context.Context, playlist models.Playlist) (*models.Playlist, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.Update").Logger()
+
+	playlist.UpdatedAt = time.Now()
+	db := p.storage.DB.WithContext(ctx).Model(&models.Playlist{}).Where("id = ?", playlist.ID.String()).
+		Updates(map[string]interface{}{
+			"title":       playlist.Title,
+			"description": playlist.Description,
+			"visibility":  playlist.Visibility,
+			"updated_at":  playlist.UpdatedAt,
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update playlist")
+		return nil, helpers.ErrRecordUpdateFail
+	}
+	return &playlist, nil
+}
+
+// DeleteByID removes a playlist with its items and collaborators
+func (p *Playlist) DeleteByID(ctx context.Context, ID uuid.UUID) error {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.DeleteByID").Logger()
+
+	err := p.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("playlist_id = ?", ID.String()).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("playlist_id = ?", ID.String()).Delete(&models.PlaylistCollaborator{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", ID.String()).Delete(&models.Playlist{}).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("delete failed")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+// GetItems lists the items of a playlist in playlist order
+func (p *Playlist) GetItems(ctx context.Context, playlistID uuid.UUID, page helpers.Page) ([]*models.PlaylistItem, helpers.PageInfo, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.GetItems").Logger()
+
+	queryDraft := p.storage.DB.WithContext(ctx).Model(&models.PlaylistItem{}).
+		Where("playlist_id = ?", playlistID.String()).
+		Order("position asc")
+
+	items, pageInfo, err := paginate[models.PlaylistItem](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch playlist items")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return items, pageInfo, nil
+}
+
+func (p *Playlist) GetItem(ctx context.Context, playlistID, itemID uuid.UUID) (*models.PlaylistItem, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.GetItem").Logger()
+
+	var item models.PlaylistItem
+	db := p.storage.DB.WithContext(ctx).
+		Where("id = ? AND playlist_id = ?", itemID.String(), playlistID.String()).Find(&item)
+	if db.Error != nil || strings.EqualFold(item.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &item, nil
+}
+
+// AddItem appends a video to the end of a playlist
+func (p *Playlist) AddItem(ctx context.Context, item models.PlaylistItem) (*models.PlaylistItem, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.AddItem").Logger()
+
+	err := p.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		playlist, err := lockPlaylist(tx, item.PlaylistID)
+		if err != nil {
+			return err
+		}
+
+		item.Position = int(playlist.ItemCount)
+		if err := tx.Create(&item).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Playlist{}).Where("id = ?", playlist.ID.String()).
+			Updates(map[string]interface{}{
+				"item_count": gorm.Expr("item_count + 1"),
+				"updated_at": time.Now(),
+			}).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to add playlist item")
+		if isDuplicateKey(err) {
+			return nil, helpers.ErrPlaylistItemExists
+		}
+		if errors.Is(err, helpers.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &item, nil
+}
+
+// RemoveItem deletes an item and closes the gap it leaves in the positions
+func (p *Playlist) RemoveItem(ctx context.Context, playlistID, itemID uuid.UUID) error {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.RemoveItem").Logger()
+
+	err := p.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockPlaylist(tx, playlistID); err != nil {
+			return err
+		}
+
+		var item models.PlaylistItem
+		err := tx.Where("id = ? AND playlist_id = ?", itemID.String(), playlistID.String()).Find(&item).Error
+		if err != nil || strings.EqualFold(item.ID.String(), helpers.ZeroUUID) {
+			return err
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		err = tx.Model(&models.PlaylistItem{}).Where("playlist_id = ? AND position > ?", playlistID.String(), item.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Playlist{}).Where("id = ? AND item_count > 0", playlistID.String()).
+			Updates(map[string]interface{}{
+				"item_count": gorm.Expr("item_count - 1"),
+				"updated_at": time.Now(),
+			}).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to remove playlist item")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+// MoveItem moves an item to position, shifting the items in between by one. Positions past the
+// end of the playlist move the item to the end.
+func (p *Playlist) MoveItem(ctx context.Context, playlistID, itemID uuid.UUID, position int) (*models.PlaylistItem, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.MoveItem").Logger()
+
+	var item models.PlaylistItem
+	err := p.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		playlist, err := lockPlaylist(tx, playlistID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("id = ? AND playlist_id = ?", itemID.String(), playlistID.String()).Find(&item).Error
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(item.ID.String(), helpers.ZeroUUID) {
+			return helpers.ErrRecordNotFound
+		}
+
+		position = min(position, int(playlist.ItemCount)-1)
+		if position == item.Position {
+			return nil
+		}
+
+		items := tx.Model(&models.PlaylistItem{}).Where("playlist_id = ?", playlistID.String())
+		if position > item.Position {
+			err = items.Where("position > ? AND position <= ?", item.Position, position).
+				Update("position", gorm.Expr("position - 1")).Error
+		} else {
+			err = items.Where("position >= ? AND position < ?", position, item.Position).
+				Update("position", gorm.Expr("position + 1")).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		item.Position = position
+		return tx.Model(&item).Update("position", position).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to move playlist item")
+		if errors.Is(err, helpers.ErrRecordNotFound) {
+			return nil, err
+		}
+		return nil, helpers.ErrRecordUpdateFail
+	}
+	return &item, nil
+}
+
+func (p *Playlist) GetCollaborators(ctx context.Context, playlistID uuid.UUID) ([]*models.PlaylistCollaborator, error) {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.GetCollaborators").Logger()
+
+	var collaborators []*models.PlaylistCollaborator
+	db := p.storage.DB.WithContext(ctx).Where("playlist_id = ?", playlistID.String()).
+		Order("created_at asc").Find(&collaborators)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch playlist collaborators")
+		return nil, helpers.ErrEmptyResult
+	}
+	return collaborators, nil
+}
+
+// AddCollaborator allows a user to add videos to a playlist, adding an existing collaborator is a no-op
+func (p *Playlist) AddCollaborator(ctx context.Context, collaborator models.PlaylistCollaborator) error {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.AddCollaborator").Logger()
+
+	db := p.storage.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&collaborator)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to add playlist collaborator")
+		return helpers.ErrRecordCreationFailed
+	}
+	return nil
+}
+
+func (p *Playlist) RemoveCollaborator(ctx context.Context, playlistID, userID uuid.UUID) error {
+	log := p.logger.With().Str(helpers.LogStrRequestIDLevel, p.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.playlist.RemoveCollaborator").Logger()
+
+	db := p.storage.DB.WithContext(ctx).
+		Where("playlist_id = ? AND user_id = ?", playlistID.String(), userID.String()).
+		Delete(&models.PlaylistCollaborator{})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to remove playlist collaborator")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+// lockPlaylist loads a playlist for update, serialising the edits of its item positions
+func lockPlaylist(tx *gorm.DB, ID uuid.UUID) (*models.Playlist, error) {
+	var playlist models.Playlist
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", ID.String()).Find(&playlist).Error
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(playlist.ID.String(), helpers.ZeroUUID) {
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &playlist, nil
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestPlaylistGetUserIDsWithoutKind(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, &models.User{}, &models.Playlist{})
+	repo := NewPlaylist(store)
+
+	users := map[string]uuid.UUID{}
+	for _, name := range []string{"none", "custom", "watch-later"} {
+		users[name] = uuid.New()
+		user := models.User{ID: users[name], Username: name, Email: name + "@example.com", Password: "password"}
+		if err := store.DB.Create(&user).Error; err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+	}
+	for name, kind := range map[string]string{"custom": models.PlaylistKindCustom, "watch-later": models.PlaylistKindWatchLater} {
+		playlist := models.Playlist{ID: uuid.New(), UserID: users[name], Kind: kind, Title: kind}
+		if err := store.DB.Create(&playlist).Error; err != nil {
+			t.Fatalf("create playlist: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []uuid.UUID
+	}{
+		{name: "users without the kind", limit: 10, want: []uuid.UUID{users["none"], users["custom"]}},
+		{name: "limited", limit: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.GetUserIDsWithoutKind(ctx, models.PlaylistKindWatchLater, tt.limit)
+			if err != nil {
+				t.Fatalf("GetUserIDsWithoutKind() error = %v", err)
+			}
+			if len(got) > tt.limit || slices.Contains(got, users["watch-later"]) {
+				t.Fatalf("GetUserIDsWithoutKind() = %v", got)
+			}
+			for _, userID := range tt.want {
+				if !slices.Contains(got, userID) {
+					t.Errorf("GetUserIDsWithoutKind() = %v, missing %s", got, userID)
+				}
+			}
+		})
+	}
+}
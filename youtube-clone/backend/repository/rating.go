@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type RatingRepo interface {
+	Rate(ctx context.Context, rating models.Rating) (*models.Rating, error)
+	Remove(ctx context.Context, userID uuid.UUID, targetType string, targetID uuid.UUID) error
+	Get(ctx context.Context, userID uuid.UUID, targetType string, targetID uuid.UUID) (*models.Rating, error)
+}
+
+type Rating struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewRating creates a new reference to the Rating storage entity
+func NewRating(s *Store) RatingRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "rating").Logger()
+	rating := &Rating{
+		logger:  l,
+		storage: s,
+	}
+	ratingDatabase := RatingRepo(rating)
+	return ratingDatabase
+}
+
+// Rate creates or changes the rating of a user on a target. The like and dislike counters of
+// the target are updated in the same transaction as the rating, so they always match the rows.
+func (r *Rating) Rate(ctx context.Context, rating models.Rating) (*models.Rating, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.rating.Rate").Logger()
+
+	err := r.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// a single insert that skips existing rows, a locking read of a missing row takes a gap lock
+		// that deadlocks concurrent first ratings
+		db := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rating)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 1 {
+			return updateRatingCounter(tx, rating.TargetType, rating.TargetID, rating.Value, 1)
+		}
+
+		// the row exists, so the lock only covers that row
+		var existing models.Rating
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND target_type = ? AND target_id = ?", rating.UserID.String(), rating.TargetType, rating.TargetID.String()).
+			First(&existing).Error
+		if err != nil {
+			return err
+		}
+
+		if existing.Value == rating.Value {
+			rating = existing
+			return nil
+		}
+		previous := existing.Value
+		existing.Value = rating.Value
+		existing.UpdatedAt = time.Now()
+		rating = existing
+		err = tx.Model(&existing).Updates(map[string]interface{}{
+			"value":      existing.Value,
+			"updated_at": existing.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := updateRatingCounter(tx, rating.TargetType, rating.TargetID, previous, -1); err != nil {
+			return err
+		}
+		return updateRatingCounter(tx, rating.TargetType, rating.TargetID, rating.Value, 1)
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to rate")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &rating, nil
+}
+
+// Remove deletes the rating of a user on a target, if there is one, and takes it off the counters
+func (r *Rating) Remove(ctx context.Context, userID uuid.UUID, targetType string, targetID uuid.UUID) error {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.rating.Remove").Logger()
+
+	err := r.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.Rating
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND target_type = ? AND target_id = ?", userID.String(), targetType, targetID.String()).
+			Find(&existing).Error
+		if err != nil || strings.EqualFold(existing.ID.String(), helpers.ZeroUUID) {
+			return err
+		}
+
+		if err := tx.Delete(&existing).Error; err != nil {
+			return err
+		}
+		return updateRatingCounter(tx, targetType, targetID, existing.Value, -1)
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to remove rating")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+func (r *Rating) Get(ctx context.Context, userID uuid.UUID, targetType string, targetID uuid.UUID) (*models.Rating, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.rating.Get").Logger()
+
+	var rating models.Rating
+	db := r.storage.DB.WithContext(ctx).
+		Where("user_id = ? AND target_type = ? AND target_id = ?", userID.String(), targetType, targetID.String()).
+		Find(&rating)
+	if db.Error != nil || strings.EqualFold(rating.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &rating, nil
+}
+
+// updateRatingCounter adds delta to the like or dislike counter of the rated row. The counter is
+// changed in SQL rather than read and written back, so concurrent ratings do not lose updates.
+func updateRatingCounter(tx *gorm.DB, targetType string, targetID uuid.UUID, value string, delta int) error {
+	column := "like_count"
+	if value == models.RatingDislike {
+		column = "dislike_count"
+	}
+
+	var model interface{} = &models.Video{}
+	if targetType == models.RatingTargetComment {
+		model = &models.Comment{}
+	}
+
+	query := tx.Model(model).Where("id = ?", targetID.String())
+	if delta < 0 {
+		query = query.Where(column + " > 0")
+	}
+	return query.Update(column, gorm.Expr(column+" + ?", delta)).Error
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestRatingRate(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, &models.Video{}, &models.Rating{})
+	repo := NewRating(store)
+
+	video := models.Video{ID: uuid.New(), UserID: uuid.New(), Title: "video"}
+	if err := store.DB.Create(&video).Error; err != nil {
+		t.Fatalf("create video: %v", err)
+	}
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		value        string
+		wantLikes    int64
+		wantDislikes int64
+	}{
+		{name: "first rating", value: models.RatingLike, wantLikes: 1},
+		{name: "same rating again", value: models.RatingLike, wantLikes: 1},
+		{name: "changed rating", value: models.RatingDislike, wantDislikes: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Rate(ctx, models.Rating{
+				ID:         uuid.New(),
+				UserID:     userID,
+				TargetType: models.RatingTargetVideo,
+				TargetID:   video.ID,
+				Value:      tt.value,
+			})
+			if err != nil {
+				t.Fatalf("Rate() error = %v", err)
+			}
+			if got.Value != tt.value {
+				t.Errorf("Rate() value = %q, want %q", got.Value, tt.value)
+			}
+
+			var stored models.Video
+			if err := store.DB.First(&stored, "id = ?", video.ID.String()).Error; err != nil {
+				t.Fatalf("get video: %v", err)
+			}
+			if stored.LikeCount != tt.wantLikes || stored.DislikeCount != tt.wantDislikes {
+				t.Errorf("counters = %d/%d, want %d/%d", stored.LikeCount, stored.DislikeCount, tt.wantLikes, tt.wantDislikes)
+			}
+		})
+	}
+}
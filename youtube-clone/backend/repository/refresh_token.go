@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type RefreshTokenRepo interface {
+	Create(ctx context.Context, token models.RefreshToken) (*models.RefreshToken, error)
+	Rotate(ctx context.Context, currentID uuid.UUID, next models.RefreshToken) (*models.RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
+}
+
+type RefreshToken struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewRefreshToken creates a new reference to the RefreshToken storage entity
+func NewRefreshToken(s *Store) RefreshTokenRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "refresh_token").Logger()
+	refreshToken := &RefreshToken{
+		logger:  l,
+		storage: s,
+	}
+	refreshTokenDatabase := RefreshTokenRepo(refreshToken)
+	return refreshTokenDatabase
+}
+
+func (r *RefreshToken) Create(ctx context.Context, token models.RefreshToken) (*models.RefreshToken, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.refresh_token.Create").Logger()
+
+	db := r.storage.DB.WithContext(ctx).Create(&token)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &token, nil
+}
+
+// Rotate marks the current token as used and stores next, its successor in the same family. Presenting
+// a token that was already used means it leaked, so the whole family is revoked and
+// helpers.ErrRefreshTokenReused returned.
+func (r *RefreshToken) Rotate(ctx context.Context, currentID uuid.UUID, next models.RefreshToken) (*models.RefreshToken, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.refresh_token.Rotate").Logger()
+
+	reused := false
+	err := r.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", currentID.String()).Find(&current).Error
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(current.ID.String(), helpers.ZeroUUID) ||
+			current.UserID != next.UserID || current.FamilyID != next.FamilyID {
+			return helpers.ErrInvalidRefreshToken
+		}
+
+		now := time.Now()
+		if current.UsedAt != nil {
+			// the revocation has to be committed, so the transaction must not fail
+			reused = true
+			return revokeFamily(tx, current.FamilyID, now)
+		}
+		if current.RevokedAt != nil || !now.Before(current.ExpiresAt) {
+			return helpers.ErrInvalidRefreshToken
+		}
+
+		err = tx.Model(&current).Updates(map[string]interface{}{
+			"used_at":     now,
+			"replaced_by": next.ID.String(),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&next).Error
+	})
+	if errors.Is(err, helpers.ErrInvalidRefreshToken) {
+		return nil, err
+	}
+	if err != nil {
+		log.Err(err).Msg("unable to rotate refresh token")
+		return nil, helpers.ErrRecordUpdateFail
+	}
+	if reused {
+		log.Warn().Str("family", next.FamilyID.String()).Msg("refresh token reused, family revoked")
+		return nil, helpers.ErrRefreshTokenReused
+	}
+	return &next, nil
+}
+
+// RevokeFamily revokes every token of a family, the tokens cannot be refreshed anymore
+func (r *RefreshToken) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.refresh_token.RevokeFamily").Logger()
+
+	if err := revokeFamily(r.storage.DB.WithContext(ctx), familyID, time.Now()); err != nil {
+		log.Err(err).Msg("unable to revoke refresh token family")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// RevokeByUserID revokes every refresh token of a user
+func (r *RefreshToken) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.refresh_token.RevokeByUserID").Logger()
+
+	db := r.storage.DB.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID.String()).
+		Update("revoked_at", time.Now())
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to revoke refresh tokens of user")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+func revokeFamily(tx *gorm.DB, familyID uuid.UUID, now time.Time) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID.String()).
+		Update("revoked_at", now).Error
+}
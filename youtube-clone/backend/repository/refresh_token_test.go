@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// newTestStore opens an in-memory SQLite database with the given models migrated
+func newTestStore(t *testing.T, tables ...interface{}) *Store {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard, TranslateError: true})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// every connection would get a database of its own
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	z := zerolog.Nop()
+	return &Store{logger: &z, DB: db}
+}
+
+func TestRefreshTokenRotate(t *testing.T) {
+	ctx := context.Background()
+	userID, familyID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		current func(token *models.RefreshToken) // adjusts the stored token before rotating it
+		reused  bool                             // rotates the token once before, so it is reused
+		next    func(token *models.RefreshToken) // adjusts the successor
+		wantErr error
+	}{
+		{name: "first use rotates"},
+		{name: "second use revokes the family", reused: true, wantErr: helpers.ErrRefreshTokenReused},
+		{
+			name:    "revoked token",
+			current: func(token *models.RefreshToken) { now := time.Now(); token.RevokedAt = &now },
+			wantErr: helpers.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "expired token",
+			current: func(token *models.RefreshToken) { token.ExpiresAt = time.Now().Add(-time.Minute) },
+			wantErr: helpers.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "successor of another family",
+			next:    func(token *models.RefreshToken) { token.FamilyID = uuid.New() },
+			wantErr: helpers.ErrInvalidRefreshToken,
+		},
+		{
+			name:    "successor of another user",
+			next:    func(token *models.RefreshToken) { token.UserID = uuid.New() },
+			wantErr: helpers.ErrInvalidRefreshToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, &models.RefreshToken{})
+			repo := NewRefreshToken(store)
+
+			newToken := func() models.RefreshToken {
+				return models.RefreshToken{
+					ID:        uuid.New(),
+					FamilyID:  familyID,
+					UserID:    userID,
+					ExpiresAt: time.Now().Add(time.Hour),
+				}
+			}
+			current := newToken()
+			if tt.current != nil {
+				tt.current(&current)
+			}
+			if _, err := repo.Create(ctx, current); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if tt.reused {
+				if _, err := repo.Rotate(ctx, current.ID, newToken()); err != nil {
+					t.Fatalf("first Rotate: %v", err)
+				}
+			}
+
+			next := newToken()
+			if tt.next != nil {
+				tt.next(&next)
+			}
+			_, err := repo.Rotate(ctx, current.ID, next)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Rotate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			var tokens []models.RefreshToken
+			if err := store.DB.Where("family_id = ?", familyID.String()).Find(&tokens).Error; err != nil {
+				t.Fatalf("list tokens: %v", err)
+			}
+			stored := map[uuid.UUID]models.RefreshToken{}
+			for _, token := range tokens {
+				stored[token.ID] = token
+			}
+			if _, ok := stored[next.ID]; ok != (tt.wantErr == nil) {
+				t.Errorf("successor stored = %v, want %v", ok, tt.wantErr == nil)
+			}
+
+			switch {
+			case tt.wantErr == nil:
+				used := stored[current.ID]
+				if used.UsedAt == nil || used.ReplacedBy == nil || *used.ReplacedBy != next.ID {
+					t.Errorf("rotated token not marked as replaced by the successor: %+v", used)
+				}
+			case errors.Is(tt.wantErr, helpers.ErrRefreshTokenReused):
+				for _, token := range tokens {
+					if token.RevokedAt == nil {
+						t.Errorf("token %s of the reused family is not revoked", token.ID)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type RenditionRepo interface {
+	ReplaceForVideo(ctx context.Context, videoID uuid.UUID, renditions []models.Rendition) error
+	GetByVideoID(ctx context.Context, videoID uuid.UUID) ([]*models.Rendition, error)
+}
+
+type Rendition struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewRendition creates a new reference to the Rendition storage entity
+func NewRendition(s *Store) RenditionRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "rendition").Logger()
+	rendition := &Rendition{
+		logger:  l,
+		storage: s,
+	}
+	renditionDatabase := RenditionRepo(rendition)
+	return renditionDatabase
+}
+
+// ReplaceForVideo swaps the renditions of a video in one transaction, so a re-run job never leaves duplicates
+func (r *Rendition) ReplaceForVideo(ctx context.Context, videoID uuid.UUID, renditions []models.Rendition) error {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.rendition.ReplaceForVideo").Logger()
+
+	err := r.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ?", videoID.String()).Delete(&models.Rendition{}).Error; err != nil {
+			return err
+		}
+		if len(renditions) == 0 {
+			return nil
+		}
+		return tx.Create(&renditions).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to replace renditions")
+		return helpers.ErrRecordCreationFailed
+	}
+	return nil
+}
+
+func (r *Rendition) GetByVideoID(ctx context.Context, videoID uuid.UUID) ([]*models.Rendition, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.rendition.GetByVideoID").Logger()
+
+	var renditions []*models.Rendition
+	db := r.storage.DB.WithContext(ctx).Where("video_id = ?", videoID.String()).
+		Order("height asc").Find(&renditions)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch renditions")
+		return nil, helpers.ErrEmptyResult
+	}
+	return renditions, nil
+}
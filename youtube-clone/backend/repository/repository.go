@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/rs/zerolog"
@@ -34,7 +35,7 @@ func New(z zerolog.Logger, env models.Env) *Store {
 		env.DBName,
 	)
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		z.Fatal().Err(err).Msgf("could not connect to the DB %+v", err.Error())
 		panic(err)
@@ -42,7 +43,7 @@ func New(z zerolog.Logger, env models.Env) *Store {
 
 	z.Debug().Msg("connected to the database")
 
-	err = db.AutoMigrate(&models.User{}, &models.Video{}) // Adjust the models as per your requirements
+	err = db.AutoMigrate(&models.User{}, &models.Video{}, &models.Upload{}, &models.Job{}, &models.Rendition{}, &models.Thumbnail{}, &models.Channel{}, &models.Subscription{}, &models.Comment{}, &models.Rating{}, &models.WatchHistory{}, &models.HistorySettings{}, &models.Playlist{}, &models.PlaylistItem{}, &models.PlaylistCollaborator{}, &models.SearchQuery{}, &models.SearchQuerySearcher{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.RevokedUserTokens{}, &models.Session{}) // Adjust the models as per your requirements
 	if err != nil {
 		z.Fatal().Err(err).Msg("unable to auto migrate models")
 		panic(err)
@@ -59,6 +60,12 @@ func (s *Store) Close() {
 	_ = sqlDB.Close()
 }
 
+// isDuplicateKey reports whether err is a unique key violation, the store translates the errors of
+// the database driver to gorm.ErrDuplicatedKey
+func isDuplicateKey(err error) bool {
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
 func (s *Store) getRequestID(ctx context.Context) string {
 	rID := ctx.Value("RequestIDContextKey")
 	if rID != nil {
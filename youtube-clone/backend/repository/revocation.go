@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type RevocationRepo interface {
+	RevokeToken(ctx context.Context, token models.RevokedToken) error
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore time.Time) error
+	GetRevokedTokens(ctx context.Context, now time.Time) ([]*models.RevokedToken, error)
+	GetRevokedUsers(ctx context.Context) ([]*models.RevokedUserTokens, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type Revocation struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewRevocation creates a new reference to the Revocation storage entity
+func NewRevocation(s *Store) RevocationRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "revocation").Logger()
+	revocation := &Revocation{
+		logger:  l,
+		storage: s,
+	}
+	revocationDatabase := RevocationRepo(revocation)
+	return revocationDatabase
+}
+
+// RevokeToken revokes a single access token, revoking it twice is not an error
+func (r *Revocation) RevokeToken(ctx context.Context, token models.RevokedToken) error {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.revocation.RevokeToken").Logger()
+
+	db := r.storage.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&token)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return helpers.ErrRecordCreationFailed
+	}
+	return nil
+}
+
+// RevokeUserTokens revokes every access token of the user issued before issuedBefore
+func (r *Revocation) RevokeUserTokens(ctx context.Context, userID uuid.UUID, issuedBefore time.Time) error {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.revocation.RevokeUserTokens").Logger()
+
+	revoked := models.RevokedUserTokens{UserID: userID, IssuedBefore: issuedBefore}
+	db := r.storage.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"issued_before", "updated_at"}),
+	}).Create(&revoked)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to revoke tokens of user")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// GetRevokedTokens lists the revoked access tokens that did not expire yet
+func (r *Revocation) GetRevokedTokens(ctx context.Context, now time.Time) ([]*models.RevokedToken, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.revocation.GetRevokedTokens").Logger()
+
+	var tokens []*models.RevokedToken
+	db := r.storage.DB.WithContext(ctx).Where("expires_at > ?", now).Find(&tokens)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch revoked tokens")
+		return nil, helpers.ErrEmptyResult
+	}
+	return tokens, nil
+}
+
+// GetRevokedUsers lists the users who signed out of all devices
+func (r *Revocation) GetRevokedUsers(ctx context.Context) ([]*models.RevokedUserTokens, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.revocation.GetRevokedUsers").Logger()
+
+	var users []*models.RevokedUserTokens
+	db := r.storage.DB.WithContext(ctx).Find(&users)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch revoked users")
+		return nil, helpers.ErrEmptyResult
+	}
+	return users, nil
+}
+
+// DeleteExpired drops the revoked tokens that expired on their own, returning the number of rows deleted
+func (r *Revocation) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	log := r.logger.With().Str(helpers.LogStrRequestIDLevel, r.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.revocation.DeleteExpired").Logger()
+
+	db := r.storage.DB.WithContext(ctx).Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to delete expired revoked tokens")
+		return 0, helpers.ErrDeleteFailed
+	}
+	return db.RowsAffected, nil
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type SearchQueryRepo interface {
+	Record(ctx context.Context, searchers map[string][]string, searchedAt time.Time) error
+	GetPopular(ctx context.Context, minSearchers int64, limit int) ([]*models.SearchQuery, error)
+}
+
+type SearchQuery struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewSearchQuery creates a new reference to the SearchQuery storage entity
+func NewSearchQuery(s *Store) SearchQueryRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "search_query").Logger()
+	searchQuery := &SearchQuery{
+		logger:  l,
+		storage: s,
+	}
+	searchQueryDatabase := SearchQueryRepo(searchQuery)
+	return searchQueryDatabase
+}
+
+// Record adds a batch of buffered searchers per query. The searcher count of a query only grows by
+// the searchers that never searched it before. Rows are written in query order so concurrent
+// batches cannot deadlock.
+func (s *SearchQuery) Record(ctx context.Context, searchers map[string][]string, searchedAt time.Time) error {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.search_query.Record").Logger()
+
+	queries := make([]string, 0, len(searchers))
+	for query := range searchers {
+		queries = append(queries, query)
+	}
+	sort.Strings(queries)
+
+	err := s.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, query := range queries {
+			rows := make([]models.SearchQuerySearcher, 0, len(searchers[query]))
+			for _, searcher := range searchers[query] {
+				rows = append(rows, models.SearchQuerySearcher{Query: query, Searcher: searcher, CreatedAt: searchedAt})
+			}
+			sort.Slice(rows, func(i, j int) bool {
+				return rows[i].Searcher < rows[j].Searcher
+			})
+			db := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+			if db.Error != nil {
+				return db.Error
+			}
+			if db.RowsAffected == 0 {
+				continue
+			}
+
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "query"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"searchers":        gorm.Expr("searchers + ?", db.RowsAffected),
+					"last_searched_at": searchedAt,
+				}),
+			}).Create(&models.SearchQuery{Query: query, Searchers: db.RowsAffected, LastSearchedAt: searchedAt}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to count search queries")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// GetPopular lists the queries searched by at least minSearchers searchers, most searched first
+func (s *SearchQuery) GetPopular(ctx context.Context, minSearchers int64, limit int) ([]*models.SearchQuery, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.search_query.GetPopular").Logger()
+
+	var queries []*models.SearchQuery
+	db := s.storage.DB.WithContext(ctx).Where("searchers >= ?", minSearchers).
+		Order("searchers desc").Limit(limit).Find(&queries)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch popular search queries")
+		return nil, helpers.ErrEmptyResult
+	}
+	return queries, nil
+}
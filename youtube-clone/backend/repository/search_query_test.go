@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestSearchQueryRecord(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, &models.SearchQuery{}, &models.SearchQuerySearcher{})
+	repo := NewSearchQuery(store)
+
+	tests := []struct {
+		name      string
+		searchers map[string][]string
+		want      map[string]int64
+	}{
+		{
+			name:      "new queries",
+			searchers: map[string][]string{"golang": {"a", "b"}, "rust": {"a"}},
+			want:      map[string]int64{"golang": 2, "rust": 1},
+		},
+		{
+			name:      "known searchers count once",
+			searchers: map[string][]string{"golang": {"a", "c"}, "rust": {"a"}},
+			want:      map[string]int64{"golang": 3, "rust": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := repo.Record(ctx, tt.searchers, time.Now()); err != nil {
+				t.Fatalf("Record() error = %v", err)
+			}
+			popular, err := repo.GetPopular(ctx, 1, 10)
+			if err != nil {
+				t.Fatalf("GetPopular() error = %v", err)
+			}
+			got := map[string]int64{}
+			for _, query := range popular {
+				got[query.Query] = query.Searchers
+			}
+			for query, want := range tt.want {
+				if got[query] != want {
+					t.Errorf("searchers of %q = %d, want %d", query, got[query], want)
+				}
+			}
+		})
+	}
+}
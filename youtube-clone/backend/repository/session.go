@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type SessionRepo interface {
+	Create(ctx context.Context, session models.Session) (*models.Session, error)
+	GetByID(ctx context.Context, sessionID uuid.UUID) (*models.Session, error)
+	GetActiveByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Session, error)
+	GetRevokedFamilies(ctx context.Context, since time.Time) ([]*models.Session, error)
+	Touch(ctx context.Context, familyID uuid.UUID, ip string, seenAt time.Time) error
+	Extend(ctx context.Context, familyID uuid.UUID, expiresAt time.Time) error
+	RevokeByFamilyID(ctx context.Context, familyID uuid.UUID) error
+	RevokeByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type Session struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewSession creates a new reference to the Session storage entity
+func NewSession(s *Store) SessionRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "session").Logger()
+	session := &Session{
+		logger:  l,
+		storage: s,
+	}
+	sessionDatabase := SessionRepo(session)
+	return sessionDatabase
+}
+
+func (s *Session) Create(ctx context.Context, session models.Session) (*models.Session, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.Create").Logger()
+
+	db := s.storage.DB.WithContext(ctx).Create(&session)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &session, nil
+}
+
+func (s *Session) GetByID(ctx context.Context, sessionID uuid.UUID) (*models.Session, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.GetByID").Logger()
+
+	var session models.Session
+	db := s.storage.DB.WithContext(ctx).Where("id = ?", sessionID.String()).Find(&session)
+	if db.Error != nil || strings.EqualFold(session.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("session not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &session, nil
+}
+
+// GetActiveByUserID lists the sessions of a user that are neither revoked nor expired, most recently seen first
+func (s *Session) GetActiveByUserID(ctx context.Context, userID uuid.UUID, now time.Time) ([]*models.Session, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.GetActiveByUserID").Logger()
+
+	var sessions []*models.Session
+	db := s.storage.DB.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID.String(), now).
+		Order("last_seen_at desc").Find(&sessions)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch sessions")
+		return nil, helpers.ErrEmptyResult
+	}
+	return sessions, nil
+}
+
+// GetRevokedFamilies lists the sessions revoked since the given time
+func (s *Session) GetRevokedFamilies(ctx context.Context, since time.Time) ([]*models.Session, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.GetRevokedFamilies").Logger()
+
+	var sessions []*models.Session
+	db := s.storage.DB.WithContext(ctx).Select("id", "family_id", "revoked_at").
+		Where("revoked_at > ?", since).Find(&sessions)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch revoked sessions")
+		return nil, helpers.ErrEmptyResult
+	}
+	return sessions, nil
+}
+
+// Touch records that the session of the refresh token family was used from ip
+func (s *Session) Touch(ctx context.Context, familyID uuid.UUID, ip string, seenAt time.Time) error {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.Touch").Logger()
+
+	db := s.storage.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID.String()).
+		Updates(map[string]interface{}{"last_seen_at": seenAt, "ip": ip})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update last seen")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// Extend moves the expiry of the session forward when its refresh token is rotated
+func (s *Session) Extend(ctx context.Context, familyID uuid.UUID, expiresAt time.Time) error {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.Extend").Logger()
+
+	db := s.storage.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID.String()).
+		Update("expires_at", expiresAt)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to extend session")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+func (s *Session) RevokeByFamilyID(ctx context.Context, familyID uuid.UUID) error {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.RevokeByFamilyID").Logger()
+
+	db := s.storage.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID.String()).
+		Update("revoked_at", time.Now())
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to revoke session")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+func (s *Session) RevokeByUserID(ctx context.Context, userID uuid.UUID) error {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.RevokeByUserID").Logger()
+
+	db := s.storage.DB.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID.String()).
+		Update("revoked_at", time.Now())
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to revoke sessions of user")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// DeleteExpired drops the sessions that expired before the given time, returning the number of rows deleted
+func (s *Session) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.session.DeleteExpired").Logger()
+
+	db := s.storage.DB.WithContext(ctx).Where("expires_at <= ?", before).Delete(&models.Session{})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to delete expired sessions")
+		return 0, helpers.ErrDeleteFailed
+	}
+	return db.RowsAffected, nil
+}
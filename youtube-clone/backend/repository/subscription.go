@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type SubscriptionRepo interface {
+	Subscribe(ctx context.Context, subscription models.Subscription) (*models.Subscription, error)
+	Unsubscribe(ctx context.Context, userID, channelID uuid.UUID) error
+	Get(ctx context.Context, userID, channelID uuid.UUID) (*models.Subscription, error)
+}
+
+type Subscription struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewSubscription creates a new reference to the Subscription storage entity
+func NewSubscription(s *Store) SubscriptionRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "subscription").Logger()
+	subscription := &Subscription{
+		logger:  l,
+		storage: s,
+	}
+	subscriptionDatabase := SubscriptionRepo(subscription)
+	return subscriptionDatabase
+}
+
+// Subscribe creates the subscription, or updates the notification preference of an existing one.
+// The subscriber count of the channel only changes for new subscriptions.
+func (s *Subscription) Subscribe(ctx context.Context, subscription models.Subscription) (*models.Subscription, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.subscription.Subscribe").Logger()
+
+	err := s.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// a single insert that skips existing rows, a locking read of a missing row takes a gap lock
+		// that deadlocks concurrent subscribers
+		db := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&subscription)
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 1 {
+			return tx.Model(&models.Channel{}).Where("id = ?", subscription.ChannelID.String()).
+				Update("subscriber_count", gorm.Expr("subscriber_count + 1")).Error
+		}
+
+		var existing models.Subscription
+		err := tx.Model(&existing).
+			Where("user_id = ? AND channel_id = ?", subscription.UserID.String(), subscription.ChannelID.String()).
+			Updates(map[string]interface{}{
+				"notifications": subscription.Notifications,
+				"updated_at":    time.Now(),
+			}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Where("user_id = ? AND channel_id = ?", subscription.UserID.String(), subscription.ChannelID.String()).
+			First(&existing).Error
+		if err != nil {
+			return err
+		}
+		subscription = existing
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to subscribe")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+	return &subscription, nil
+}
+
+func (s *Subscription) Unsubscribe(ctx context.Context, userID, channelID uuid.UUID) error {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.subscription.Unsubscribe").Logger()
+
+	err := s.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		db := tx.Where("user_id = ? AND channel_id = ?", userID.String(), channelID.String()).
+			Delete(&models.Subscription{})
+		if db.Error != nil || db.RowsAffected == 0 {
+			return db.Error
+		}
+		return tx.Model(&models.Channel{}).Where("id = ? AND subscriber_count > 0", channelID.String()).
+			Update("subscriber_count", gorm.Expr("subscriber_count - 1")).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to unsubscribe")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
+
+func (s *Subscription) Get(ctx context.Context, userID, channelID uuid.UUID) (*models.Subscription, error) {
+	log := s.logger.With().Str(helpers.LogStrRequestIDLevel, s.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.subscription.Get").Logger()
+
+	var subscription models.Subscription
+	db := s.storage.DB.WithContext(ctx).
+		Where("user_id = ? AND channel_id = ?", userID.String(), channelID.String()).Find(&subscription)
+	if db.Error != nil || strings.EqualFold(subscription.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &subscription, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestSubscriptionSubscribe(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t, &models.Channel{}, &models.Subscription{})
+	repo := NewSubscription(store)
+
+	channel := models.Channel{ID: uuid.New(), UserID: uuid.New(), Handle: "channel", DisplayName: "Channel"}
+	if err := store.DB.Create(&channel).Error; err != nil {
+		t.Fatalf("create channel: %v", err)
+	}
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		notifications string
+		wantCount     int64
+	}{
+		{name: "new subscription", notifications: models.SubscriptionNotifyAll, wantCount: 1},
+		{name: "existing subscription changes notifications", notifications: models.SubscriptionNotifyNone, wantCount: 1},
+	}
+	var firstID uuid.UUID
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Subscribe(ctx, models.Subscription{
+				ID:            uuid.New(),
+				UserID:        userID,
+				ChannelID:     channel.ID,
+				Notifications: tt.notifications,
+			})
+			if err != nil {
+				t.Fatalf("Subscribe() error = %v", err)
+			}
+			if firstID == uuid.Nil {
+				firstID = got.ID
+			}
+			if got.ID != firstID || got.Notifications != tt.notifications {
+				t.Errorf("Subscribe() = %s %q, want %s %q", got.ID, got.Notifications, firstID, tt.notifications)
+			}
+
+			var stored models.Channel
+			if err := store.DB.First(&stored, "id = ?", channel.ID.String()).Error; err != nil {
+				t.Fatalf("get channel: %v", err)
+			}
+			if stored.SubscriberCount != tt.wantCount {
+				t.Errorf("subscriber count = %d, want %d", stored.SubscriberCount, tt.wantCount)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type ThumbnailRepo interface {
+	Create(ctx context.Context, thumbnail models.Thumbnail) (*models.Thumbnail, error)
+	ReplaceCandidates(ctx context.Context, videoID uuid.UUID, thumbnails []models.Thumbnail) error
+	GetByID(ctx context.Context, ID uuid.UUID) (*models.Thumbnail, error)
+	GetByVideoID(ctx context.Context, videoID uuid.UUID) ([]*models.Thumbnail, error)
+}
+
+type Thumbnail struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewThumbnail creates a new reference to the Thumbnail storage entity
+func NewThumbnail(s *Store) ThumbnailRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "thumbnail").Logger()
+	thumbnail := &Thumbnail{
+		logger:  l,
+		storage: s,
+	}
+	thumbnailDatabase := ThumbnailRepo(thumbnail)
+	return thumbnailDatabase
+}
+
+func (t *Thumbnail) Create(ctx context.Context, thumbnail models.Thumbnail) (*models.Thumbnail, error) {
+	log := t.logger.With().Str(helpers.LogStrRequestIDLevel, t.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.thumbnail.Create").Logger()
+
+	db := t.storage.DB.WithContext(ctx).Model(&models.Thumbnail{}).Create(&thumbnail)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+
+	return &thumbnail, nil
+}
+
+// ReplaceCandidates swaps the extracted thumbnails of a video, custom uploads are kept
+func (t *Thumbnail) ReplaceCandidates(ctx context.Context, videoID uuid.UUID, thumbnails []models.Thumbnail) error {
+	log := t.logger.With().Str(helpers.LogStrRequestIDLevel, t.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.thumbnail.ReplaceCandidates").Logger()
+
+	err := t.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("video_id = ? AND custom = ?", videoID.String(), false).Delete(&models.Thumbnail{}).Error; err != nil {
+			return err
+		}
+		if len(thumbnails) == 0 {
+			return nil
+		}
+		return tx.Create(&thumbnails).Error
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to replace thumbnails")
+		return helpers.ErrRecordCreationFailed
+	}
+	return nil
+}
+
+func (t *Thumbnail) GetByID(ctx context.Context, ID uuid.UUID) (*models.Thumbnail, error) {
+	log := t.logger.With().Str(helpers.LogStrRequestIDLevel, t.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.thumbnail.GetByID").Logger()
+
+	var thumbnail models.Thumbnail
+	db := t.storage.DB.WithContext(ctx).Where("id = ?", ID.String()).Find(&thumbnail)
+	if db.Error != nil || strings.EqualFold(thumbnail.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &thumbnail, nil
+}
+
+func (t *Thumbnail) GetByVideoID(ctx context.Context, videoID uuid.UUID) ([]*models.Thumbnail, error) {
+	log := t.logger.With().Str(helpers.LogStrRequestIDLevel, t.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.thumbnail.GetByVideoID").Logger()
+
+	var thumbnails []*models.Thumbnail
+	db := t.storage.DB.WithContext(ctx).Where("video_id = ?", videoID.String()).
+		Order("custom desc, position asc").Find(&thumbnails)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch thumbnails")
+		return nil, helpers.ErrEmptyResult
+	}
+	return thumbnails, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+type UploadRepo interface {
+	Create(ctx context.Context, upload models.Upload) (*models.Upload, error)
+	GetByID(ctx context.Context, ID uuid.UUID) (*models.Upload, error)
+	UpdateOffset(ctx context.Context, ID uuid.UUID, fromOffset, toOffset int64, parts int) error
+	Complete(ctx context.Context, ID uuid.UUID, videoID uuid.UUID) error
+	GetExpired(ctx context.Context, before time.Time, limit int) ([]*models.Upload, error)
+	DeleteByID(ctx context.Context, ID uuid.UUID) error
+}
+
+type Upload struct {
+	logger  zerolog.Logger
+	storage *Store
+}
+
+// NewUpload creates a new reference to the Upload storage entity
+func NewUpload(s *Store) UploadRepo {
+	l := s.logger.With().Str("LEVEL_NAME", "upload").Logger()
+	upload := &Upload{
+		logger:  l,
+		storage: s,
+	}
+	uploadDatabase := UploadRepo(upload)
+	return uploadDatabase
+}
+
+func (u *Upload) Create(ctx context.Context, upload models.Upload) (*models.Upload, error) {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.upload.Create").Logger()
+
+	db := u.storage.DB.WithContext(ctx).Model(&models.Upload{}).Create(&upload)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to insert new row")
+		return nil, helpers.ErrRecordCreationFailed
+	}
+
+	return &upload, nil
+}
+
+func (u *Upload) GetByID(ctx context.Context, ID uuid.UUID) (*models.Upload, error) {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.upload.GetByID").Logger()
+
+	var upload models.Upload
+	db := u.storage.DB.WithContext(ctx).Where("id = ?", ID.String()).Find(&upload)
+	if db.Error != nil || strings.EqualFold(upload.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &upload, nil
+}
+
+// UpdateOffset moves the upload offset forward, but only if nobody else moved it since fromOffset was read
+func (u *Upload) UpdateOffset(ctx context.Context, ID uuid.UUID, fromOffset, toOffset int64, parts int) error {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.upload.UpdateOffset").Logger()
+
+	db := u.storage.DB.WithContext(ctx).Model(&models.Upload{}).
+		Where("id = ? AND upload_offset = ?", ID.String(), fromOffset).
+		Updates(map[string]interface{}{
+			"upload_offset": toOffset,
+			"parts":         parts,
+			"updated_at":    time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update upload offset")
+		return helpers.ErrRecordUpdateFail
+	}
+	if db.RowsAffected == 0 {
+		return helpers.ErrUploadOffsetMismatch
+	}
+	return nil
+}
+
+func (u *Upload) Complete(ctx context.Context, ID uuid.UUID, videoID uuid.UUID) error {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.upload.Complete").Logger()
+
+	db := u.storage.DB.WithContext(ctx).Model(&models.Upload{}).Where("id = ?", ID.String()).
+		Updates(map[string]interface{}{
+			"video_id":   videoID.String(),
+			"parts":      0,
+			"updated_at": time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to complete upload")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+func (u *Upload) GetExpired(ctx context.Context, before time.Time, limit int) ([]*models.Upload, error) {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.upload.GetExpired").Logger()
+
+	var uploads []*models.Upload
+	db := u.storage.DB.WithContext(ctx).Where("expires_at < ?", before).
+		Order("expires_at asc").Limit(limit).Find(&uploads)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch expired uploads")
+		return nil, helpers.ErrEmptyResult
+	}
+	return uploads, nil
+}
+
+func (u *Upload) DeleteByID(ctx context.Context, ID uuid.UUID) error {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.upload.DeleteByID").Logger()
+
+	db := u.storage.DB.WithContext(ctx).Where("id = ?", ID.String()).Delete(&models.Upload{})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("delete failed")
+		return helpers.ErrDeleteFailed
+	}
+	return nil
+}
@@ -5,11 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
-	"gorm.io/gorm"
 
 	"github.com/joshua468/youtube-clone/backend/utils/helpers"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
@@ -21,6 +19,7 @@ type UserRepo interface {
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 	GetAllUsers(ctx context.Context, query models.User, page helpers.Page) ([]*models.User, helpers.PageInfo, error)
+	SearchUsers(ctx context.Context, query string, page helpers.Page) ([]*models.User, helpers.PageInfo, error)
 	CountUsers(ctx context.Context) (int64, error)
 }
 
@@ -30,14 +29,13 @@ type User struct {
 }
 
 // NewUser creates a new reference to the User storage entity
-func NewUser(s *Store) *User {
+func NewUser(s *Store) UserRepo {
 	l := s.logger.With().Str("LEVEL_NAME", "user").Logger()
 	user := &User{
 		logger:  l,
 		storage: s,
 	}
-	userDatabase := UserRepo(user)
-	return &userDatabase
+	return user
 }
 
 func (u *User) CountUsers(ctx context.Context) (int64, error) {
@@ -122,6 +120,22 @@ func (u *User) GetAllUsers(ctx context.Context, query models.User, page helpers.
 	}, nil
 }
 
+// SearchUsers lists the users whose username starts with query
+func (u *User) SearchUsers(ctx context.Context, query string, page helpers.Page) ([]*models.User, helpers.PageInfo, error) {
+	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.user.SearchUsers").Logger()
+
+	// the wildcards of LIKE match literally in the query
+	prefix := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+	queryDraft := u.storage.DB.WithContext(ctx).Model(models.User{}).Where("username LIKE ?", prefix)
+	users, pageInfo, err := paginate[models.User](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not search users")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return users, pageInfo, nil
+}
+
 func (u *User) CreateUser(ctx context.Context, user models.User) (*models.User, error) {
 	log := u.logger.With().Str(helpers.LogStrRequestIDLevel, u.storage.getRequestID(ctx)).
 		Str(helpers.LogStrKeyMethod, "repository.user.Create").Logger()
@@ -129,7 +143,7 @@ func (u *User) CreateUser(ctx context.Context, user models.User) (*models.User,
 	db := u.storage.DB.WithContext(ctx).Model(&models.User{}).Create(&user)
 	if db.Error != nil {
 		log.Err(db.Error).Msg("unable to insert new row")
-		if strings.Contains(db.Error.Error(), "duplicate key value") {
+		if isDuplicateKey(db.Error) {
 			return nil, errors.New("duplicate record error")
 		}
 		return nil, helpers.ErrRecordCreationFailed
@@ -3,7 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
-	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,17 +13,25 @@ import (
 
 	"github.com/joshua468/youtube-clone/backend/utils/helpers"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
-	"github.com/joshua468/youtube-clone/backend/utils/helpers"
-	"github.com/joshua468/youtube-clone/backend/repository/models" 
 )
 
 type VideoRepo interface {
 	Create(ctx context.Context, v models.Video) (*models.Video, error)
-	GetAllVideos(ctx context.Context, query models.Video, p helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	GetAllVideos(ctx context.Context, query models.Video, viewerID uuid.UUID, p helpers.Page) ([]*models.Video, helpers.PageInfo, error)
+	GetSubscriptionFeed(ctx context.Context, userID uuid.UUID, p helpers.Page) ([]*models.Video, helpers.PageInfo, error)
 	SoftDeleteByID(ctx context.Context, ID uuid.UUID) error
 	GetByID(ctx context.Context, ID uuid.UUID) (*models.Video, error)
+	GetByIDs(ctx context.Context, IDs []uuid.UUID) ([]*models.Video, error)
+	GetAfterID(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.Video, error)
+	GetVisibleByID(ctx context.Context, ID uuid.UUID, viewerID uuid.UUID) (*models.Video, error)
 	UpdateVideo(ctx context.Context, video models.Video) (*models.Video, error)
 	CountVideos(ctx context.Context) (int64, error)
+	UpdateStatus(ctx context.Context, ID uuid.UUID, status string) error
+	UpdateThumbnail(ctx context.Context, ID uuid.UUID, thumbnailID uuid.UUID) error
+	UpdateVisibility(ctx context.Context, ID uuid.UUID, visibility string, publishAt *time.Time) error
+	PublishScheduled(ctx context.Context, now time.Time) (int64, error)
+	UpdateCommentsDisabled(ctx context.Context, ID uuid.UUID, disabled bool) error
+	IncrementViews(ctx context.Context, counts map[uuid.UUID]int64) error
 }
 
 type Video struct {
@@ -32,14 +40,13 @@ type Video struct {
 }
 
 // NewVideo creates a new reference to the Video storage entity
-func NewVideo(s *Store) *Video {
+func NewVideo(s *Store) VideoRepo {
 	l := s.logger.With().Str("LEVEL_NAME", "video").Logger()
 	video := &Video{
 		logger:  l,
 		storage: s,
 	}
-	videoDatabase := VideoRepo(video)
-	return &videoDatabase
+	return video
 }
 
 func (v *Video) CountVideos(ctx context.Context) (int64, error) {
@@ -64,6 +71,7 @@ func (v *Video) UpdateVideo(ctx context.Context, video models.Video) (*models.Vi
 	}).Updates(models.Video{
 		Title:       video.Title,
 		Description: video.Description,
+		Tags:        video.Tags,
 		UpdatedAt:   video.UpdatedAt,
 	})
 	if db.Error != nil {
@@ -86,60 +94,93 @@ func (v *Video) GetByID(ctx context.Context, ID uuid.UUID) (*models.Video, error
 	return &video, nil
 }
 
-func (v *Video) GetAllVideos(ctx context.Context, query models.Video, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+// GetByIDs returns the videos with the given IDs, in no particular order. Unknown IDs are skipped.
+func (v *Video) GetByIDs(ctx context.Context, IDs []uuid.UUID) ([]*models.Video, error) {
 	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
-		Str(helpers.LogStrKeyMethod, "repository.video.GetAllVideos").Logger()
+		Str(helpers.LogStrKeyMethod, "repository.video.GetByIDs").Logger()
 
-	var videos []*models.Video
-	offset := 0
-	// load defaults
-	if page.Number == nil {
-		tmpPageNumber := helpers.PageDefaultNumber
-		page.Number = &tmpPageNumber
-	}
-	if page.Size == nil {
-		tmpPageSize := helpers.PageDefaultSize
-		page.Size = &tmpPageSize
-	}
-	if page.SortBy == nil {
-		tmpPageSortBy := helpers.PageDefaultSortBy
-		page.SortBy = &tmpPageSortBy
-	}
-	if page.SortDirectionDesc == nil {
-		tmpPageSortDirectionDesc := helpers.PageDefaultSortDirectionDesc
-		page.SortDirectionDesc = &tmpPageSortDirectionDesc
+	keys := make([]string, 0, len(IDs))
+	for _, ID := range IDs {
+		keys = append(keys, ID.String())
 	}
 
-	if *page.Number > 1 {
-		offset = *page.Size * (*page.Number - 1)
+	var videos []*models.Video
+	db := v.storage.DB.WithContext(ctx).Where("id IN ?", keys).Find(&videos)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch videos")
+		return nil, helpers.ErrEmptyResult
 	}
-	sortDirection := helpers.PageSortDirectionDescending
-	if !*page.SortDirectionDesc {
-		sortDirection = helpers.PageSortDirectionAscending
+	return videos, nil
+}
+
+// GetAfterID walks over every video that is not deleted in ID order, limit videos at a time
+func (v *Video) GetAfterID(ctx context.Context, afterID uuid.UUID, limit int) ([]*models.Video, error) {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.GetAfterID").Logger()
+
+	var videos []*models.Video
+	db := v.storage.DB.WithContext(ctx).Where("id > ? AND deleted_at IS NULL", afterID.String()).
+		Order("id asc").Limit(limit).Find(&videos)
+	if db.Error != nil {
+		log.Err(db.Error).Msg("could not fetch videos")
+		return nil, helpers.ErrEmptyResult
 	}
+	return videos, nil
+}
 
-	queryDraft := v.storage.DB.WithContext(ctx).Model(models.Video{}).Where(query)
+// GetVisibleByID returns the video only when viewerID may see it, see models.Video.IsVisibleTo.
+// Background processing that has to see every video uses GetByID instead.
+func (v *Video) GetVisibleByID(ctx context.Context, ID uuid.UUID, viewerID uuid.UUID) (*models.Video, error) {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.GetVisibleByID").Logger()
 
-	// then do counting
-	var count int64
-	queryDraft.Count(&count)
+	var video models.Video
+	db := v.storage.DB.WithContext(ctx).Where("id = ?", ID.String()).
+		Where(v.storage.DB.Where("visibility = ?", models.VideoVisibilityUnlisted).
+			Or(publicVideos(v.storage.DB, time.Now())).
+			Or(ownVideos(v.storage.DB, viewerID))).
+		Find(&video)
+	if db.Error != nil || strings.EqualFold(video.ID.String(), helpers.ZeroUUID) {
+		log.Err(db.Error).Msg("record not found")
+		return nil, helpers.ErrRecordNotFound
+	}
+	return &video, nil
+}
 
-	db := queryDraft.Offset(offset).Limit(*page.Size).
-		Order(fmt.Sprintf("%s %s", *page.SortBy, sortDirection)).
-		Find(&videos)
+// GetAllVideos lists the videos matching query that are public, or owned by viewerID
+func (v *Video) GetAllVideos(ctx context.Context, query models.Video, viewerID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.GetAllVideos").Logger()
 
-	if db.Error != nil {
-		log.Err(db.Error).Msg("could not fetch list of videos")
+	// unlisted and private videos only show up in the listings of their owner
+	queryDraft := v.storage.DB.WithContext(ctx).Model(models.Video{}).Where(query).
+		Where(publicVideos(v.storage.DB, time.Now()).Or(ownVideos(v.storage.DB, viewerID)))
+
+	videos, pageInfo, err := paginate[models.Video](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch list of videos")
 		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
 	}
+	return videos, pageInfo, nil
+}
+
+// GetSubscriptionFeed lists the ready public videos of the channels userID subscribed to
+func (v *Video) GetSubscriptionFeed(ctx context.Context, userID uuid.UUID, page helpers.Page) ([]*models.Video, helpers.PageInfo, error) {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.GetSubscriptionFeed").Logger()
+
+	subscribed := v.storage.DB.Model(&models.Subscription{}).Select("channel_id").Where("user_id = ?", userID.String())
+	queryDraft := v.storage.DB.WithContext(ctx).Model(models.Video{}).
+		Where("channel_id IN (?)", subscribed).
+		Where("status = ?", models.VideoStatusReady).
+		Where(publicVideos(v.storage.DB, time.Now()))
 
-	return videos, helpers.PageInfo{
-		Page:            *page.Number,
-		Size:            *page.Size,
-		HasNextPage:     int64(offset+*page.Size) < count,
-		HasPreviousPage: *page.Number > 1,
-		TotalCount:      count,
-	}, nil
+	videos, pageInfo, err := paginate[models.Video](queryDraft, page)
+	if err != nil {
+		log.Err(err).Msg("could not fetch subscription feed")
+		return nil, helpers.PageInfo{}, helpers.ErrEmptyResult
+	}
+	return videos, pageInfo, nil
 }
 
 func (v *Video) Create(ctx context.Context, video models.Video) (*models.Video, error) {
@@ -149,7 +190,7 @@ func (v *Video) Create(ctx context.Context, video models.Video) (*models.Video,
 	db := v.storage.DB.WithContext(ctx).Model(&models.Video{}).Create(&video)
 	if db.Error != nil {
 		log.Err(db.Error).Msg("unable to insert new row")
-		if strings.Contains(db.Error.Error(), "duplicate key value") {
+		if isDuplicateKey(db.Error) {
 			return nil, errors.New("duplicate record error")
 		}
 		return nil, helpers.ErrRecordCreationFailed
@@ -176,3 +217,130 @@ func (v *Video) SoftDeleteByID(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+func (v *Video) UpdateStatus(ctx context.Context, ID uuid.UUID, status string) error {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.UpdateStatus").Logger()
+
+	db := v.storage.DB.WithContext(ctx).Model(&models.Video{}).Where("id = ?", ID.String()).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update video status")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+func (v *Video) UpdateThumbnail(ctx context.Context, ID uuid.UUID, thumbnailID uuid.UUID) error {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.UpdateThumbnail").Logger()
+
+	db := v.storage.DB.WithContext(ctx).Model(&models.Video{}).Where("id = ?", ID.String()).
+		Updates(map[string]interface{}{
+			"thumbnail_id": thumbnailID.String(),
+			"updated_at":   time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update video thumbnail")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+func (v *Video) UpdateVisibility(ctx context.Context, ID uuid.UUID, visibility string, publishAt *time.Time) error {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.UpdateVisibility").Logger()
+
+	db := v.storage.DB.WithContext(ctx).Model(&models.Video{}).Where("id = ?", ID.String()).
+		Updates(map[string]interface{}{
+			"visibility": visibility,
+			"publish_at": publishAt,
+			"updated_at": time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update video visibility")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// PublishScheduled makes every scheduled video whose publish time has passed public
+func (v *Video) PublishScheduled(ctx context.Context, now time.Time) (int64, error) {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.PublishScheduled").Logger()
+
+	db := v.storage.DB.WithContext(ctx).Model(&models.Video{}).
+		Where("visibility = ? AND publish_at <= ?", models.VideoVisibilityScheduled, now).
+		Updates(map[string]interface{}{
+			"visibility": models.VideoVisibilityPublic,
+			"updated_at": now,
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to publish scheduled videos")
+		return 0, helpers.ErrRecordUpdateFail
+	}
+	return db.RowsAffected, nil
+}
+
+func (v *Video) UpdateCommentsDisabled(ctx context.Context, ID uuid.UUID, disabled bool) error {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.UpdateCommentsDisabled").Logger()
+
+	db := v.storage.DB.WithContext(ctx).Model(&models.Video{}).Where("id = ?", ID.String()).
+		Updates(map[string]interface{}{
+			"comments_disabled": disabled,
+			"updated_at":        time.Now(),
+		})
+	if db.Error != nil {
+		log.Err(db.Error).Msg("unable to update video comment settings")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// IncrementViews adds a batch of buffered views to the view counters in a single transaction.
+// Rows are updated in ID order so concurrent batches cannot deadlock.
+func (v *Video) IncrementViews(ctx context.Context, counts map[uuid.UUID]int64) error {
+	log := v.logger.With().Str(helpers.LogStrRequestIDLevel, v.storage.getRequestID(ctx)).
+		Str(helpers.LogStrKeyMethod, "repository.video.IncrementViews").Logger()
+
+	IDs := make([]uuid.UUID, 0, len(counts))
+	for ID := range counts {
+		IDs = append(IDs, ID)
+	}
+	sort.Slice(IDs, func(i, j int) bool { return IDs[i].String() < IDs[j].String() })
+
+	err := v.storage.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, ID := range IDs {
+			// UpdateColumn leaves updated_at alone, a view does not modify the video
+			err := tx.Model(&models.Video{}).Where("id = ?", ID.String()).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", counts[ID])).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Err(err).Msg("unable to increment view counts")
+		return helpers.ErrRecordUpdateFail
+	}
+	return nil
+}
+
+// publicVideos matches public videos, including scheduled ones the scheduler has not flipped yet
+func publicVideos(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("visibility = ?", models.VideoVisibilityPublic).
+		Or("visibility = ? AND publish_at <= ?", models.VideoVisibilityScheduled, now)
+}
+
+// ownVideos matches the videos of viewerID, nothing for an anonymous viewer
+func ownVideos(db *gorm.DB, viewerID uuid.UUID) *gorm.DB {
+	if viewerID == uuid.Nil {
+		return db.Where("1 = 0")
+	}
+	return db.Where("user_id = ?", viewerID.String())
+}
@@ -0,0 +1,301 @@
+package search
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	titleBoost       = 3
+	tagBoost         = 2
+	descriptionBoost = 1
+
+	// viewsWeight scales the boost a video gets per order of magnitude of views
+	viewsWeight = 0.1
+	// recencyWeight is the boost of a video published right now, fading over recencyDecay
+	recencyWeight = 0.5
+	recencyDecay  = 30 * 24 * time.Hour
+)
+
+// document is the indexed form of a video, field names follow the json tags
+type document struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Tags        []string   `json:"tags"`
+	UserID      string     `json:"user_id"`
+	ChannelID   string     `json:"channel_id"`
+	Visibility  string     `json:"visibility"`
+	Status      string     `json:"status"`
+	PublishAt   *time.Time `json:"publish_at,omitempty"`
+	PublishedAt time.Time  `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Duration    float64    `json:"duration"`
+	ViewCount   int64      `json:"view_count"`
+}
+
+// Bleve keeps the index in an embedded Bleve index on the local filesystem
+type Bleve struct {
+	index bleve.Index
+}
+
+// NewBleve opens the Bleve index at path, creating an empty one when it does not exist yet
+func NewBleve(path string) (*Bleve, error) {
+	index, err := bleve.Open(path)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		index, err = bleve.New(path, newIndexMapping())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Bleve{index: index}, nil
+}
+
+func newIndexMapping() mapping.IndexMapping {
+	text := bleve.NewTextFieldMapping()
+	text.Analyzer = en.AnalyzerName
+	keyword := bleve.NewKeywordFieldMapping()
+	numeric := bleve.NewNumericFieldMapping()
+	date := bleve.NewDateTimeFieldMapping()
+
+	video := bleve.NewDocumentMapping()
+	video.Dynamic = false
+	video.AddFieldMappingsAt("title", text)
+	video.AddFieldMappingsAt("description", text)
+	video.AddFieldMappingsAt("tags", keyword)
+	video.AddFieldMappingsAt("user_id", keyword)
+	video.AddFieldMappingsAt("channel_id", keyword)
+	video.AddFieldMappingsAt("visibility", keyword)
+	video.AddFieldMappingsAt("status", keyword)
+	video.AddFieldMappingsAt("publish_at", date)
+	video.AddFieldMappingsAt("published_at", date)
+	video.AddFieldMappingsAt("created_at", date)
+	video.AddFieldMappingsAt("duration", numeric)
+	video.AddFieldMappingsAt("view_count", numeric)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = video
+	return indexMapping
+}
+
+func (b *Bleve) Index(ctx context.Context, videos ...*models.Video) error {
+	batch := b.index.NewBatch()
+	for _, video := range videos {
+		// soft deleted videos must not be found anymore
+		if video.DeletedAt != nil && video.DeletedAt.Valid {
+			batch.Delete(video.ID.String())
+			continue
+		}
+		if err := batch.Index(video.ID.String(), newDocument(video)); err != nil {
+			return err
+		}
+	}
+	return b.index.Batch(batch)
+}
+
+func newDocument(video *models.Video) document {
+	doc := document{
+		Title:       video.Title,
+		Description: video.Description,
+		Tags:        make([]string, 0, len(video.Tags)),
+		UserID:      video.UserID.String(),
+		Visibility:  video.Visibility,
+		Status:      video.Status,
+		CreatedAt:   video.CreatedAt,
+		Duration:    video.Duration,
+		ViewCount:   video.ViewCount,
+		PublishAt:   video.PublishAt,
+		PublishedAt: video.CreatedAt,
+	}
+	// public videos record when they were published, the ones public from the upload on do not
+	if video.PublishAt != nil {
+		doc.PublishedAt = *video.PublishAt
+	}
+	for _, tag := range video.Tags {
+		doc.Tags = append(doc.Tags, normalizeTag(tag))
+	}
+	if video.ChannelID != nil {
+		doc.ChannelID = video.ChannelID.String()
+	}
+	return doc
+}
+
+func (b *Bleve) Delete(ctx context.Context, IDs ...uuid.UUID) error {
+	batch := b.index.NewBatch()
+	for _, ID := range IDs {
+		batch.Delete(ID.String())
+	}
+	return b.index.Batch(batch)
+}
+
+// Search ranks the best text matches by their relevance boosted by views and recency. Bleve can
+// only sort by its own score, so the candidates are re-ranked here and highlighted once paged.
+func (b *Bleve) Search(ctx context.Context, q Query) (*Result, error) {
+	text := strings.TrimSpace(q.Text)
+	if text == "" || q.Offset < 0 || q.Limit < 0 {
+		return nil, ErrInvalidQuery
+	}
+	now := time.Now()
+	searchQuery := bleve.NewConjunctionQuery(textQuery(text), listedQuery(now))
+	for _, filter := range filterQueries(q) {
+		searchQuery.AddQuery(filter)
+	}
+
+	candidates := bleve.NewSearchRequestOptions(searchQuery, CandidateLimit, 0, false)
+	candidates.Fields = []string{"view_count", "published_at", "created_at"}
+	found, err := b.index.SearchInContext(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := make([]Hit, 0, len(found.Hits))
+	for _, match := range found.Hits {
+		ID, err := uuid.Parse(match.ID)
+		if err != nil {
+			continue
+		}
+		hits = append(hits, Hit{ID: ID, Score: boostedScore(match.Score, match.Fields, now)})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+
+	result := &Result{Total: int64(min(found.Total, CandidateLimit))}
+	if q.Offset >= len(hits) {
+		return result, nil
+	}
+	result.Hits = hits[q.Offset:min(q.Offset+q.Limit, len(hits))]
+
+	if err := b.highlight(ctx, searchQuery, result.Hits); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// highlight fills the highlights of hits, searching again restricted to their IDs
+func (b *Bleve) highlight(ctx context.Context, searchQuery query.Query, hits []Hit) error {
+	IDs := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		IDs = append(IDs, hit.ID.String())
+	}
+
+	request := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(searchQuery, bleve.NewDocIDQuery(IDs)), len(IDs), 0, false)
+	request.Highlight = bleve.NewHighlight()
+	request.Highlight.AddField("title")
+	request.Highlight.AddField("description")
+	found, err := b.index.SearchInContext(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	fragments := make(map[string]map[string][]string, len(found.Hits))
+	for _, match := range found.Hits {
+		fragments[match.ID] = match.Fragments
+	}
+	for i := range hits {
+		hits[i].Highlights = fragments[hits[i].ID.String()]
+	}
+	return nil
+}
+
+func (b *Bleve) Count() (uint64, error) {
+	return b.index.DocCount()
+}
+
+func (b *Bleve) Close() error {
+	return b.index.Close()
+}
+
+// textQuery matches the text against the title, the description and, as a whole, the tags
+func textQuery(text string) query.Query {
+	title := bleve.NewMatchQuery(text)
+	title.SetField("title")
+	title.SetBoost(titleBoost)
+	description := bleve.NewMatchQuery(text)
+	description.SetField("description")
+	description.SetBoost(descriptionBoost)
+	tag := bleve.NewTermQuery(normalizeTag(text))
+	tag.SetField("tags")
+	tag.SetBoost(tagBoost)
+	return bleve.NewDisjunctionQuery(title, description, tag)
+}
+
+// listedQuery matches the ready videos that are public at now, the same rule as models.Video.IsPublic
+func listedQuery(now time.Time) query.Query {
+	ready := termQuery("status", models.VideoStatusReady)
+
+	published := bleve.NewDateRangeQuery(time.Time{}, now)
+	published.SetField("publish_at")
+	scheduled := bleve.NewConjunctionQuery(termQuery("visibility", models.VideoVisibilityScheduled), published)
+
+	return bleve.NewConjunctionQuery(ready, bleve.NewDisjunctionQuery(termQuery("visibility", models.VideoVisibilityPublic), scheduled))
+}
+
+func filterQueries(q Query) []query.Query {
+	var filters []query.Query
+	if !q.UploadedAfter.IsZero() {
+		uploaded := bleve.NewDateRangeQuery(q.UploadedAfter, time.Time{})
+		uploaded.SetField("created_at")
+		filters = append(filters, uploaded)
+	}
+	if q.MinDuration > 0 || q.MaxDuration > 0 {
+		var minDuration, maxDuration *float64
+		if q.MinDuration > 0 {
+			minDuration = &q.MinDuration
+		}
+		if q.MaxDuration > 0 {
+			maxDuration = &q.MaxDuration
+		}
+		duration := bleve.NewNumericRangeQuery(minDuration, maxDuration)
+		duration.SetField("duration")
+		filters = append(filters, duration)
+	}
+	if q.ChannelID != uuid.Nil {
+		filters = append(filters, termQuery("channel_id", q.ChannelID.String()))
+	}
+	for _, tag := range q.Tags {
+		filters = append(filters, termQuery("tags", normalizeTag(tag)))
+	}
+	return filters
+}
+
+func termQuery(field, term string) *query.TermQuery {
+	q := bleve.NewTermQuery(term)
+	q.SetField(field)
+	return q
+}
+
+// boostedScore multiplies the text relevance of a hit with its popularity and recency. Recency
+// counts from the publish time, documents indexed before it was stored fall back to the upload time.
+func boostedScore(score float64, fields map[string]interface{}, now time.Time) float64 {
+	views, _ := fields["view_count"].(float64)
+	popularity := 1 + viewsWeight*math.Log10(1+max(views, 0))
+
+	published, ok := fields["published_at"].(string)
+	if !ok {
+		published, ok = fields["created_at"].(string)
+	}
+	recency := 1.0
+	if ok {
+		if publishedAt, err := time.Parse(time.RFC3339, published); err == nil {
+			age := max(now.Sub(publishedAt), 0)
+			recency += recencyWeight * math.Exp(-float64(age)/float64(recencyDecay))
+		}
+	}
+	return score * popularity * recency
+}
+
+// normalizeTag makes tag filters case insensitive, tags are indexed as keywords
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
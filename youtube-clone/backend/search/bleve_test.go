@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestBleveSearch(t *testing.T) {
+	ctx := context.Background()
+	index, err := bleve.NewMemOnly(newIndexMapping())
+	if err != nil {
+		t.Fatalf("create index: %v", err)
+	}
+	b := &Bleve{index: index}
+	t.Cleanup(func() { _ = b.Close() })
+
+	now := time.Now()
+	recentlyPublished := now.Add(-time.Hour)
+	fresh := &models.Video{ID: uuid.New(), Title: "golang tutorial", Status: models.VideoStatusReady,
+		Visibility: models.VideoVisibilityPublic, CreatedAt: now.AddDate(-1, 0, 0), PublishAt: &recentlyPublished}
+	old := &models.Video{ID: uuid.New(), Title: "golang tutorial", Status: models.VideoStatusReady,
+		Visibility: models.VideoVisibilityPublic, CreatedAt: now.AddDate(0, -6, 0)}
+	private := &models.Video{ID: uuid.New(), Title: "golang tutorial", Status: models.VideoStatusReady,
+		Visibility: models.VideoVisibilityPrivate, CreatedAt: now}
+	if err := b.Index(ctx, fresh, old, private); err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		q         Query
+		wantIDs   []uuid.UUID
+		wantTotal int64
+		wantErr   error
+	}{
+		{name: "ranked by publish time", q: Query{Text: "golang", Limit: 10}, wantIDs: []uuid.UUID{fresh.ID, old.ID}, wantTotal: 2},
+		{name: "paged", q: Query{Text: "golang", Offset: 1, Limit: 1}, wantIDs: []uuid.UUID{old.ID}, wantTotal: 2},
+		{name: "past the hits", q: Query{Text: "golang", Offset: 5, Limit: 10}, wantTotal: 2},
+		{name: "negative offset", q: Query{Text: "golang", Offset: -1, Limit: 10}, wantErr: ErrInvalidQuery},
+		{name: "no text", q: Query{Text: "  ", Limit: 10}, wantErr: ErrInvalidQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.Search(ctx, tt.q)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Search() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.Total != tt.wantTotal {
+				t.Errorf("Search() total = %d, want %d", got.Total, tt.wantTotal)
+			}
+			if len(got.Hits) != len(tt.wantIDs) {
+				t.Fatalf("Search() = %d hits, want %d", len(got.Hits), len(tt.wantIDs))
+			}
+			for i, hit := range got.Hits {
+				if hit.ID != tt.wantIDs[i] {
+					t.Errorf("Search() hit %d = %s, want %s", i, hit.ID, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const defaultIndexPath = "./data/search.bleve"
+
+// CandidateLimit caps the hits a search ranks, pages beyond it are empty
+const CandidateLimit = 1000
+
+// ErrInvalidQuery occurs when there is no text to search for or the page is out of range
+var ErrInvalidQuery = errors.New("invalid search query")
+
+const (
+	UploadedLastHour  = "hour"
+	UploadedToday     = "today"
+	UploadedThisWeek  = "week"
+	UploadedThisMonth = "month"
+	UploadedThisYear  = "year"
+)
+
+const (
+	// DurationShort videos are under 4 minutes
+	DurationShort = "short"
+	// DurationMedium videos are between 4 and 20 minutes
+	DurationMedium = "medium"
+	// DurationLong videos are over 20 minutes
+	DurationLong = "long"
+)
+
+// Query is a full-text search over the listed videos. Zero valued filters are not applied.
+type Query struct {
+	Text          string
+	UploadedAfter time.Time
+	MinDuration   float64 // seconds, inclusive
+	MaxDuration   float64 // seconds, exclusive
+	ChannelID     uuid.UUID
+	Tags          []string // every tag has to be present
+	Offset        int
+	Limit         int
+}
+
+// Hit is a matching video, Highlights maps a field to its fragments with the matched terms marked
+type Hit struct {
+	ID         uuid.UUID
+	Score      float64
+	Highlights map[string][]string
+}
+
+// Result is a page of hits, Total counts every hit that could be paged to
+type Result struct {
+	Hits  []Hit
+	Total int64
+}
+
+// Index is implemented by every backend able to search videos
+type Index interface {
+	// Index adds the videos to the index, replacing what was indexed for them before
+	Index(ctx context.Context, videos ...*models.Video) error
+	// Delete removes the videos from the index. Deleting a missing video is not an error
+	Delete(ctx context.Context, IDs ...uuid.UUID) error
+	// Search returns the listed videos matching q, most relevant first
+	Search(ctx context.Context, q Query) (*Result, error)
+	// Count returns the number of indexed videos
+	Count() (uint64, error)
+	Close() error
+}
+
+// New opens the search index selected by the environment, creating it when missing
+func New(env models.Env) (Index, error) {
+	path := env.SearchIndexPath
+	if path == "" {
+		path = defaultIndexPath
+	}
+	return NewBleve(path)
+}
+
+// UploadedSince returns the earliest upload time matching an upload date filter, the zero time for unknown filters
+func UploadedSince(filter string, now time.Time) time.Time {
+	switch filter {
+	case UploadedLastHour:
+		return now.Add(-time.Hour)
+	case UploadedToday:
+		return now.AddDate(0, 0, -1)
+	case UploadedThisWeek:
+		return now.AddDate(0, 0, -7)
+	case UploadedThisMonth:
+		return now.AddDate(0, -1, 0)
+	case UploadedThisYear:
+		return now.AddDate(-1, 0, 0)
+	}
+	return time.Time{}
+}
+
+// DurationRange returns the bounds in seconds of a duration filter, zero for an open bound
+func DurationRange(filter string) (float64, float64) {
+	switch filter {
+	case DurationShort:
+		return 0, 4 * 60
+	case DurationMedium:
+		return 4 * 60, 20 * 60
+	case DurationLong:
+		return 20 * 60, 0
+	}
+	return 0, 0
+}
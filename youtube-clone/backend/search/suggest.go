@@ -0,0 +1,145 @@
+package search
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// suggestionsPerPrefix is the number of completions kept for every prefix
+	suggestionsPerPrefix = 10
+	// maxPrefixLength bounds the depth of the trie in runes, longer prefixes are matched by filtering
+	maxPrefixLength = 40
+)
+
+// Suggester completes search prefixes from a weighted set of phrases. It is immutable once built,
+// so lookups need no locking and a new Suggester can replace an old one at any time.
+type Suggester struct {
+	root *trieNode
+}
+
+// trieNode holds the best completions of the prefix leading to it, best first
+type trieNode struct {
+	children map[rune]*trieNode
+	top      []string
+}
+
+// personalData matches what looks like an email address, a link or a phone, order or account number
+var personalData = regexp.MustCompile(`@|://|www\.|\d{5,}`)
+
+// Suggestable reports whether a past query may be suggested to other people. Queries that look like
+// they carry personal data, or that contain a word of denied, stay out of the suggestions.
+func Suggestable(query string, denied map[string]bool) bool {
+	query = NormalizeQuery(query)
+	if query == "" || personalData.MatchString(query) {
+		return false
+	}
+	for _, word := range strings.Fields(query) {
+		if denied[word] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseDenylist reads a comma separated list of words that must never be suggested
+func ParseDenylist(list string) map[string]bool {
+	denied := map[string]bool{}
+	for _, word := range strings.Split(list, ",") {
+		if word = NormalizeQuery(word); word != "" {
+			denied[word] = true
+		}
+	}
+	return denied
+}
+
+type weightedPhrase struct {
+	phrase string
+	weight float64
+}
+
+// BuildSuggester builds a Suggester from phrases and their weights, phrases are normalized with NormalizeQuery
+func BuildSuggester(weights map[string]float64) *Suggester {
+	merged := make(map[string]float64, len(weights))
+	for phrase, weight := range weights {
+		if phrase = NormalizeQuery(phrase); phrase != "" {
+			merged[phrase] += weight
+		}
+	}
+	phrases := make([]weightedPhrase, 0, len(merged))
+	for phrase, weight := range merged {
+		phrases = append(phrases, weightedPhrase{phrase: phrase, weight: weight})
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if phrases[i].weight != phrases[j].weight {
+			return phrases[i].weight > phrases[j].weight
+		}
+		return phrases[i].phrase < phrases[j].phrase
+	})
+
+	// phrases come in best first, so every node is full once it saw its first suggestionsPerPrefix phrases
+	root := newTrieNode()
+	for _, p := range phrases {
+		node := root
+		node.offer(p.phrase)
+		for depth, r := range []rune(p.phrase) {
+			if depth == maxPrefixLength {
+				break
+			}
+			child, ok := node.children[r]
+			if !ok {
+				child = newTrieNode()
+				node.children[r] = child
+			}
+			node = child
+			node.offer(p.phrase)
+		}
+	}
+	return &Suggester{root: root}
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: map[rune]*trieNode{}}
+}
+
+func (n *trieNode) offer(phrase string) {
+	if len(n.top) < suggestionsPerPrefix {
+		n.top = append(n.top, phrase)
+	}
+}
+
+// Suggest returns up to limit completions of prefix, best first. A nil Suggester suggests nothing.
+func (s *Suggester) Suggest(prefix string, limit int) []string {
+	prefix = NormalizeQuery(prefix)
+	if s == nil || prefix == "" || limit <= 0 {
+		return []string{}
+	}
+
+	node := s.root
+	for depth, r := range []rune(prefix) {
+		if depth == maxPrefixLength {
+			break
+		}
+		if node = node.children[r]; node == nil {
+			return []string{}
+		}
+	}
+
+	suggestions := make([]string, 0, min(limit, len(node.top)))
+	for _, phrase := range node.top {
+		if len(suggestions) == limit {
+			break
+		}
+		// beyond maxPrefixLength the node also holds phrases sharing only the first runes
+		if strings.HasPrefix(phrase, prefix) {
+			suggestions = append(suggestions, phrase)
+		}
+	}
+	return suggestions
+}
+
+// NormalizeQuery lowercases a query and collapses its whitespace, so equal queries count together
+func NormalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSuggesterSuggest(t *testing.T) {
+	long := strings.Repeat("a", maxPrefixLength)
+	weights := map[string]float64{
+		"golang tutorial":  5,
+		"Golang  Tutorial": 2, // merged with the above
+		"golang generics":  6,
+		"go concurrency":   4,
+		"gopher":           1,
+		"rust":             3,
+		"écoute":           1,
+		long + "b":         2,
+		long + "c":         1,
+		"   ":              9, // nothing left to suggest
+	}
+	for i := 0; i < suggestionsPerPrefix+5; i++ {
+		weights[fmt.Sprintf("zz %02d", i)] = float64(100 - i)
+	}
+	s := BuildSuggester(weights)
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{name: "best first", prefix: "go", limit: 10, want: []string{"golang tutorial", "golang generics", "go concurrency", "gopher"}},
+		{name: "limited", prefix: "go", limit: 2, want: []string{"golang tutorial", "golang generics"}},
+		{name: "normalized prefix", prefix: "  GOLANG   T", limit: 10, want: []string{"golang tutorial"}},
+		{name: "complete phrase", prefix: "rust", limit: 10, want: []string{"rust"}},
+		{name: "unicode", prefix: "éc", limit: 10, want: []string{"écoute"}},
+		{name: "no match", prefix: "java", limit: 10, want: []string{}},
+		{name: "empty prefix", prefix: "  ", limit: 10, want: []string{}},
+		{name: "zero limit", prefix: "go", limit: 0, want: []string{}},
+		{name: "beyond the trie depth", prefix: long + "c", limit: 10, want: []string{long + "c"}},
+		{name: "at most suggestionsPerPrefix", prefix: "zz", limit: 100, want: []string{
+			"zz 00", "zz 01", "zz 02", "zz 03", "zz 04", "zz 05", "zz 06", "zz 07", "zz 08", "zz 09",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Suggest(tt.prefix, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Suggest(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNilSuggester(t *testing.T) {
+	var s *Suggester
+	if got := s.Suggest("go", 10); len(got) != 0 {
+		t.Errorf("Suggest() on nil Suggester = %q, want none", got)
+	}
+}
+
+func TestSuggestable(t *testing.T) {
+	denied := ParseDenylist(" Badword , ,worse")
+
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: "golang tutorial", want: true},
+		{query: "top 10 songs 2024", want: true},
+		{query: "  ", want: false},
+		{query: "BADWORD compilation", want: false},
+		{query: "badwords", want: true},
+		{query: "jane.doe@example.com", want: false},
+		{query: "https://example.com/watch", want: false},
+		{query: "www.example.com", want: false},
+		{query: "call 5551234567", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := Suggestable(tt.query, denied); got != tt.want {
+				t.Errorf("Suggestable(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
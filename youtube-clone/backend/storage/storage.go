@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	// DriverLocal stores objects on the local filesystem
+	DriverLocal = "local"
+	// DriverS3 stores objects in an S3-compatible bucket
+	DriverS3 = "s3"
+
+	defaultLocalRoot = "./data/blobs"
+)
+
+var (
+	// ErrNotFound occurs when the requested object does not exist in the store
+	ErrNotFound = errors.New("object not found")
+
+	// ErrInvalidKey occurs when an object key is empty or escapes the store root
+	ErrInvalidKey = errors.New("invalid object key")
+
+	// ErrUnknownDriver occurs when STORAGE_DRIVER names an unsupported backend
+	ErrUnknownDriver = errors.New("unknown storage driver")
+)
+
+// Object describes a stored blob
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	Checksum    string // hex encoded sha256 of the content, only set on Put
+	ModTime     time.Time
+}
+
+// BlobStore is implemented by every backend able to hold video bytes
+type BlobStore interface {
+	// Put streams r into the object identified by key, replacing any existing content
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (*Object, error)
+	// Get opens the object for reading. The caller must close the returned reader
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	// Stat returns the object metadata without reading its content
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete removes the object. Deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the BlobStore selected by the environment
+func New(env models.Env) (BlobStore, error) {
+	switch strings.ToLower(env.StorageDriver) {
+	case "", DriverLocal:
+		root := env.StorageLocalPath
+		if root == "" {
+			root = defaultLocalRoot
+		}
+		return NewLocal(root)
+	case DriverS3:
+		return NewS3(env.S3Endpoint, env.S3AccessKey, env.S3SecretKey, env.S3Bucket, env.S3Region, env.S3UseSSL == "true")
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, env.StorageDriver)
+	}
+}
+
+// VideoObjectKey returns the key under which the original upload of a video is stored
+func VideoObjectKey(videoID uuid.UUID, ext string) string {
+	return fmt.Sprintf("videos/%s/original%s", videoID.String(), strings.ToLower(ext))
+}
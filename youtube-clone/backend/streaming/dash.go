@@ -0,0 +1,183 @@
+package streaming
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	// DASHContentType is the media type of MPEG-DASH manifests
+	DASHContentType = "application/dash+xml"
+
+	// DASHManifest is the name of the manifest players start from
+	DASHManifest = "manifest.mpd"
+
+	dashNamespace   = "urn:mpeg:dash:schema:mpd:2011"
+	dashProfile     = "urn:mpeg:dash:profile:isoff-live:2011"
+	dashChannelsURI = "urn:mpeg:dash:23003:3:audio_channel_configuration:2011"
+	dashTimescale   = 1000
+
+	// the manifest reuses the fMP4 segments packaged for HLS, relative to .../dash/manifest.mpd
+	dashBaseURL = "../hls/"
+)
+
+type mpd struct {
+	XMLName                   xml.Name `xml:"MPD"`
+	Xmlns                     string   `xml:"xmlns,attr"`
+	Profiles                  string   `xml:"profiles,attr"`
+	Type                      string   `xml:"type,attr"`
+	MediaPresentationDuration string   `xml:"mediaPresentationDuration,attr"`
+	MinBufferTime             string   `xml:"minBufferTime,attr"`
+	BaseURL                   string   `xml:"BaseURL"`
+	Period                    period   `xml:"Period"`
+}
+
+type period struct {
+	ID             string          `xml:"id,attr"`
+	Start          string          `xml:"start,attr"`
+	AdaptationSets []adaptationSet `xml:"AdaptationSet"`
+}
+
+type adaptationSet struct {
+	ID               int              `xml:"id,attr"`
+	ContentType      string           `xml:"contentType,attr"`
+	MimeType         string           `xml:"mimeType,attr"`
+	Lang             string           `xml:"lang,attr,omitempty"`
+	SegmentAlignment bool             `xml:"segmentAlignment,attr"`
+	StartWithSAP     int              `xml:"startWithSAP,attr"`
+	MaxWidth         int              `xml:"maxWidth,attr,omitempty"`
+	MaxHeight        int              `xml:"maxHeight,attr,omitempty"`
+	SegmentTemplate  segmentTemplate  `xml:"SegmentTemplate"`
+	Representations  []representation `xml:"Representation"`
+}
+
+type segmentTemplate struct {
+	Timescale      int    `xml:"timescale,attr"`
+	Duration       int    `xml:"duration,attr"`
+	StartNumber    int    `xml:"startNumber,attr"`
+	Initialization string `xml:"initialization,attr"`
+	Media          string `xml:"media,attr"`
+}
+
+type representation struct {
+	ID                        string                     `xml:"id,attr"`
+	Bandwidth                 int                        `xml:"bandwidth,attr"`
+	Codecs                    string                     `xml:"codecs,attr"`
+	Width                     int                        `xml:"width,attr,omitempty"`
+	Height                    int                        `xml:"height,attr,omitempty"`
+	AudioChannelConfiguration *audioChannelConfiguration `xml:"AudioChannelConfiguration,omitempty"`
+}
+
+type audioChannelConfiguration struct {
+	SchemeIDURI string `xml:"schemeIdUri,attr"`
+	Value       string `xml:"value,attr"`
+}
+
+// MPD renders a static MPD describing the packaged renditions of a video,
+// with one AdaptationSet for the video renditions and one for the audio track.
+func MPD(renditions []*models.Rendition) ([]byte, error) {
+	video := adaptationSet{
+		ID:               0,
+		ContentType:      "video",
+		MimeType:         "video/mp4",
+		SegmentAlignment: true,
+		StartWithSAP:     1,
+	}
+	audio := adaptationSet{
+		ID:               1,
+		ContentType:      "audio",
+		MimeType:         "audio/mp4",
+		Lang:             "und",
+		SegmentAlignment: true,
+		StartWithSAP:     1,
+	}
+
+	duration := 0.0
+	for _, rendition := range renditions {
+		if rendition.SegmentCount == 0 {
+			continue
+		}
+		duration = max(duration, rendition.Duration)
+
+		switch rendition.Kind {
+		case models.RenditionKindVideo:
+			video.SegmentTemplate = newSegmentTemplate(rendition)
+			video.MaxWidth = max(video.MaxWidth, rendition.Width)
+			video.MaxHeight = max(video.MaxHeight, rendition.Height)
+			video.Representations = append(video.Representations, representation{
+				ID:        rendition.Name,
+				Bandwidth: rendition.PeakBitrate,
+				Codecs:    rendition.Codecs,
+				Width:     rendition.Width,
+				Height:    rendition.Height,
+			})
+		case models.RenditionKindAudio:
+			audio.SegmentTemplate = newSegmentTemplate(rendition)
+			audio.Representations = append(audio.Representations, representation{
+				ID:        rendition.Name,
+				Bandwidth: rendition.AudioBitrate,
+				Codecs:    rendition.Codecs,
+				AudioChannelConfiguration: &audioChannelConfiguration{
+					SchemeIDURI: dashChannelsURI,
+					Value:       "2",
+				},
+			})
+		}
+	}
+	if len(video.Representations) == 0 {
+		return nil, errors.New("no packaged video renditions")
+	}
+
+	sets := []adaptationSet{video}
+	if len(audio.Representations) > 0 {
+		sets = append(sets, audio)
+	}
+
+	manifest := mpd{
+		Xmlns:                     dashNamespace,
+		Profiles:                  dashProfile,
+		Type:                      "static",
+		MediaPresentationDuration: isoDuration(duration),
+		MinBufferTime:             isoDuration(float64(video.SegmentTemplate.Duration) / dashTimescale),
+		BaseURL:                   dashBaseURL,
+		Period: period{
+			ID:             "0",
+			Start:          "PT0S",
+			AdaptationSets: sets,
+		},
+	}
+
+	out, err := xml.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// newSegmentTemplate addresses the segments of each representation by number, the same
+// files the HLS media playlists list
+func newSegmentTemplate(rendition *models.Rendition) segmentTemplate {
+	return segmentTemplate{
+		Timescale:      dashTimescale,
+		Duration:       int(rendition.SegmentDuration * dashTimescale),
+		StartNumber:    0,
+		Initialization: "$RepresentationID$/init.mp4",
+		Media:          "$RepresentationID$/seg_$Number%05d$.m4s",
+	}
+}
+
+// isoDuration formats seconds as an ISO 8601 duration, e.g. PT1M4.5S
+func isoDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second)).Round(time.Millisecond)
+	minutes := int(d / time.Minute)
+	rest := (d % time.Minute).Seconds()
+	if minutes == 0 {
+		return "PT" + strconv.FormatFloat(rest, 'f', -1, 64) + "S"
+	}
+	return fmt.Sprintf("PT%dM%sS", minutes, strconv.FormatFloat(rest, 'f', -1, 64))
+}
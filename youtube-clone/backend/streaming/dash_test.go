@@ -0,0 +1,105 @@
+package streaming
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestMPD(t *testing.T) {
+	video720 := &models.Rendition{Name: "720p", Kind: models.RenditionKindVideo, Width: 1280, Height: 720,
+		PeakBitrate: 3_000_000, Codecs: "avc1.64001f", SegmentCount: 16, SegmentDuration: 4, Duration: 64.5}
+	video360 := &models.Rendition{Name: "360p", Kind: models.RenditionKindVideo, Width: 640, Height: 360,
+		PeakBitrate: 1_000_000, Codecs: "avc1.64001e", SegmentCount: 16, SegmentDuration: 4, Duration: 64.4}
+	unpackaged := &models.Rendition{Name: "1080p", Kind: models.RenditionKindVideo, Width: 1920, Height: 1080}
+	audio := &models.Rendition{Name: "audio", Kind: models.RenditionKindAudio, AudioBitrate: 128_000,
+		Codecs: "mp4a.40.2", SegmentCount: 16, SegmentDuration: 4, Duration: 64.52}
+
+	tests := []struct {
+		name         string
+		renditions   []*models.Rendition
+		wantErr      bool
+		wantDuration string
+		wantSets     []string // content type and representation IDs of every adaptation set
+	}{
+		{
+			name:         "video and audio",
+			renditions:   []*models.Rendition{video720, unpackaged, audio, video360},
+			wantDuration: "PT1M4.52S",
+			wantSets:     []string{"video:720p,360p", "audio:audio"},
+		},
+		{
+			name:         "video only",
+			renditions:   []*models.Rendition{video360},
+			wantDuration: "PT1M4.4S",
+			wantSets:     []string{"video:360p"},
+		},
+		{name: "nothing packaged", renditions: []*models.Rendition{unpackaged, audio}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := MPD(tt.renditions)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MPD() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !strings.HasPrefix(string(out), xml.Header) {
+				t.Errorf("MPD() does not start with the XML header")
+			}
+
+			var manifest mpd
+			if err := xml.Unmarshal(out, &manifest); err != nil {
+				t.Fatalf("MPD() is not valid XML: %v", err)
+			}
+			if manifest.Xmlns != dashNamespace || manifest.Type != "static" || manifest.BaseURL != dashBaseURL {
+				t.Errorf("MPD() = %+v", manifest)
+			}
+			if manifest.MediaPresentationDuration != tt.wantDuration || manifest.MinBufferTime != "PT4S" {
+				t.Errorf("durations = %s, %s, want %s, PT4S", manifest.MediaPresentationDuration, manifest.MinBufferTime, tt.wantDuration)
+			}
+
+			var sets []string
+			for _, set := range manifest.Period.AdaptationSets {
+				ids := make([]string, 0, len(set.Representations))
+				for _, representation := range set.Representations {
+					ids = append(ids, representation.ID)
+				}
+				sets = append(sets, set.ContentType+":"+strings.Join(ids, ","))
+
+				template := set.SegmentTemplate
+				if template.Timescale != dashTimescale || template.Duration != 4000 ||
+					template.Media != "$RepresentationID$/seg_$Number%05d$.m4s" || template.Initialization != "$RepresentationID$/init.mp4" {
+					t.Errorf("SegmentTemplate of %s = %+v", set.ContentType, template)
+				}
+			}
+			if strings.Join(sets, " ") != strings.Join(tt.wantSets, " ") {
+				t.Errorf("adaptation sets = %v, want %v", sets, tt.wantSets)
+			}
+		})
+	}
+}
+
+func TestISODuration(t *testing.T) {
+	tests := []struct {
+		seconds float64
+		want    string
+	}{
+		{seconds: 0, want: "PT0S"},
+		{seconds: 4, want: "PT4S"},
+		{seconds: 4.5, want: "PT4.5S"},
+		{seconds: 60, want: "PT1M0S"},
+		{seconds: 64.5004, want: "PT1M4.5S"},
+		{seconds: 3725.25, want: "PT62M5.25S"},
+	}
+
+	for _, tt := range tests {
+		if got := isoDuration(tt.seconds); got != tt.want {
+			t.Errorf("isoDuration(%v) = %s, want %s", tt.seconds, got, tt.want)
+		}
+	}
+}
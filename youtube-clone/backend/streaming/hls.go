@@ -0,0 +1,100 @@
+package streaming
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	// HLSContentType is the media type of HLS playlists
+	HLSContentType = "application/vnd.apple.mpegurl"
+
+	// HLSMasterPlaylist is the name of the playlist players start from
+	HLSMasterPlaylist = "master.m3u8"
+	// HLSMediaPlaylist is the name of the per rendition playlist
+	HLSMediaPlaylist = "index.m3u8"
+
+	hlsVersion    = 7 // needed for EXT-X-MAP on fMP4 segments
+	hlsAudioGroup = "audio"
+)
+
+// MediaSegment is one entry of a media playlist
+type MediaSegment struct {
+	URI      string
+	Duration float64 // seconds
+}
+
+// MediaPlaylist renders the VOD playlist of a single rendition
+func MediaPlaylist(initURI string, segments []MediaSegment) string {
+	target := 0
+	for _, segment := range segments {
+		target = max(target, int(math.Ceil(segment.Duration)))
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#EXT-X-VERSION:%d\n", hlsVersion)
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", target)
+	b.WriteString("#EXT-X-MEDIA-SEQUENCE:0\n")
+	b.WriteString("#EXT-X-PLAYLIST-TYPE:VOD\n")
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+	fmt.Fprintf(&b, "#EXT-X-MAP:URI=%q\n", initURI)
+	for _, segment := range segments {
+		fmt.Fprintf(&b, "#EXTINF:%.6f,\n", segment.Duration)
+		b.WriteString(segment.URI + "\n")
+	}
+	b.WriteString("#EXT-X-ENDLIST\n")
+	return b.String()
+}
+
+// MasterPlaylist renders the adaptive bitrate playlist listing every packaged rendition.
+// Video renditions reference the audio rendition through an EXT-X-MEDIA group when there is one.
+func MasterPlaylist(renditions []*models.Rendition) string {
+	var audio *models.Rendition
+	for _, rendition := range renditions {
+		if rendition.Kind == models.RenditionKindAudio && rendition.SegmentCount > 0 {
+			audio = rendition
+			break
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#EXT-X-VERSION:%d\n", hlsVersion)
+	b.WriteString("#EXT-X-INDEPENDENT-SEGMENTS\n")
+
+	if audio != nil {
+		fmt.Fprintf(&b, "#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=%q,NAME=%q,DEFAULT=YES,AUTOSELECT=YES,CHANNELS=\"2\",URI=%q\n",
+			hlsAudioGroup, "default", MediaPlaylistURI(audio))
+	}
+
+	for _, rendition := range renditions {
+		if rendition.Kind != models.RenditionKindVideo || rendition.SegmentCount == 0 {
+			continue
+		}
+
+		bandwidth, average := rendition.PeakBitrate, rendition.VideoBitrate
+		codecs := rendition.Codecs
+		if audio != nil {
+			bandwidth += audio.AudioBitrate
+			average += audio.AudioBitrate
+			codecs += "," + audio.Codecs
+		}
+
+		fmt.Fprintf(&b, "#EXT-X-STREAM-INF:BANDWIDTH=%d,AVERAGE-BANDWIDTH=%d,RESOLUTION=%dx%d,CODECS=%q",
+			bandwidth, average, rendition.Width, rendition.Height, codecs)
+		if audio != nil {
+			fmt.Fprintf(&b, ",AUDIO=%q", hlsAudioGroup)
+		}
+		b.WriteString("\n" + MediaPlaylistURI(rendition) + "\n")
+	}
+	return b.String()
+}
+
+// MediaPlaylistURI is the location of a rendition playlist relative to the master playlist
+func MediaPlaylistURI(rendition *models.Rendition) string {
+	return rendition.Name + "/" + HLSMediaPlaylist
+}
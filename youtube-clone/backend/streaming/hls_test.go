@@ -0,0 +1,97 @@
+package streaming
+
+import (
+	"testing"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+func TestMediaPlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		segments []MediaSegment
+		want     string
+	}{
+		{
+			name:     "target duration rounds the longest segment up",
+			segments: []MediaSegment{{URI: "seg_00000.m4s", Duration: 4}, {URI: "seg_00001.m4s", Duration: 4.2}, {URI: "seg_00002.m4s", Duration: 1.5}},
+			want: "#EXTM3U\n" +
+				"#EXT-X-VERSION:7\n" +
+				"#EXT-X-TARGETDURATION:5\n" +
+				"#EXT-X-MEDIA-SEQUENCE:0\n" +
+				"#EXT-X-PLAYLIST-TYPE:VOD\n" +
+				"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+				"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+				"#EXTINF:4.000000,\nseg_00000.m4s\n" +
+				"#EXTINF:4.200000,\nseg_00001.m4s\n" +
+				"#EXTINF:1.500000,\nseg_00002.m4s\n" +
+				"#EXT-X-ENDLIST\n",
+		},
+		{
+			name: "no segments",
+			want: "#EXTM3U\n" +
+				"#EXT-X-VERSION:7\n" +
+				"#EXT-X-TARGETDURATION:0\n" +
+				"#EXT-X-MEDIA-SEQUENCE:0\n" +
+				"#EXT-X-PLAYLIST-TYPE:VOD\n" +
+				"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+				"#EXT-X-MAP:URI=\"init.mp4\"\n" +
+				"#EXT-X-ENDLIST\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MediaPlaylist("init.mp4", tt.segments); got != tt.want {
+				t.Errorf("MediaPlaylist() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMasterPlaylist(t *testing.T) {
+	video720 := &models.Rendition{Name: "720p", Kind: models.RenditionKindVideo, Width: 1280, Height: 720,
+		VideoBitrate: 2_500_000, PeakBitrate: 3_000_000, Codecs: "avc1.64001f", SegmentCount: 3}
+	video360 := &models.Rendition{Name: "360p", Kind: models.RenditionKindVideo, Width: 640, Height: 360,
+		VideoBitrate: 800_000, PeakBitrate: 1_000_000, Codecs: "avc1.64001e", SegmentCount: 3}
+	unpackaged := &models.Rendition{Name: "1080p", Kind: models.RenditionKindVideo, Width: 1920, Height: 1080,
+		VideoBitrate: 5_000_000, PeakBitrate: 6_000_000, Codecs: "avc1.640028"}
+	audio := &models.Rendition{Name: "audio", Kind: models.RenditionKindAudio, AudioBitrate: 128_000,
+		Codecs: "mp4a.40.2", SegmentCount: 3}
+
+	tests := []struct {
+		name       string
+		renditions []*models.Rendition
+		want       string
+	}{
+		{
+			name:       "video with audio group",
+			renditions: []*models.Rendition{video720, unpackaged, audio, video360},
+			want: "#EXTM3U\n" +
+				"#EXT-X-VERSION:7\n" +
+				"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+				"#EXT-X-MEDIA:TYPE=AUDIO,GROUP-ID=\"audio\",NAME=\"default\",DEFAULT=YES,AUTOSELECT=YES,CHANNELS=\"2\",URI=\"audio/index.m3u8\"\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=3128000,AVERAGE-BANDWIDTH=2628000,RESOLUTION=1280x720,CODECS=\"avc1.64001f,mp4a.40.2\",AUDIO=\"audio\"\n" +
+				"720p/index.m3u8\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=1128000,AVERAGE-BANDWIDTH=928000,RESOLUTION=640x360,CODECS=\"avc1.64001e,mp4a.40.2\",AUDIO=\"audio\"\n" +
+				"360p/index.m3u8\n",
+		},
+		{
+			name:       "video without audio",
+			renditions: []*models.Rendition{video360},
+			want: "#EXTM3U\n" +
+				"#EXT-X-VERSION:7\n" +
+				"#EXT-X-INDEPENDENT-SEGMENTS\n" +
+				"#EXT-X-STREAM-INF:BANDWIDTH=1000000,AVERAGE-BANDWIDTH=800000,RESOLUTION=640x360,CODECS=\"avc1.64001e\"\n" +
+				"360p/index.m3u8\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MasterPlaylist(tt.renditions); got != tt.want {
+				t.Errorf("MasterPlaylist() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
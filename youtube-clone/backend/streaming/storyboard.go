@@ -0,0 +1,50 @@
+package streaming
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/joshua468/youtube-clone/backend/transcode"
+)
+
+const (
+	// WebVTTContentType is the media type of WebVTT tracks
+	WebVTTContentType = "text/vtt"
+
+	// StoryboardTrack is the name of the thumbnail track
+	StoryboardTrack = "storyboard.vtt"
+)
+
+// StoryboardSheet is the name of the n-th sprite sheet, relative to the storyboard track
+func StoryboardSheet(n int) string {
+	return fmt.Sprintf("storyboard/sprite_%03d.jpg", n)
+}
+
+// StoryboardVTT renders a WebVTT thumbnail track mapping every interval of the video to
+// its tile in the sprite sheets, using media fragments (sprite.jpg#xywh=x,y,w,h)
+func StoryboardVTT(duration time.Duration, spec transcode.StoryboardSpec) string {
+	tiles := int(math.Ceil(duration.Seconds() / spec.Interval.Seconds()))
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i := 0; i < tiles; i++ {
+		start := time.Duration(i) * spec.Interval
+		end := min(start+spec.Interval, duration)
+
+		sheet, tile := i/spec.TilesPerSheet(), i%spec.TilesPerSheet()
+		x := (tile % spec.Columns) * spec.TileWidth
+		y := (tile / spec.Columns) * spec.TileHeight
+
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			vttTimestamp(start), vttTimestamp(end), StoryboardSheet(sheet), x, y, spec.TileWidth, spec.TileHeight)
+	}
+	return b.String()
+}
+
+// vttTimestamp formats d as hh:mm:ss.ttt
+func vttTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, ms%1000)
+}
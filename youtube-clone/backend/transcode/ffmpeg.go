@@ -0,0 +1,222 @@
+package transcode
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFFmpegBinary = "ffmpeg"
+	// stderrTail is how much of the ffmpeg output is kept in error messages
+	stderrTail = 2048
+)
+
+// FFmpeg transcodes by shelling out to a local ffmpeg binary
+type FFmpeg struct {
+	binary string
+}
+
+// NewFFmpeg creates a Transcoder using the ffmpeg binary at path, or the one on $PATH when empty
+func NewFFmpeg(path string) *FFmpeg {
+	if path == "" {
+		path = defaultFFmpegBinary
+	}
+	return &FFmpeg{binary: path}
+}
+
+// Transcode encodes input to an H.264/AAC MP4 per profile
+func (f *FFmpeg) Transcode(ctx context.Context, input, outputDir string, profiles []Profile) ([]Output, error) {
+	outputs := make([]Output, 0, len(profiles))
+	for _, profile := range profiles {
+		output := filepath.Join(outputDir, profile.Name+".mp4")
+		args := []string{
+			"-hide_banner", "-nostdin", "-y",
+			"-i", input,
+			// keep the aspect ratio, fit the profile height and keep the width even for x264
+			"-vf", fmt.Sprintf("scale=-2:%d", profile.Height),
+			"-c:v", "libx264",
+			"-preset", "veryfast",
+			"-profile:v", "main",
+			"-level", profile.Level,
+			// fixed keyframes so every rendition can be cut at the same points
+			"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%g)", KeyframeInterval.Seconds()),
+			"-sc_threshold", "0",
+			"-b:v", strconv.Itoa(profile.VideoBitrate),
+			"-maxrate", strconv.Itoa(profile.PeakBitrate()),
+			"-bufsize", strconv.Itoa(profile.VideoBitrate * 2),
+			"-c:a", "aac",
+			"-b:a", strconv.Itoa(profile.AudioBitrate),
+			"-ac", "2",
+			"-movflags", "+faststart",
+			output,
+		}
+		if err := f.run(ctx, args); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrTranscodeFailed, profile.Name, err)
+		}
+
+		outputs = append(outputs, Output{
+			Profile:  profile,
+			Path:     output,
+			MimeType: "video/mp4",
+		})
+	}
+	return outputs, nil
+}
+
+// Segment copies one track of input into fMP4 segments using the ffmpeg HLS muxer
+func (f *FFmpeg) Segment(ctx context.Context, input, outputDir, track string) (*Segments, error) {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	streamMap := "0:v:0"
+	if track == TrackAudio {
+		streamMap = "0:a:0?"
+	}
+
+	playlist := filepath.Join(outputDir, "ffmpeg.m3u8")
+	args := []string{
+		"-hide_banner", "-nostdin", "-y",
+		"-i", input,
+		"-map", streamMap,
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", strconv.Itoa(int(SegmentDuration.Seconds())),
+		"-hls_playlist_type", "vod",
+		// DASH addresses the same segments by number starting at 0
+		"-start_number", "0",
+		"-hls_segment_type", "fmp4",
+		"-hls_fmp4_init_filename", "init.mp4",
+		"-hls_segment_filename", filepath.Join(outputDir, "seg_%05d.m4s"),
+		playlist,
+	}
+	if err := f.run(ctx, args); err != nil {
+		if track == TrackAudio && strings.Contains(err.Error(), "does not contain any stream") {
+			return nil, ErrNoTrack
+		}
+		return nil, fmt.Errorf("%w: segment %s: %v", ErrTranscodeFailed, track, err)
+	}
+
+	segments, err := readSegmentList(playlist)
+	if err != nil {
+		return nil, err
+	}
+	segments.Dir = outputDir
+	segments.InitFile = "init.mp4"
+	return segments, nil
+}
+
+// Thumbnails grabs one frame per offset, scaled down to at most 720 lines
+func (f *FFmpeg) Thumbnails(ctx context.Context, input, outputDir string, offsets []time.Duration) ([]string, error) {
+	files := make([]string, 0, len(offsets))
+	for i, offset := range offsets {
+		output := filepath.Join(outputDir, fmt.Sprintf("thumb_%02d.jpg", i))
+		args := []string{
+			"-hide_banner", "-nostdin", "-y",
+			// seeking before -i is fast and still frame accurate when re-encoding
+			"-ss", strconv.FormatFloat(offset.Seconds(), 'f', 3, 64),
+			"-i", input,
+			"-frames:v", "1",
+			"-vf", "scale=-2:'min(720,ih)'",
+			"-q:v", "3",
+			output,
+		}
+		if err := f.run(ctx, args); err != nil {
+			return nil, fmt.Errorf("%w: thumbnail at %s: %v", ErrTranscodeFailed, offset, err)
+		}
+		files = append(files, output)
+	}
+	return files, nil
+}
+
+// Storyboard samples a frame every spec.Interval and tiles the frames into JPEG sprite sheets
+func (f *FFmpeg) Storyboard(ctx context.Context, input, outputDir string, spec StoryboardSpec) ([]string, error) {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	filter := fmt.Sprintf(
+		"fps=1/%g,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		spec.Interval.Seconds(), spec.TileWidth, spec.TileHeight, spec.TileWidth, spec.TileHeight, spec.Columns, spec.Rows,
+	)
+	args := []string{
+		"-hide_banner", "-nostdin", "-y",
+		"-i", input,
+		"-an",
+		"-vf", filter,
+		"-q:v", "4",
+		"-start_number", "0",
+		filepath.Join(outputDir, "sprite_%03d.jpg"),
+	}
+	if err := f.run(ctx, args); err != nil {
+		return nil, fmt.Errorf("%w: storyboard: %v", ErrTranscodeFailed, err)
+	}
+
+	sheets, err := filepath.Glob(filepath.Join(outputDir, "sprite_*.jpg"))
+	if err != nil {
+		return nil, err
+	}
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("%w: no sprite sheets produced", ErrTranscodeFailed)
+	}
+	sort.Strings(sheets)
+	return sheets, nil
+}
+
+// readSegmentList reads the segment names and durations back from the playlist written by ffmpeg
+func readSegmentList(playlist string) (*Segments, error) {
+	f, err := os.Open(playlist)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	segments := &Segments{}
+	duration := -1.0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXTINF:"):
+			value, _, _ := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			duration, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid segment duration %q", ErrTranscodeFailed, value)
+			}
+		case line != "" && !strings.HasPrefix(line, "#") && duration >= 0:
+			segments.Files = append(segments.Files, filepath.Base(line))
+			segments.Durations = append(segments.Durations, duration)
+			duration = -1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(segments.Files) == 0 {
+		return nil, fmt.Errorf("%w: no segments produced", ErrTranscodeFailed)
+	}
+	return segments, nil
+}
+
+func (f *FFmpeg) run(ctx context.Context, args []string) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.binary, args...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		out := stderr.Bytes()
+		if len(out) > stderrTail {
+			out = out[len(out)-stderrTail:]
+		}
+		return fmt.Errorf("%v: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package transcode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const defaultFFprobeBinary = "ffprobe"
+
+// supportedContainers maps the ffprobe format names we accept to the name recorded on the video
+var supportedContainers = map[string]string{
+	"mov,mp4,m4a,3gp,3g2,mj2": "mp4",
+	"matroska,webm":           "matroska",
+	"avi":                     "avi",
+	"mpegts":                  "mpegts",
+	"flv":                     "flv",
+	"mpeg":                    "mpeg",
+	"ogg":                     "ogg",
+}
+
+// supportedVideoCodecs are the codecs ffmpeg is expected to decode for transcoding
+var supportedVideoCodecs = map[string]bool{
+	"h264":       true,
+	"hevc":       true,
+	"vp8":        true,
+	"vp9":        true,
+	"av1":        true,
+	"mpeg4":      true,
+	"mpeg2video": true,
+	"prores":     true,
+	"theora":     true,
+}
+
+// FFprobe reads media metadata by shelling out to a local ffprobe binary
+type FFprobe struct {
+	binary string
+}
+
+// NewFFprobe creates a Prober using the ffprobe binary at path, or the one on $PATH when empty
+func NewFFprobe(path string) *FFprobe {
+	if path == "" {
+		path = defaultFFprobeBinary
+	}
+	return &FFprobe{binary: path}
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		Disposition  struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+		BitRate    string `json:"bit_rate"`
+	} `json:"format"`
+}
+
+// Probe reads the container and first video and audio streams of input
+func (f *FFprobe) Probe(ctx context.Context, input string) (*MediaInfo, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, f.binary,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		input,
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMedia, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return nil, err
+	}
+
+	var out ffprobeOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMedia, err)
+	}
+
+	container, ok := supportedContainers[out.Format.FormatName]
+	if !ok {
+		return nil, fmt.Errorf("%w: container %q", ErrUnsupportedMedia, out.Format.FormatName)
+	}
+
+	info := &MediaInfo{Container: container}
+	for _, stream := range out.Streams {
+		switch stream.CodecType {
+		case "video":
+			// cover art is reported as a video stream
+			if info.VideoCodec != "" || stream.Disposition.AttachedPic == 1 {
+				continue
+			}
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+			info.FrameRate = parseFrameRate(stream.AvgFrameRate)
+		case "audio":
+			if info.AudioCodec == "" {
+				info.AudioCodec = stream.CodecName
+			}
+		}
+	}
+	if info.VideoCodec == "" {
+		return nil, fmt.Errorf("%w: no video stream", ErrUnsupportedMedia)
+	}
+	if !supportedVideoCodecs[info.VideoCodec] {
+		return nil, fmt.Errorf("%w: video codec %q", ErrUnsupportedMedia, info.VideoCodec)
+	}
+
+	info.Duration, _ = strconv.ParseFloat(out.Format.Duration, 64)
+	info.Bitrate, _ = strconv.Atoi(out.Format.BitRate)
+	if info.Duration <= 0 || info.Width <= 0 || info.Height <= 0 {
+		return nil, fmt.Errorf("%w: missing duration or dimensions", ErrInvalidMedia)
+	}
+	return info, nil
+}
+
+// parseFrameRate turns an ffprobe rational such as "30000/1001" into frames per second
+func parseFrameRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		fps, _ := strconv.ParseFloat(rate, 64)
+		return fps
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0
+	}
+	d, err := strconv.ParseFloat(den, 64)
+	if err != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
@@ -0,0 +1,186 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/repository"
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	defaultWorkers      = 2
+	defaultPollInterval = 5 * time.Second
+	// workers renew the lock of their job every heartbeatInterval, jobs whose lock was not renewed
+	// for staleAfter are assumed to belong to a dead worker and are requeued every reapInterval
+	heartbeatInterval = time.Minute
+	staleAfter        = 10 * time.Minute
+	reapInterval      = time.Minute
+	baseBackoff       = 30 * time.Second
+)
+
+// HandlerFunc processes one job. Returning an error schedules a retry until the job runs out of attempts
+type HandlerFunc func(ctx context.Context, job *models.Job) error
+
+// Pool runs queued jobs on a fixed number of workers
+type Pool struct {
+	logger       zerolog.Logger
+	jobs         repository.JobRepo
+	handlers     map[string]HandlerFunc
+	workers      int
+	pollInterval time.Duration
+}
+
+// NewPool creates a worker pool reading from the persisted job queue
+func NewPool(z zerolog.Logger, jobs repository.JobRepo, workers int) *Pool {
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	return &Pool{
+		logger:       z.With().Str("PACKAGE", "transcode").Logger(),
+		jobs:         jobs,
+		handlers:     map[string]HandlerFunc{},
+		workers:      workers,
+		pollInterval: defaultPollInterval,
+	}
+}
+
+// Handle registers the handler of a job type. It must be called before Run
+func (p *Pool) Handle(jobType string, handler HandlerFunc) {
+	p.handlers[jobType] = handler
+}
+
+// Run processes jobs until ctx is cancelled
+func (p *Pool) Run(ctx context.Context) {
+	jobTypes := make([]string, 0, len(p.handlers))
+	for jobType := range p.handlers {
+		jobTypes = append(jobTypes, jobType)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.reap(ctx)
+	}()
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			p.work(ctx, worker, jobTypes)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// reap requeues the jobs of dead workers, on start and then every reapInterval until ctx is cancelled
+func (p *Pool) reap(ctx context.Context) {
+	ticker := time.NewTicker(reapInterval)
+	defer ticker.Stop()
+
+	for {
+		released, err := p.jobs.ReleaseStale(ctx, time.Now().Add(-staleAfter))
+		if err != nil {
+			p.logger.Err(err).Msg("unable to release stale jobs")
+		} else if released > 0 {
+			p.logger.Warn().Int64("released", released).Msg("requeued jobs left running by a dead worker")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pool) work(ctx context.Context, worker int, jobTypes []string) {
+	log := p.logger.With().Int("worker", worker).Logger()
+	for {
+		job, err := p.jobs.ClaimNext(ctx, jobTypes)
+		if err != nil {
+			if !errors.Is(err, helpers.ErrRecordNotFound) {
+				log.Err(err).Msg("unable to claim job")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(p.pollInterval):
+			}
+			continue
+		}
+
+		p.process(ctx, log, job)
+	}
+}
+
+func (p *Pool) process(ctx context.Context, log zerolog.Logger, job *models.Job) {
+	log = log.With().Str("job", job.ID.String()).Str("type", job.Type).Str("video", job.VideoID.String()).Logger()
+	log.Info().Int("attempt", job.Attempts).Msg("job started")
+
+	stopHeartbeat := p.heartbeat(ctx, log, job)
+	err := p.run(ctx, job)
+	stopHeartbeat()
+	if err == nil {
+		if err := p.jobs.Complete(ctx, job.ID); err != nil {
+			log.Err(err).Msg("unable to mark job as done")
+		}
+		log.Info().Msg("job done")
+		return
+	}
+
+	if job.Attempts >= job.MaxAttempts {
+		log.Err(err).Msg("job failed permanently")
+		if err := p.jobs.Fail(ctx, job.ID, err.Error()); err != nil {
+			log.Err(err).Msg("unable to mark job as failed")
+		}
+		return
+	}
+
+	runAt := time.Now().Add(baseBackoff * time.Duration(job.Attempts*job.Attempts))
+	log.Warn().Err(err).Time("retryAt", runAt).Msg("job failed, retrying")
+	if err := p.jobs.Retry(ctx, job.ID, err.Error(), runAt); err != nil {
+		log.Err(err).Msg("unable to requeue job")
+	}
+}
+
+// heartbeat renews the lock of job every heartbeatInterval until the returned func is called
+func (p *Pool) heartbeat(ctx context.Context, log zerolog.Logger, job *models.Job) func() {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := p.jobs.Heartbeat(ctx, job.ID); err != nil {
+					log.Err(err).Msg("unable to renew job lock")
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// run calls the job handler, turning a panic into an ordinary job failure
+func (p *Pool) run(ctx context.Context, job *models.Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return p.handlers[job.Type](ctx, job)
+}
@@ -0,0 +1,32 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrInvalidMedia occurs when a file cannot be decoded as media at all, e.g. it is truncated or corrupt
+	ErrInvalidMedia = errors.New("invalid media file")
+
+	// ErrUnsupportedMedia occurs when a file decodes but uses a container or codec we do not accept
+	ErrUnsupportedMedia = errors.New("unsupported media file")
+)
+
+// MediaInfo is the technical metadata of a media file
+type MediaInfo struct {
+	Duration   float64 // seconds
+	Width      int
+	Height     int
+	FrameRate  float64 // frames per second
+	VideoCodec string
+	AudioCodec string // empty when there is no audio track
+	Bitrate    int    // bits per second, over all tracks
+	Container  string
+}
+
+// Prober reads the technical metadata of a media file. It returns ErrInvalidMedia when the file
+// cannot be read and ErrUnsupportedMedia when it is not a video we can process.
+type Prober interface {
+	Probe(ctx context.Context, input string) (*MediaInfo, error)
+}
@@ -0,0 +1,140 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTranscodeFailed occurs when the encoder could not produce a rendition
+var ErrTranscodeFailed = errors.New("transcode failed")
+
+const (
+	// KeyframeInterval is the fixed GOP length of every rendition, so segments line up across renditions
+	KeyframeInterval = 2 * time.Second
+	// SegmentDuration is the target length of a packaged segment, a multiple of KeyframeInterval
+	SegmentDuration = 6 * time.Second
+
+	// AudioCodec is the RFC 6381 codec string of the AAC-LC audio we encode
+	AudioCodec = "mp4a.40.2"
+)
+
+// Profile describes a target rendition
+type Profile struct {
+	Name         string
+	Width        int
+	Height       int
+	VideoBitrate int    // bits per second
+	AudioBitrate int    // bits per second
+	Level        string // H.264 level passed to the encoder
+	VideoCodec   string // RFC 6381 codec string matching the profile and level
+}
+
+// DefaultProfiles are the renditions produced for every upload
+var DefaultProfiles = []Profile{
+	{Name: "240p", Width: 426, Height: 240, VideoBitrate: 400_000, AudioBitrate: 64_000, Level: "3.0", VideoCodec: "avc1.4d401e"},
+	{Name: "480p", Width: 854, Height: 480, VideoBitrate: 1_000_000, AudioBitrate: 96_000, Level: "3.0", VideoCodec: "avc1.4d401e"},
+	{Name: "720p", Width: 1280, Height: 720, VideoBitrate: 2_500_000, AudioBitrate: 128_000, Level: "3.1", VideoCodec: "avc1.4d401f"},
+}
+
+// ProfilesFor picks the profiles no taller than the source, so nothing is upscaled. A source smaller
+// than every profile gets the smallest profile at its own height.
+func ProfilesFor(sourceHeight int, profiles []Profile) []Profile {
+	picked := make([]Profile, 0, len(profiles))
+	for _, profile := range profiles {
+		if profile.Height <= sourceHeight {
+			picked = append(picked, profile)
+		}
+	}
+	if len(picked) > 0 || len(profiles) == 0 || sourceHeight <= 0 {
+		return picked
+	}
+
+	smallest := profiles[0]
+	for _, profile := range profiles[1:] {
+		if profile.Height < smallest.Height {
+			smallest = profile
+		}
+	}
+	// the encoder needs even dimensions
+	smallest.Height = max(2, sourceHeight&^1)
+	smallest.Width = 0
+	smallest.Name = fmt.Sprintf("%dp", smallest.Height)
+	return []Profile{smallest}
+}
+
+// PeakBitrate is the maximum bitrate the encoder is allowed to reach for the profile
+func (p Profile) PeakBitrate() int {
+	return p.VideoBitrate * 3 / 2
+}
+
+// Output is a rendition written to the local filesystem
+type Output struct {
+	Profile  Profile
+	Path     string
+	MimeType string
+}
+
+// Transcoder turns a source media file into one output per profile, written below outputDir
+type Transcoder interface {
+	Transcode(ctx context.Context, input, outputDir string, profiles []Profile) ([]Output, error)
+}
+
+const (
+	TrackVideo = "video"
+	TrackAudio = "audio"
+)
+
+// Segments is a track split into fragmented MP4 segments below Dir
+type Segments struct {
+	Dir       string
+	InitFile  string
+	Files     []string
+	Durations []float64 // seconds, one per file
+}
+
+// Segmenter splits a single track of a rendition into fMP4 segments written below outputDir.
+// It returns ErrNoTrack when the input has no track of the requested kind.
+type Segmenter interface {
+	Segment(ctx context.Context, input, outputDir, track string) (*Segments, error)
+}
+
+// ErrNoTrack occurs when the input to segment has no track of the requested kind
+var ErrNoTrack = errors.New("input has no such track")
+
+// ThumbnailPositions are the percentages of the duration candidate thumbnails are taken at
+var ThumbnailPositions = []int{10, 25, 50, 75, 90}
+
+// Thumbnailer extracts a JPEG still per offset of input, written below outputDir
+type Thumbnailer interface {
+	Thumbnails(ctx context.Context, input, outputDir string, offsets []time.Duration) ([]string, error)
+}
+
+// StoryboardSpec describes the sprite sheets used for seek previews
+type StoryboardSpec struct {
+	Interval   time.Duration // time between two tiles
+	TileWidth  int
+	TileHeight int
+	Columns    int
+	Rows       int
+}
+
+// DefaultStoryboardSpec is used when no interval is configured
+var DefaultStoryboardSpec = StoryboardSpec{
+	Interval:   10 * time.Second,
+	TileWidth:  160,
+	TileHeight: 90,
+	Columns:    10,
+	Rows:       10,
+}
+
+// TilesPerSheet is the number of tiles held by one sprite sheet
+func (s StoryboardSpec) TilesPerSheet() int {
+	return s.Columns * s.Rows
+}
+
+// Storyboarder renders sprite sheets of input below outputDir and returns them in order
+type Storyboarder interface {
+	Storyboard(ctx context.Context, input, outputDir string, spec StoryboardSpec) ([]string, error)
+}
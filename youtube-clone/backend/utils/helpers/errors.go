@@ -0,0 +1,84 @@
+package helpers
+
+import "errors"
+
+var (
+	// ErrRecordNotFound occurs when a record does not exist, or the caller may not see it
+	ErrRecordNotFound = errors.New("record not found")
+
+	// ErrRecordCreationFailed occurs when a record could not be inserted
+	ErrRecordCreationFailed = errors.New("record creation failed")
+
+	// ErrRecordUpdateFail occurs when a record could not be updated
+	ErrRecordUpdateFail = errors.New("record update failed")
+
+	// ErrDeleteFailed occurs when a record could not be deleted
+	ErrDeleteFailed = errors.New("record deletion failed")
+
+	// ErrEmptyResult occurs when a query that must return rows returned none
+	ErrEmptyResult = errors.New("empty result")
+
+	// ErrUnsupportedMediaType occurs when an uploaded file is not a video we can accept, including
+	// files that claim to be a video but cannot be decoded
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+	// ErrUploadFailed occurs when the uploaded bytes could not be written to the blob store
+	ErrUploadFailed = errors.New("unable to store uploaded file")
+
+	// ErrUploadOffsetMismatch occurs when a chunk does not start where the stored upload ends
+	ErrUploadOffsetMismatch = errors.New("upload offset does not match")
+
+	// ErrUploadTooLarge occurs when an upload exceeds the configured maximum size
+	ErrUploadTooLarge = errors.New("upload exceeds maximum size")
+
+	// ErrUploadExpired occurs when an unfinished upload is used after its expiry
+	ErrUploadExpired = errors.New("upload has expired")
+
+	// ErrUploadLocked occurs when another request is currently writing to the same upload
+	ErrUploadLocked = errors.New("upload is locked by another request")
+
+	// ErrVideoNotReady occurs when streaming is requested before processing has finished
+	ErrVideoNotReady = errors.New("video is still processing")
+
+	// ErrUnsupportedImageType occurs when a custom thumbnail is not a JPEG or PNG image
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+
+	// ErrInvalidPlaybackToken occurs when a signed playback URL was tampered with or is malformed
+	ErrInvalidPlaybackToken = errors.New("playback token is invalid")
+
+	// ErrPlaybackTokenExpired occurs when a signed playback URL is used after its expiry
+	ErrPlaybackTokenExpired = errors.New("playback token has expired")
+
+	// ErrInvalidChannelHandle occurs when a channel handle has characters other than letters, digits, '_', '-' and '.'
+	ErrInvalidChannelHandle = errors.New("invalid channel handle")
+
+	// ErrChannelHandleTaken occurs when another channel already uses the requested handle
+	ErrChannelHandleTaken = errors.New("channel handle is already taken")
+
+	// ErrSelfSubscription occurs when a user tries to subscribe to one of their own channels
+	ErrSelfSubscription = errors.New("cannot subscribe to own channel")
+
+	// ErrCommentsDisabled occurs when commenting on, or reading the comments of, a video whose owner disabled them
+	ErrCommentsDisabled = errors.New("comments are disabled for this video")
+
+	// ErrCommentNotTopLevel occurs when pinning a reply, only top-level comments can be pinned
+	ErrCommentNotTopLevel = errors.New("only top-level comments can be pinned")
+
+	// ErrViewTooShort occurs when a view is reported before enough of the video was played
+	ErrViewTooShort = errors.New("not enough of the video was watched")
+
+	// ErrWatchLaterPlaylist occurs when renaming, sharing or deleting the built-in watch later playlist
+	ErrWatchLaterPlaylist = errors.New("the watch later playlist cannot be changed")
+
+	// ErrPlaylistItemExists occurs when adding a video that is already in the playlist
+	ErrPlaylistItemExists = errors.New("video is already in the playlist")
+
+	// ErrInvalidRefreshToken occurs when a refresh token is unknown, expired or revoked
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid")
+
+	// ErrRefreshTokenReused occurs when an already rotated refresh token is presented again, its family is revoked
+	ErrRefreshTokenReused = errors.New("refresh token was already used")
+
+	// ErrForbidden occurs when a user acts on a resource they do not own
+	ErrForbidden = errors.New("action not allowed")
+)
@@ -2,6 +2,7 @@ package helpers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -11,6 +12,15 @@ import (
 	"github.com/go-playground/validator/v10"
 )
 
+const (
+	// LogStrRequestIDLevel is the log key of the request ID
+	LogStrRequestIDLevel = "REQUEST_ID"
+	// LogStrKeyMethod is the log key of the method writing the log
+	LogStrKeyMethod = "METHOD"
+	// ZeroUUID is the string form of uuid.Nil, used as request ID when none is set
+	ZeroUUID = "00000000-0000-0000-0000-000000000000"
+)
+
 // GinContextToContextMiddleware converts Gin context to standard context
 func GinContextToContextMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -96,7 +106,7 @@ func ValidateRequest(request interface{}) error {
 	err := validate.Struct(request)
 	if err != nil {
 		for _, fieldErr := range err.(validator.ValidationErrors) {
-			return fmt.Errorf(fieldError{fieldErr}.String())
+			return errors.New(fieldError{fieldErr}.String())
 		}
 	}
 
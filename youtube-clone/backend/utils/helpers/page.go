@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	PageDefaultNumber            = 1
+	PageDefaultSize              = 10
+	PageMaxSize                  = 100
+	PageDefaultSortBy            = "created_at"
+	PageDefaultSortDirectionDesc = true
+
+	PageSortDirectionAscending  = "asc"
+	PageSortDirectionDescending = "desc"
+)
+
+// Page is a pagination request, nil fields fall back to the PageDefault values in the repositories
+type Page struct {
+	Number            *int
+	Size              *int
+	SortBy            *string
+	SortDirectionDesc *bool
+}
+
+// PageInfo describes the page of results returned
+type PageInfo struct {
+	Page            int   `json:"page"`
+	Size            int   `json:"size"`
+	HasNextPage     bool  `json:"hasNextPage"`
+	HasPreviousPage bool  `json:"hasPreviousPage"`
+	TotalCount      int64 `json:"totalCount"`
+}
+
+// ParsePage reads the page, size, sort_by and sort_direction query parameters. sort_by is only
+// accepted when it is one of sortable, since it ends up in the ORDER BY clause.
+func ParsePage(c *gin.Context, sortable ...string) Page {
+	params := ParsePaginationParams(c)
+
+	var page Page
+	if params.Page >= 1 {
+		page.Number = &params.Page
+	}
+	if params.Size >= 1 {
+		size := min(params.Size, PageMaxSize)
+		page.Size = &size
+	}
+	if slices.Contains(sortable, params.SortBy) {
+		page.SortBy = &params.SortBy
+	}
+	switch params.SortDirection {
+	case PageSortDirectionAscending:
+		desc := false
+		page.SortDirectionDesc = &desc
+	case PageSortDirectionDescending:
+		desc := true
+		page.SortDirectionDesc = &desc
+	}
+	return page
+}
@@ -2,6 +2,9 @@ package helpers
 
 import "golang.org/x/crypto/bcrypt"
 
+// StarPassword replaces passwords in responses
+const StarPassword = "********"
+
 // Password represents a hashed password
 type Password string
 
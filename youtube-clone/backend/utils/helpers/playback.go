@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// playbackPayloadSize is the encoded size of a token: video ID, viewer ID and expiry as unix seconds
+const playbackPayloadSize = 16 + 16 + 8
+
+// PlaybackToken grants access to the media of a single video until it expires
+type PlaybackToken struct {
+	VideoID   uuid.UUID
+	ViewerID  uuid.UUID // uuid.Nil when the token is not bound to a viewer
+	ExpiresAt time.Time
+}
+
+// PlaybackKey derives the key playback tokens are signed with, so a secret shared with
+// JWT signing never signs both kinds of tokens with the same key
+func PlaybackKey(secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("playback-url"))
+	return mac.Sum(nil)
+}
+
+// Sign encodes the token as "<payload>.<signature>" in base64url, safe to use as a path segment
+func (t PlaybackToken) Sign(key []byte) string {
+	payload := make([]byte, 0, playbackPayloadSize)
+	payload = append(payload, t.VideoID[:]...)
+	payload = append(payload, t.ViewerID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(t.ExpiresAt.Unix()))
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(playbackSignature(key, payload))
+}
+
+// ParsePlaybackToken verifies the signature of a token created by Sign and that it has not expired at now
+func ParsePlaybackToken(key []byte, token string, now time.Time) (*PlaybackToken, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidPlaybackToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil || len(payload) != playbackPayloadSize {
+		return nil, ErrInvalidPlaybackToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, playbackSignature(key, payload)) {
+		return nil, ErrInvalidPlaybackToken
+	}
+
+	reader := bytes.NewReader(payload)
+	var parsed PlaybackToken
+	var expiresAt uint64
+	_, _ = reader.Read(parsed.VideoID[:])
+	_, _ = reader.Read(parsed.ViewerID[:])
+	_ = binary.Read(reader, binary.BigEndian, &expiresAt)
+	parsed.ExpiresAt = time.Unix(int64(expiresAt), 0)
+
+	if !now.Before(parsed.ExpiresAt) {
+		return nil, ErrPlaybackTokenExpired
+	}
+	return &parsed, nil
+}
+
+func playbackSignature(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParsePlaybackToken(t *testing.T) {
+	key := PlaybackKey("secret")
+	now := time.Unix(1_700_000_000, 0)
+	token := PlaybackToken{VideoID: uuid.New(), ViewerID: uuid.New(), ExpiresAt: now.Add(time.Hour)}
+	signed := token.Sign(key)
+	payload, signature, _ := strings.Cut(signed, ".")
+
+	// move the expiry of the signed payload
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw[len(raw)-1]++
+	tampered := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name    string
+		key     []byte
+		token   string
+		now     time.Time
+		want    *PlaybackToken
+		wantErr error
+	}{
+		{name: "valid", key: key, token: signed, now: now, want: &token},
+		{
+			name:  "not bound to a viewer",
+			key:   key,
+			token: PlaybackToken{VideoID: token.VideoID, ExpiresAt: token.ExpiresAt}.Sign(key),
+			now:   now,
+			want:  &PlaybackToken{VideoID: token.VideoID, ExpiresAt: token.ExpiresAt},
+		},
+		{name: "expired", key: key, token: signed, now: token.ExpiresAt, wantErr: ErrPlaybackTokenExpired},
+		{name: "other key", key: PlaybackKey("other"), token: signed, now: now, wantErr: ErrInvalidPlaybackToken},
+		{name: "JWT secret as key", key: []byte("secret"), token: signed, now: now, wantErr: ErrInvalidPlaybackToken},
+		{name: "tampered payload", key: key, token: tampered + "." + signature, now: now, wantErr: ErrInvalidPlaybackToken},
+		{name: "missing signature", key: key, token: payload, now: now, wantErr: ErrInvalidPlaybackToken},
+		{name: "short payload", key: key, token: payload[:10] + "." + signature, now: now, wantErr: ErrInvalidPlaybackToken},
+		{name: "not base64", key: key, token: "!!!." + signature, now: now, wantErr: ErrInvalidPlaybackToken},
+		{name: "empty", key: key, token: "", now: now, wantErr: ErrInvalidPlaybackToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlaybackToken(tt.key, tt.token, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParsePlaybackToken() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.want == nil {
+				return
+			}
+			if got.VideoID != tt.want.VideoID || got.ViewerID != tt.want.ViewerID || !got.ExpiresAt.Equal(tt.want.ExpiresAt) {
+				t.Errorf("ParsePlaybackToken() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const (
+	accessTokenCookie  = "access_token"
+	refreshTokenCookie = "refresh_token"
+	csrfCookie         = "csrf_token"
+
+	// CSRFHeader has to repeat the csrf_token cookie on state-changing requests authenticated by cookie
+	CSRFHeader = "X-CSRF-Token"
+
+	// CookieOnlyHeader lets a browser in cookie mode ask for the tokens of a login or signup as cookies
+	// only, leaving them out of the response body
+	CookieOnlyHeader = "X-Auth-Cookie-Only"
+
+	// refreshCookiePath keeps the refresh token cookie from being sent anywhere but to the user
+	// endpoints, which refresh the tokens and log out with it
+	refreshCookiePath = "/api/user"
+)
+
+// NewJwtConfig reads the cookie settings, cookies are only set when AUTH_COOKIE_MODE is enabled
+func NewJwtConfig(env *models.Env) *JwtConfig {
+	cookieMode, _ := strconv.ParseBool(env.AuthCookieMode)
+	secure, err := strconv.ParseBool(env.AuthCookieSecure)
+	if err != nil {
+		secure = true
+	}
+	return &JwtConfig{
+		CookieMode:     cookieMode,
+		CookieDomain:   env.AuthCookieDomain,
+		SecureCookie:   secure,
+		CookieHTTPOnly: true,
+	}
+}
+
+// CookieMode tells whether tokens are handed out and accepted as cookies
+func (m *Middleware) CookieMode() bool {
+	return m.jwt.CookieMode
+}
+
+// SetAuthCookies hands the tokens to a browser as cookies along with a new CSRF token, the tokens
+// cookies cannot be read by scripts while the CSRF token can. It does nothing unless cookie mode is on.
+func (m *Middleware) SetAuthCookies(c *gin.Context, tokens *Tokens) error {
+	if !m.CookieMode() {
+		return nil
+	}
+	csrfToken, err := newCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	accessMaxAge := int(tokens.AccessExpiresAt.Sub(now).Seconds())
+	refreshMaxAge := int(tokens.RefreshExpiresAt.Sub(now).Seconds())
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(accessTokenCookie, tokens.AccessToken, accessMaxAge, "/", m.jwt.CookieDomain, m.jwt.SecureCookie, m.jwt.CookieHTTPOnly)
+	c.SetCookie(refreshTokenCookie, tokens.RefreshToken, refreshMaxAge, refreshCookiePath, m.jwt.CookieDomain, m.jwt.SecureCookie, m.jwt.CookieHTTPOnly)
+	c.SetCookie(csrfCookie, csrfToken, refreshMaxAge, "/", m.jwt.CookieDomain, m.jwt.SecureCookie, false)
+	return nil
+}
+
+// ClearAuthCookies removes the cookies set by SetAuthCookies
+func (m *Middleware) ClearAuthCookies(c *gin.Context) {
+	if !m.CookieMode() {
+		return
+	}
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(accessTokenCookie, "", -1, "/", m.jwt.CookieDomain, m.jwt.SecureCookie, m.jwt.CookieHTTPOnly)
+	c.SetCookie(refreshTokenCookie, "", -1, refreshCookiePath, m.jwt.CookieDomain, m.jwt.SecureCookie, m.jwt.CookieHTTPOnly)
+	c.SetCookie(csrfCookie, "", -1, "/", m.jwt.CookieDomain, m.jwt.SecureCookie, false)
+}
+
+// RefreshTokenFromCookie returns the refresh token cookie, ok is false when cookie mode is off or
+// the request carries none. The caller has to check the CSRF token with ValidCSRF.
+func (m *Middleware) RefreshTokenFromCookie(c *gin.Context) (token string, ok bool) {
+	return m.cookieToken(c, refreshTokenCookie)
+}
+
+// accessTokenFromCookie returns the access token cookie, it is only looked at without an Authorization header
+func (m *Middleware) accessTokenFromCookie(c *gin.Context) (token string, ok bool) {
+	return m.cookieToken(c, accessTokenCookie)
+}
+
+func (m *Middleware) cookieToken(c *gin.Context, name string) (string, bool) {
+	if !m.CookieMode() {
+		return "", false
+	}
+	token, err := c.Cookie(name)
+	if err != nil || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// CookieOnly tells whether a request in cookie mode opted in to get its tokens as cookies only
+func (m *Middleware) CookieOnly(c *gin.Context) bool {
+	cookieOnly, _ := strconv.ParseBool(c.GetHeader(CookieOnlyHeader))
+	return m.CookieMode() && cookieOnly
+}
+
+// ValidOrigin guards login and signup against login CSRF in cookie mode, where another site could
+// otherwise sign a browser in to an account of its choosing. Browsers name the page a request comes
+// from in the Origin header, it has to be the API's own host or a host sharing the auth cookies.
+// Requests without one do not come from a page and pass.
+func (m *Middleware) ValidOrigin(c *gin.Context) bool {
+	origin := c.GetHeader("Origin")
+	if !m.CookieMode() || origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	if strings.EqualFold(u.Host, c.Request.Host) {
+		return true
+	}
+
+	domain := strings.ToLower(strings.TrimPrefix(m.jwt.CookieDomain, "."))
+	host := strings.ToLower(u.Hostname())
+	return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+}
+
+// ValidCSRF checks the double-submit CSRF token of a request authenticated by cookie. Another site can
+// make the browser send our cookies but cannot read them, so it cannot repeat the token in CSRFHeader.
+// Safe methods change nothing and need no token.
+func ValidCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := c.Cookie(csrfCookie)
+	header := c.GetHeader(CSRFHeader)
+	if err != nil || cookie == "" || header == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) == 1
+}
+
+func newCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,115 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidCSRF(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		method string
+		cookie string // csrf_token cookie, none when empty
+		header string // CSRFHeader, none when empty
+		want   bool
+	}{
+		{name: "safe method without token", method: http.MethodGet, want: true},
+		{name: "head without token", method: http.MethodHead, want: true},
+		{name: "matching token", method: http.MethodPost, cookie: "token", header: "token", want: true},
+		{name: "missing header", method: http.MethodPost, cookie: "token"},
+		{name: "missing cookie", method: http.MethodDelete, header: "token"},
+		{name: "missing both", method: http.MethodPut},
+		{name: "mismatched header", method: http.MethodPatch, cookie: "token", header: "other"},
+		{name: "header of another length", method: http.MethodPost, cookie: "token", header: "token2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(tt.method, "/", nil)
+			if tt.cookie != "" {
+				c.Request.AddCookie(&http.Cookie{Name: csrfCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				c.Request.Header.Set(CSRFHeader, tt.header)
+			}
+
+			if got := ValidCSRF(c); got != tt.want {
+				t.Errorf("ValidCSRF() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		cookieMode   bool
+		cookieDomain string
+		origin       string // Origin header, none when empty
+		want         bool
+	}{
+		{name: "cookie mode off", origin: "https://evil.example", want: true},
+		{name: "no origin", cookieMode: true, want: true},
+		{name: "same host", cookieMode: true, origin: "https://api.example.com", want: true},
+		{name: "other site", cookieMode: true, origin: "https://evil.example"},
+		{name: "opaque origin", cookieMode: true, origin: "null"},
+		{name: "same host on another port", cookieMode: true, origin: "https://api.example.com:8443"},
+		{name: "host of the cookie domain", cookieMode: true, cookieDomain: ".example.com", origin: "https://app.example.com", want: true},
+		{name: "cookie domain itself", cookieMode: true, cookieDomain: "example.com", origin: "https://example.com", want: true},
+		{name: "lookalike of the cookie domain", cookieMode: true, cookieDomain: "example.com", origin: "https://evilexample.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{jwt: &JwtConfig{CookieMode: tt.cookieMode, CookieDomain: tt.cookieDomain}}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "https://api.example.com/api/user/login", nil)
+			if tt.origin != "" {
+				c.Request.Header.Set("Origin", tt.origin)
+			}
+
+			if got := m.ValidOrigin(c); got != tt.want {
+				t.Errorf("ValidOrigin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCookieOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		cookieMode bool
+		header     string // CookieOnlyHeader, none when empty
+		want       bool
+	}{
+		{name: "cookie mode off", header: "true"},
+		{name: "opted in", cookieMode: true, header: "true", want: true},
+		{name: "opted out", cookieMode: true, header: "false"},
+		{name: "no header", cookieMode: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Middleware{jwt: &JwtConfig{CookieMode: tt.cookieMode}}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set(CookieOnlyHeader, tt.header)
+			}
+
+			if got := m.CookieOnly(c); got != tt.want {
+				t.Errorf("CookieOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,10 @@ package middlewares
 
 import (
 	"errors"
-	"fmt"
-	"strconv"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
 	"github.com/joshua468/youtube-clone/backend/utils/models"
@@ -19,6 +18,12 @@ const (
 	isAdminClaims   = "is_admin"
 	claimsExpiry    = "exp"
 	claimsCreatedAt = "orig_iat"
+	claimsTokenID   = "jti"
+	claimsFamilyID  = "fid"
+	claimsTokenType = "typ"
+
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
 )
 
 var (
@@ -36,20 +41,24 @@ var (
 
 	// ErrInvalidTokenHeaderFormat e.g when client passes the token without the Bearer prefix
 	ErrInvalidTokenHeaderFormat = errors.New("invalid token header format, token type must be bearer")
+
+	// ErrInvalidCSRFToken occurs when a request authenticated by cookie does not repeat the CSRF token
+	ErrInvalidCSRFToken = errors.New("csrf token is missing or invalid")
 )
 
 type Middleware struct {
-	jwt  *JwtConfig
-	pKey interface{} // Public key for asymmetric signing algorithm
-	sKey interface{} // Secret key for symmetric signing algorithm
+	env    models.Env
+	app    models.App
+	logger zerolog.Logger
+	jwt    *JwtConfig
+	keys   *KeySet // Keys tokens are signed and verified with, selected by kid
 }
 
 type JwtConfig struct {
-	SigningAlgorithm string // Algorithm used for signing (e.g., HS256, RS256)
-	Key              []byte // Secret key for symmetric signing algorithm
-	CookieDomain     string // Domain for setting cookies
-	SecureCookie     bool   // Whether cookies are secure
-	CookieHTTPOnly   bool   // Whether cookies are HTTP-only
+	CookieMode     bool   // Whether tokens are also handed out and accepted as cookies
+	CookieDomain   string // Domain for setting cookies
+	SecureCookie   bool   // Whether cookies are secure
+	CookieHTTPOnly bool   // Whether cookies are HTTP-only
 }
 
 type Tokens struct {
@@ -57,46 +66,69 @@ type Tokens struct {
 	RefreshToken       string
 	AccessTokenExpiry  string
 	RefreshTokenExpiry string
+
+	// the access token can be revoked under AccessTokenID until AccessExpiresAt, see app.Logout
+	AccessTokenID   uuid.UUID `json:"-"`
+	AccessExpiresAt time.Time `json:"-"`
+
+	// the refresh token has to be stored server side under these, see app.SaveRefreshToken
+	RefreshTokenID   uuid.UUID `json:"-"`
+	RefreshFamilyID  uuid.UUID `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
 }
 
-func jwtAccessTokenExpiry(env *models.Env) time.Duration {
-	ttl, err := strconv.Atoi(env.JWTAccessTokenExpiry)
-	if err != nil {
-		return time.Minute * 10000
-	}
-	return time.Minute * time.Duration(ttl)
+// AccessClaims identify an access token, the token family of the login it descends from and its user.
+// Tokens issued before token IDs were introduced have a zero TokenID and FamilyID.
+type AccessClaims struct {
+	UserID    string
+	IsAdmin   bool
+	TokenID   uuid.UUID
+	FamilyID  uuid.UUID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
 }
 
-func jwtRefreshTokenExpiry(env *models.Env) time.Duration {
-	ttl, err := strconv.Atoi(env.JWTRefreshTokenExpiry)
-	if err != nil {
-		return time.Hour * 240000
-	}
-	return time.Hour * time.Duration(ttl)
+// RefreshClaims identify a refresh token, the token family it belongs to and its user
+type RefreshClaims struct {
+	UserID   uuid.UUID
+	TokenID  uuid.UUID
+	FamilyID uuid.UUID
+	IsAdmin  bool
 }
 
-// CreateToken creates a new user access and refresh tokens
-func (m *Middleware) CreateToken(env *models.Env, userID string, isAdmin bool) (*Tokens, error) {
-	accessToken := jwt.NewWithClaims(jwt.GetSigningMethod(m.jwt.SigningAlgorithm), jwt.MapClaims{
+// CreateToken creates a new user access and refresh tokens. The refresh token continues the token
+// family familyID, uuid.Nil starts a new family as on login.
+func (m *Middleware) CreateToken(env *models.Env, userID string, isAdmin bool, familyID uuid.UUID) (*Tokens, error) {
+	if familyID == uuid.Nil {
+		familyID = uuid.New()
+	}
+
+	accessTokenID := uuid.New()
+	accessExpiresAt := time.Now().Add(env.AccessTokenExpiry())
+	accessTokenString, err := m.keys.Sign(jwt.MapClaims{
 		claimsID:        userID,
-		claimsExpiry:    time.Now().Add(jwtAccessTokenExpiry(env)).Unix(),
+		claimsExpiry:    accessExpiresAt.Unix(),
 		claimsCreatedAt: time.Now().Unix(),
 		isAdminClaims:   isAdmin,
+		claimsTokenID:   accessTokenID.String(),
+		claimsFamilyID:  familyID.String(),
+		claimsTokenType: tokenTypeAccess,
 	})
-
-	accessTokenString, err := accessToken.SignedString(m.jwt.Key)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken := jwt.NewWithClaims(jwt.GetSigningMethod(m.jwt.SigningAlgorithm), jwt.MapClaims{
+	refreshTokenID := uuid.New()
+	refreshExpiresAt := time.Now().Add(env.RefreshTokenExpiry())
+	refreshTokenString, err := m.keys.Sign(jwt.MapClaims{
 		claimsID:        userID,
-		claimsExpiry:    time.Now().Add(jwtRefreshTokenExpiry(env)).Unix(),
+		claimsExpiry:    refreshExpiresAt.Unix(),
 		claimsCreatedAt: time.Now().Unix(),
 		isAdminClaims:   isAdmin,
+		claimsTokenID:   refreshTokenID.String(),
+		claimsFamilyID:  familyID.String(),
+		claimsTokenType: tokenTypeRefresh,
 	})
-
-	refreshTokenString, err := refreshToken.SignedString(m.jwt.Key)
 	if err != nil {
 		return nil, err
 	}
@@ -104,21 +136,25 @@ func (m *Middleware) CreateToken(env *models.Env, userID string, isAdmin bool) (
 	return &Tokens{
 		AccessToken:        accessTokenString,
 		RefreshToken:       refreshTokenString,
-		AccessTokenExpiry:  time.Now().Add(jwtAccessTokenExpiry(env)).String(),
-		RefreshTokenExpiry: time.Now().Add(jwtRefreshTokenExpiry(env)).String(),
+		AccessTokenExpiry:  accessExpiresAt.String(),
+		RefreshTokenExpiry: refreshExpiresAt.String(),
+		AccessTokenID:      accessTokenID,
+		AccessExpiresAt:    accessExpiresAt,
+		RefreshTokenID:     refreshTokenID,
+		RefreshFamilyID:    familyID,
+		RefreshExpiresAt:   refreshExpiresAt,
 	}, nil
 }
 
-// ValidateRefreshToken validates the refresh token
-func (m *Middleware) ValidateRefreshToken(z zerolog.Logger, env *models.Env, token string) (*uuid.UUID, error) {
-	tokenGotten, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		//Make sure that the token method conform to "SigningMethodHMAC"
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			z.Error().Msgf("RefreshToken unexpected signing method: (%v)", token.Header["alg"])
-			return nil, ErrUnexpectedSigningMethod
-		}
-		return m.jwt.Key, nil
-	})
+// JWKS lists the public keys tokens are verified with, see KeySet.JWKS
+func (m *Middleware) JWKS() JSONWebKeySet {
+	return m.keys.JWKS()
+}
+
+// ValidateRefreshToken validates the signature and expiry of a refresh token. Whether the token was
+// already used or revoked is only known server side, see app.RotateRefreshToken.
+func (m *Middleware) ValidateRefreshToken(z zerolog.Logger, env *models.Env, token string) (*RefreshClaims, error) {
+	tokenGotten, err := jwt.Parse(token, m.keys.Keyfunc)
 
 	//any error may be due to token expiration
 	if err != nil {
@@ -133,45 +169,77 @@ func (m *Middleware) ValidateRefreshToken(z zerolog.Logger, env *models.Env, tok
 	}
 
 	claims, ok := tokenGotten.Claims.(jwt.MapClaims)
-	claimsUUID := claims[claimsID].(string)
+	if !ok || !tokenGotten.Valid || claims[claimsTokenType] != tokenTypeRefresh {
+		return nil, ErrInvalidToken
+	}
 
-	if ok && tokenGotten.Valid {
-		//convert the interface to uuid.UUID
-		parsedUUID, err := uuid.Parse(claimsUUID)
-		if err != nil {
+	//convert the interfaces to uuid.UUID
+	var refreshClaims RefreshClaims
+	for claim, parsed := range map[string]*uuid.UUID{
+		claimsID:       &refreshClaims.UserID,
+		claimsTokenID:  &refreshClaims.TokenID,
+		claimsFamilyID: &refreshClaims.FamilyID,
+	} {
+		value, _ := claims[claim].(string)
+		if *parsed, err = uuid.Parse(value); err != nil {
 			z.Err(err).Msgf("RefreshToken error::(%v)", err)
 			return nil, ErrInvalidToken
 		}
-
-		return &parsedUUID, nil
 	}
+	refreshClaims.IsAdmin, _ = claims[isAdminClaims].(bool)
 
-	return nil, ErrInvalidToken
+	return &refreshClaims, nil
 }
 
 // ParseToken checks if token is valid and parses it
 func (m *Middleware) ParseToken(env *models.Env, tokenStr string) (userID string, isAdmin bool, err error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return m.jwt.Key, nil
-	})
+	claims, err := m.ParseAccessClaims(env, tokenStr)
+	if err != nil {
+		return userID, isAdmin, err
+	}
+	return claims.UserID, claims.IsAdmin, nil
+}
+
+// ParseAccessClaims checks if an access token is valid and returns its claims. Whether the token
+// was revoked is only known server side, see app.IsTokenRevoked.
+func (m *Middleware) ParseAccessClaims(env *models.Env, tokenStr string) (*AccessClaims, error) {
+	token, err := jwt.Parse(tokenStr, m.keys.Keyfunc)
 
 	if token == nil {
 		m.logger.Error().Str("token", tokenStr).Msg("unable to parse token - token is most likely not valid")
-		return userID, isAdmin, ErrInvalidToken
+		return nil, ErrInvalidToken
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID = claims[claimsID].(string)
-
-		if found, ok := claims[isAdminClaims]; ok {
-			isAdmin = found.(bool)
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		if err == nil {
+			err = ErrInvalidToken
 		}
-
-		return userID, isAdmin, nil
+		return nil, err
+	}
+	// a refresh token must never pass as an access token
+	if claims[claimsTokenType] == tokenTypeRefresh {
+		return nil, ErrInvalidToken
 	}
 
-	return userID, isAdmin, err
+	var accessClaims AccessClaims
+	accessClaims.UserID, _ = claims[claimsID].(string)
+	accessClaims.IsAdmin, _ = claims[isAdminClaims].(bool)
+	if issuedAt, ok := claims[claimsCreatedAt].(float64); ok {
+		accessClaims.IssuedAt = time.Unix(int64(issuedAt), 0)
+	}
+	if expiresAt, ok := claims[claimsExpiry].(float64); ok {
+		accessClaims.ExpiresAt = time.Unix(int64(expiresAt), 0)
+	}
+	if tokenID, ok := claims[claimsTokenID].(string); ok {
+		if accessClaims.TokenID, err = uuid.Parse(tokenID); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+	if familyID, ok := claims[claimsFamilyID].(string); ok {
+		if accessClaims.FamilyID, err = uuid.Parse(familyID); err != nil {
+			return nil, ErrInvalidToken
+		}
+	}
+	return &accessClaims, nil
 }
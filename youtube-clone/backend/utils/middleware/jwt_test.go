@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// TestTokenTypes makes sure access and refresh tokens are only accepted where they belong
+func TestTokenTypes(t *testing.T) {
+	env := models.Env{JWTSigningSecret: "secret"}
+	ks, err := LoadKeySet(&env)
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	m := NewMiddleware(env, nil, ks)
+
+	userID, familyID := uuid.New(), uuid.New()
+	tokens, err := m.CreateToken(&env, userID.String(), true, familyID)
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		asRefresh bool // validated as a refresh token rather than parsed as an access token
+		wantErr   bool
+	}{
+		{name: "access token as access token", token: tokens.AccessToken},
+		{name: "refresh token as access token", token: tokens.RefreshToken, wantErr: true},
+		{name: "refresh token as refresh token", token: tokens.RefreshToken, asRefresh: true},
+		{name: "access token as refresh token", token: tokens.AccessToken, asRefresh: true, wantErr: true},
+		{name: "garbage as access token", token: "not.a.token", wantErr: true},
+		{name: "garbage as refresh token", token: "not.a.token", asRefresh: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.asRefresh {
+				claims, err := m.ValidateRefreshToken(zerolog.Nop(), &env, tt.token)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("ValidateRefreshToken() error = %v, want error %v", err, tt.wantErr)
+				}
+				if err == nil && (claims.UserID != userID || !claims.IsAdmin ||
+					claims.TokenID != tokens.RefreshTokenID || claims.FamilyID != familyID) {
+					t.Errorf("ValidateRefreshToken() = %+v", claims)
+				}
+				return
+			}
+
+			claims, err := m.ParseAccessClaims(&env, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAccessClaims() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && (claims.UserID != userID.String() || !claims.IsAdmin ||
+				claims.TokenID != tokens.AccessTokenID || claims.FamilyID != familyID) {
+				t.Errorf("ParseAccessClaims() = %+v", claims)
+			}
+		})
+	}
+}
@@ -0,0 +1,398 @@
+package middlewares
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/rs/zerolog"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+const claimsKeyID = "kid"
+
+var (
+	// ErrNoSigningKey occurs when no configured key is active, e.g. when every key is retired
+	ErrNoSigningKey = errors.New("no active signing key")
+
+	// ErrUnknownKey occurs when a token names a key that is not configured or already retired
+	ErrUnknownKey = errors.New("token signed with an unknown key")
+
+	// ErrNoSecretRetireAt occurs when a key file replaces the JWT secret without saying when the secret retires
+	ErrNoSecretRetireAt = errors.New("JWT_SIGNING_SECRET_RETIRE_AT is required with JWT_SIGNING_KEYS_FILE")
+)
+
+// SigningMethodEdDSA signs tokens with Ed25519 keys, jwt-go has no EdDSA support of its own
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+type signingMethodEdDSA struct{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+func (m *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (m *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
+
+// SigningKey is a key tokens are signed with, identified in their header by its kid. It signs from
+// ActiveFrom on until a newer key becomes active, and verifies tokens until RetireAt.
+type SigningKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	ActiveFrom time.Time
+	RetireAt   time.Time // zero when the key is never retired
+
+	signKey   interface{}
+	verifyKey interface{}
+	publicKey crypto.PublicKey // nil for symmetric keys, which are never published
+}
+
+func (k *SigningKey) retired(now time.Time) bool {
+	return !k.RetireAt.IsZero() && !now.Before(k.RetireAt)
+}
+
+// KeySet holds the keys tokens are signed and verified with. Keys are rotated by scheduling a new key
+// in the key file ahead of time: it is published right away, signs from its activation on, and the
+// keys it replaces keep verifying the tokens they signed until they are retired.
+type KeySet struct {
+	path string // key file, empty when signing with the JWT secret only
+
+	mu     sync.RWMutex
+	keys   []*SigningKey // newest activation first
+	byID   map[string]*SigningKey
+	legacy *SigningKey // JWT secret, verifies tokens without a kid
+}
+
+// keyConfig is an entry of the key file set in JWT_SIGNING_KEYS_FILE
+type keyConfig struct {
+	ID             string     `json:"kid"`
+	Algorithm      string     `json:"alg"`
+	PrivateKeyFile string     `json:"privateKeyFile"` // PEM encoded, relative to the key file
+	ActiveFrom     time.Time  `json:"activeFrom"`
+	RetireAt       *time.Time `json:"retireAt,omitempty"`
+}
+
+// LoadKeySet loads the signing keys listed in JWT_SIGNING_KEYS_FILE. Without a key file tokens are signed
+// with JWT_SIGNING_SECRET as before, with one the secret only verifies the tokens it signed in the past:
+// until JWT_SIGNING_SECRET_RETIRE_AT (RFC 3339), which is then required. Set it to when the last refresh
+// token the secret signed expires.
+func LoadKeySet(env *models.Env) (*KeySet, error) {
+	ks := &KeySet{path: env.JWTSigningKeysFile}
+	if env.JWTSigningSecret != "" {
+		ks.legacy = &SigningKey{
+			Method:    jwt.SigningMethodHS256,
+			signKey:   []byte(env.JWTSigningSecret),
+			verifyKey: []byte(env.JWTSigningSecret),
+		}
+		if ks.path != "" {
+			retireAt, err := legacyRetireAt(env)
+			if err != nil {
+				return nil, err
+			}
+			ks.legacy.RetireAt = retireAt
+		}
+	}
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload reads the key file again, picking up newly scheduled and retired keys. The current keys are
+// kept when the file cannot be read.
+func (ks *KeySet) Reload() error {
+	var keys []*SigningKey
+	if ks.path != "" {
+		var err error
+		if keys, err = readKeyFile(ks.path); err != nil {
+			return err
+		}
+	} else if ks.legacy != nil {
+		keys = []*SigningKey{ks.legacy}
+	}
+	if len(keys) == 0 {
+		return ErrNoSigningKey
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].ActiveFrom.After(keys[j].ActiveFrom)
+	})
+	byID := make(map[string]*SigningKey, len(keys))
+	for _, key := range keys {
+		if _, ok := byID[key.ID]; ok {
+			return fmt.Errorf("duplicate signing key %q", key.ID)
+		}
+		byID[key.ID] = key
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys, ks.byID = keys, byID
+	return nil
+}
+
+// legacyRetireAt is when the JWT secret stops verifying tokens once a key file replaced it
+func legacyRetireAt(env *models.Env) (time.Time, error) {
+	if env.JWTSigningSecretRetireAt == "" {
+		return time.Time{}, ErrNoSecretRetireAt
+	}
+	retireAt, err := time.Parse(time.RFC3339, env.JWTSigningSecretRetireAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid JWT_SIGNING_SECRET_RETIRE_AT: %w", err)
+	}
+	return retireAt, nil
+}
+
+// RunReloader reloads the key file every interval until ctx is cancelled
+func (ks *KeySet) RunReloader(ctx context.Context, z zerolog.Logger, interval time.Duration) {
+	if ks.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := ks.Reload(); err != nil {
+			z.Err(err).Str("path", ks.path).Msg("unable to reload signing keys")
+		}
+	}
+}
+
+func readKeyFile(path string) ([]*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var configs []keyConfig
+	if err := json.Unmarshal(raw, &configs); err != nil {
+		return nil, fmt.Errorf("invalid signing key file %s: %w", path, err)
+	}
+
+	keys := make([]*SigningKey, 0, len(configs))
+	for _, config := range configs {
+		if config.ID == "" {
+			return nil, fmt.Errorf("signing key without kid in %s", path)
+		}
+		keyFile := config.PrivateKeyFile
+		if !filepath.IsAbs(keyFile) {
+			keyFile = filepath.Join(filepath.Dir(path), keyFile)
+		}
+		key, err := readPrivateKey(keyFile, config.Algorithm)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %q: %w", config.ID, err)
+		}
+		key.ID = config.ID
+		key.ActiveFrom = config.ActiveFrom
+		if config.RetireAt != nil {
+			key.RetireAt = *config.RetireAt
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// readPrivateKey reads a PEM encoded RSA, P-256 or Ed25519 private key. The algorithm follows from
+// the key, alg only has to be set to pick RS384 or RS512 over RS256.
+func readPrivateKey(path, alg string) (*SigningKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+
+	var private interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{signKey: private}
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if alg == "" {
+			alg = jwt.SigningMethodRS256.Alg()
+		}
+		if alg != "RS256" && alg != "RS384" && alg != "RS512" {
+			return nil, fmt.Errorf("algorithm %s does not fit an RSA key", alg)
+		}
+		key.publicKey = &private.PublicKey
+	case *ecdsa.PrivateKey:
+		if private.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 EC keys are supported")
+		}
+		if alg == "" {
+			alg = jwt.SigningMethodES256.Alg()
+		}
+		if alg != jwt.SigningMethodES256.Alg() {
+			return nil, fmt.Errorf("algorithm %s does not fit a P-256 key", alg)
+		}
+		key.publicKey = &private.PublicKey
+	case ed25519.PrivateKey:
+		if alg == "" {
+			alg = SigningMethodEdDSA.Alg()
+		}
+		if alg != SigningMethodEdDSA.Alg() {
+			return nil, fmt.Errorf("algorithm %s does not fit an Ed25519 key", alg)
+		}
+		key.publicKey = private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+	key.Method = jwt.GetSigningMethod(alg)
+	key.verifyKey = key.publicKey
+	return key, nil
+}
+
+// signingKey returns the key new tokens are signed with: the most recently activated key not retired
+func (ks *KeySet) signingKey(now time.Time) (*SigningKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	for _, key := range ks.keys {
+		if !key.ActiveFrom.After(now) && !key.retired(now) {
+			return key, nil
+		}
+	}
+	return nil, ErrNoSigningKey
+}
+
+// Sign signs claims with the current key, naming it in the kid header
+func (ks *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	key, err := ks.signingKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header[claimsKeyID] = key.ID
+	}
+	return token.SignedString(key.signKey)
+}
+
+// Keyfunc finds the key a token is verified with. The algorithm is taken from the key, never from the
+// token, so a token cannot get an RSA public key used as an HMAC secret.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header[claimsKeyID].(string)
+
+	ks.mu.RLock()
+	key := ks.legacy
+	if kid != "" {
+		key = ks.byID[kid]
+	}
+	ks.mu.RUnlock()
+
+	if key == nil || key.retired(time.Now()) {
+		return nil, ErrUnknownKey
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, ErrUnexpectedSigningMethod
+	}
+	return key.verifyKey, nil
+}
+
+// JSONWebKey is the public part of a signing key as published in the JWKS
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is served at /.well-known/jwks.json for other services to verify tokens with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// JWKS lists the public keys that verify tokens, including scheduled keys so they are known before they sign
+func (ks *KeySet) JWKS() JSONWebKeySet {
+	now := time.Now()
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range ks.keys {
+		if key.publicKey == nil || key.retired(now) {
+			continue
+		}
+		jwk := JSONWebKey{Use: "sig", KeyID: key.ID, Algorithm: key.Method.Alg()}
+		switch public := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk.KeyType = "EC"
+			jwk.Curve = "P-256"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, 32)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, 32)))
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
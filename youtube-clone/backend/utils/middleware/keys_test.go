@@ -0,0 +1,369 @@
+package middlewares
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// testKey is an entry of the key file written by writeKeyFile
+type testKey struct {
+	kid        string
+	alg        string
+	private    crypto.Signer
+	activeFrom time.Time
+	retireAt   *time.Time
+}
+
+// writeKeyFile writes the private keys as PKCS #8 PEM files next to a key file listing them
+func writeKeyFile(t *testing.T, keys ...testKey) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	configs := make([]keyConfig, 0, len(keys))
+	for _, key := range keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key.private)
+		if err != nil {
+			t.Fatalf("marshal key %s: %v", key.kid, err)
+		}
+		name := key.kid + ".pem"
+		if err := os.WriteFile(filepath.Join(dir, name), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		configs = append(configs, keyConfig{
+			ID:             key.kid,
+			Algorithm:      key.alg,
+			PrivateKeyFile: name,
+			ActiveFrom:     key.activeFrom,
+			RetireAt:       key.retireAt,
+		})
+	}
+
+	raw, err := json.Marshal(configs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newTestKeys(t *testing.T) (rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey, edKey ed25519.PrivateKey) {
+	t.Helper()
+	var err error
+	if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		t.Fatal(err)
+	}
+	if ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	if _, edKey, err = ed25519.GenerateKey(rand.Reader); err != nil {
+		t.Fatal(err)
+	}
+	return rsaKey, ecKey, edKey
+}
+
+// keyfuncError unwraps the error jwt.Parse wraps the Keyfunc error in
+func keyfuncError(err error) error {
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Inner != nil {
+		return validationErr.Inner
+	}
+	return err
+}
+
+func TestKeySetKeyfunc(t *testing.T) {
+	rsaKey, ecKey, edKey := newTestKeys(t)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	keyFile := writeKeyFile(t,
+		testKey{kid: "rsa", private: rsaKey, activeFrom: past},
+		testKey{kid: "ec", private: ecKey, activeFrom: past.Add(-time.Hour)},
+		testKey{kid: "retired", private: edKey, activeFrom: past.Add(-2 * time.Hour), retireAt: &past},
+	)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		env      models.Env
+		method   jwt.SigningMethod
+		kid      string
+		key      interface{}
+		wantErr  error
+		wantSign string // kid Sign is expected to name
+	}{
+		{
+			name:     "current key",
+			env:      models.Env{JWTSigningKeysFile: keyFile},
+			method:   jwt.SigningMethodRS256,
+			kid:      "rsa",
+			key:      rsaKey,
+			wantSign: "rsa",
+		},
+		{
+			name:   "older key still verifies",
+			env:    models.Env{JWTSigningKeysFile: keyFile},
+			method: jwt.SigningMethodES256,
+			kid:    "ec",
+			key:    ecKey,
+		},
+		{
+			name:    "retired key",
+			env:     models.Env{JWTSigningKeysFile: keyFile},
+			method:  SigningMethodEdDSA,
+			kid:     "retired",
+			key:     edKey,
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "unknown kid",
+			env:     models.Env{JWTSigningKeysFile: keyFile},
+			method:  jwt.SigningMethodRS256,
+			kid:     "unknown",
+			key:     rsaKey,
+			wantErr: ErrUnknownKey,
+		},
+		{
+			// an HMAC signed with the public key must not pass for the RSA key
+			name:    "alg does not match the kid",
+			env:     models.Env{JWTSigningKeysFile: keyFile},
+			method:  jwt.SigningMethodHS256,
+			kid:     "rsa",
+			key:     pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublic}),
+			wantErr: ErrUnexpectedSigningMethod,
+		},
+		{
+			name:    "alg none",
+			env:     models.Env{JWTSigningKeysFile: keyFile},
+			method:  jwt.SigningMethodNone,
+			kid:     "rsa",
+			key:     jwt.UnsafeAllowNoneSignatureType,
+			wantErr: ErrUnexpectedSigningMethod,
+		},
+		{
+			name:    "without kid or JWT secret",
+			env:     models.Env{JWTSigningKeysFile: keyFile},
+			method:  jwt.SigningMethodRS256,
+			key:     rsaKey,
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:   "JWT secret before it retires",
+			env:    models.Env{JWTSigningKeysFile: keyFile, JWTSigningSecret: "secret", JWTSigningSecretRetireAt: future.Format(time.RFC3339)},
+			method: jwt.SigningMethodHS256,
+			key:    []byte("secret"),
+		},
+		{
+			name:    "JWT secret after it retired",
+			env:     models.Env{JWTSigningKeysFile: keyFile, JWTSigningSecret: "secret", JWTSigningSecretRetireAt: past.Format(time.RFC3339)},
+			method:  jwt.SigningMethodHS256,
+			key:     []byte("secret"),
+			wantErr: ErrUnknownKey,
+		},
+		{
+			name:    "JWT secret with another alg",
+			env:     models.Env{JWTSigningSecret: "secret"},
+			method:  jwt.SigningMethodHS512,
+			key:     []byte("secret"),
+			wantErr: ErrUnexpectedSigningMethod,
+		},
+		{
+			name:   "JWT secret without key file",
+			env:    models.Env{JWTSigningSecret: "secret"},
+			method: jwt.SigningMethodHS256,
+			key:    []byte("secret"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := LoadKeySet(&tt.env)
+			if err != nil {
+				t.Fatalf("LoadKeySet: %v", err)
+			}
+
+			token := jwt.NewWithClaims(tt.method, jwt.MapClaims{claimsID: "user"})
+			if tt.kid != "" {
+				token.Header[claimsKeyID] = tt.kid
+			}
+			signed, err := token.SignedString(tt.key)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			_, err = jwt.Parse(signed, ks.Keyfunc)
+			if err = keyfuncError(err); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantSign != "" {
+				signed, err := ks.Sign(jwt.MapClaims{claimsID: "user"})
+				if err != nil {
+					t.Fatalf("Sign: %v", err)
+				}
+				parsed, err := jwt.Parse(signed, ks.Keyfunc)
+				if err != nil {
+					t.Fatalf("Parse(Sign()) error = %v", err)
+				}
+				if parsed.Header[claimsKeyID] != tt.wantSign {
+					t.Errorf("Sign() used kid %v, want %s", parsed.Header[claimsKeyID], tt.wantSign)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadKeySetSecretRetireAt(t *testing.T) {
+	_, _, edKey := newTestKeys(t)
+	keyFile := writeKeyFile(t, testKey{kid: "ed", private: edKey, activeFrom: time.Now().Add(-time.Hour)})
+
+	tests := []struct {
+		name    string
+		env     models.Env
+		wantErr error
+	}{
+		{name: "key file only", env: models.Env{JWTSigningKeysFile: keyFile}},
+		{name: "secret only", env: models.Env{JWTSigningSecret: "secret"}},
+		{
+			name: "secret with retire time",
+			env:  models.Env{JWTSigningKeysFile: keyFile, JWTSigningSecret: "secret", JWTSigningSecretRetireAt: "2030-01-01T00:00:00Z"},
+		},
+		{
+			name:    "secret without retire time",
+			env:     models.Env{JWTSigningKeysFile: keyFile, JWTSigningSecret: "secret"},
+			wantErr: ErrNoSecretRetireAt,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadKeySet(&tt.env); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadKeySet() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// publicKeyFromJWK decodes a key published by KeySet.JWKS the way a verifying service would
+func publicKeyFromJWK(t *testing.T, jwk JSONWebKey) crypto.PublicKey {
+	t.Helper()
+	decode := func(value string) []byte {
+		b, err := base64.RawURLEncoding.DecodeString(value)
+		if err != nil {
+			t.Fatalf("decode %s: %v", jwk.KeyID, err)
+		}
+		return b
+	}
+
+	switch {
+	case jwk.KeyType == "RSA":
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(decode(jwk.N)), E: int(new(big.Int).SetBytes(decode(jwk.E)).Int64())}
+	case jwk.KeyType == "EC" && jwk.Curve == "P-256":
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(decode(jwk.X)), Y: new(big.Int).SetBytes(decode(jwk.Y))}
+	case jwk.KeyType == "OKP" && jwk.Curve == "Ed25519":
+		return ed25519.PublicKey(decode(jwk.X))
+	}
+	t.Fatalf("unexpected key %+v", jwk)
+	return nil
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	rsaKey, ecKey, edKey := newTestKeys(t)
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		key     testKey
+		method  jwt.SigningMethod
+		wantKty string
+	}{
+		{name: "RSA", key: testKey{kid: "rsa", private: rsaKey}, method: jwt.SigningMethodRS256, wantKty: "RSA"},
+		{name: "RSA with RS512", key: testKey{kid: "rsa512", alg: "RS512", private: rsaKey}, method: jwt.SigningMethodRS512, wantKty: "RSA"},
+		{name: "P-256", key: testKey{kid: "ec", private: ecKey}, method: jwt.SigningMethodES256, wantKty: "EC"},
+		{name: "Ed25519", key: testKey{kid: "ed", private: edKey}, method: SigningMethodEdDSA, wantKty: "OKP"},
+		{name: "scheduled key", key: testKey{kid: "next", private: edKey, activeFrom: future}, method: SigningMethodEdDSA, wantKty: "OKP"},
+		{name: "retired key", key: testKey{kid: "old", private: edKey, retireAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ks, err := LoadKeySet(&models.Env{
+				JWTSigningKeysFile:       writeKeyFile(t, tt.key),
+				JWTSigningSecret:         "secret",
+				JWTSigningSecretRetireAt: future.Format(time.RFC3339),
+			})
+			if err != nil {
+				t.Fatalf("LoadKeySet: %v", err)
+			}
+
+			// the JWKS has to survive being served as JSON
+			raw, err := json.Marshal(ks.JWKS())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var set JSONWebKeySet
+			if err := json.Unmarshal(raw, &set); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.method == nil {
+				if len(set.Keys) != 0 {
+					t.Fatalf("JWKS() published %+v, want no keys", set.Keys)
+				}
+				return
+			}
+			// the JWT secret is never published
+			if len(set.Keys) != 1 {
+				t.Fatalf("JWKS() published %d keys, want 1", len(set.Keys))
+			}
+			jwk := set.Keys[0]
+			if jwk.KeyID != tt.key.kid || jwk.KeyType != tt.wantKty || jwk.Algorithm != tt.method.Alg() || jwk.Use != "sig" {
+				t.Fatalf("JWKS() = %+v", jwk)
+			}
+
+			public := publicKeyFromJWK(t, jwk)
+			if !tt.key.private.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(public) {
+				t.Fatalf("published key of %s does not match its private key", jwk.KeyID)
+			}
+
+			// a token signed with the private key verifies with the published key
+			token := jwt.NewWithClaims(tt.method, jwt.MapClaims{claimsID: "user"})
+			token.Header[claimsKeyID] = tt.key.kid
+			signed, err := token.SignedString(tt.key.private)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			_, err = jwt.Parse(signed, func(token *jwt.Token) (interface{}, error) {
+				if token.Method.Alg() != jwk.Algorithm {
+					return nil, ErrUnexpectedSigningMethod
+				}
+				return public, nil
+			})
+			if err != nil {
+				t.Fatalf("verify with the published key: %v", err)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package middlewares
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-contrib/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 
 	"github.com/joshua468/youtube-clone/backend/utils/helpers"
 	"github.com/joshua468/youtube-clone/backend/utils/models"
@@ -19,49 +21,30 @@ const (
 	IsAdminInContext = "is_admin_in_context"
 	IsAdminOnHeaders = "is_admin"
 	packageName      = "middleware"
-)
 
-type Middleware struct {
-	env models.Env
-	app models.App
-}
+	// AccessClaimsInContext holds the *AccessClaims of the token the request was authenticated with
+	AccessClaimsInContext = "access_claims_in_context"
+)
 
-func NewMiddleware(env models.Env, app models.App) *Middleware {
-	return &Middleware{env: env, app: app}
+func NewMiddleware(env models.Env, app models.App, keys *KeySet) *Middleware {
+	return &Middleware{
+		env:    env,
+		app:    app,
+		logger: log.With().Str("PACKAGE", packageName).Logger(),
+		jwt:    NewJwtConfig(&env),
+		keys:   keys,
+	}
 }
 
 func (m *Middleware) AuthMiddleware(onlyAdmin bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := requestid.Get(c)
-		bearerToken := c.Request.Header.Get("Authorization")
-
-		if len(bearerToken) == 0 {
-			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
-				ID:            requestID,
-				Handler:       packageName,
-				PublicMessage: "auth token is missing",
-			})
-			return
-		}
-
-		if !strings.HasPrefix(bearerToken, "Bearer ") {
-			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
-				ID:            requestID,
-				Handler:       packageName,
-				PublicMessage: "auth token is invalid",
-			})
-			return
-		}
-
-		userID, isAdmin, err := m.ParseToken(m.env, strings.TrimPrefix(bearerToken, "Bearer "))
+		claims, err := m.RequestAccessClaims(c)
 		if err != nil {
-			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
-				ID:            requestID,
-				Handler:       packageName,
-				PublicMessage: "token supplied is invalid/expired",
-			})
+			authErrorResponse(c, err)
 			return
 		}
+		userID, isAdmin := claims.UserID, claims.IsAdmin
 
 		uID, err := uuid.Parse(userID)
 		if err != nil {
@@ -94,11 +77,80 @@ func (m *Middleware) AuthMiddleware(onlyAdmin bool) gin.HandlerFunc {
 
 		c.Set(UserIDInContext, userID)
 		c.Set(IsAdminInContext, isAdmin)
+		c.Set(AccessClaimsInContext, claims)
 		c.Header(IsAdminOnHeaders, fmt.Sprint(isAdmin))
+		m.app.TouchSession(c, claims.FamilyID, c.ClientIP())
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware identifies the user like AuthMiddleware when a valid bearer token or access
+// token cookie is sent, and lets anonymous requests through without a user in the context
+func (m *Middleware) OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := m.RequestAccessClaims(c)
+		if errors.Is(err, ErrMissingToken) || errors.Is(err, ErrInvalidTokenHeaderFormat) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			authErrorResponse(c, err)
+			return
+		}
+
+		c.Set(UserIDInContext, claims.UserID)
+		c.Set(IsAdminInContext, claims.IsAdmin)
+		c.Set(AccessClaimsInContext, claims)
 		c.Next()
 	}
 }
 
+// RequestAccessClaims authenticates a request by the bearer token in the Authorization header or,
+// without one, by the access token cookie together with its CSRF token. The token has to be a valid
+// access token that was not revoked. It is shared by every middleware accepting access tokens.
+func (m *Middleware) RequestAccessClaims(c *gin.Context) (*AccessClaims, error) {
+	bearerToken := c.Request.Header.Get("Authorization")
+
+	// browsers in cookie mode send the access token as a cookie instead
+	if token, ok := m.accessTokenFromCookie(c); ok && len(bearerToken) == 0 {
+		if !ValidCSRF(c) {
+			return nil, ErrInvalidCSRFToken
+		}
+		bearerToken = "Bearer " + token
+	}
+
+	if len(bearerToken) == 0 {
+		return nil, ErrMissingToken
+	}
+	if !strings.HasPrefix(bearerToken, "Bearer ") {
+		return nil, ErrInvalidTokenHeaderFormat
+	}
+
+	claims, err := m.ParseAccessClaims(&m.env, strings.TrimPrefix(bearerToken, "Bearer "))
+	if err != nil || m.app.IsTokenRevoked(c, claims.TokenID, claims.FamilyID, claims.UserID, claims.IssuedAt) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// authErrorResponse aborts a request RequestAccessClaims rejected
+func authErrorResponse(c *gin.Context, err error) {
+	status, message := http.StatusUnauthorized, "token supplied is invalid/expired"
+	switch {
+	case errors.Is(err, ErrInvalidCSRFToken):
+		status, message = http.StatusForbidden, "CSRF token is missing or invalid"
+	case errors.Is(err, ErrMissingToken):
+		message = "auth token is missing"
+	case errors.Is(err, ErrInvalidTokenHeaderFormat):
+		message = "auth token is invalid"
+	}
+	models.ErrorResponse(c, status, models.ErrorData{
+		ID:            requestid.Get(c),
+		Handler:       packageName,
+		PublicMessage: message,
+	})
+}
+
 func (m *Middleware) CorsMiddleware() gin.HandlerFunc {
 	return cors.New(cors.DefaultConfig())
 }
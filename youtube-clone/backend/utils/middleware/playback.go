@@ -0,0 +1,50 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-contrib/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/joshua468/youtube-clone/backend/utils/helpers"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
+)
+
+// PlaybackMiddleware guards media routes with the signed token in the :token path parameter.
+// The token has to be issued for the video in :id and not be expired; a token bound to a viewer
+// additionally needs an access token of that viewer, checked like in AuthMiddleware.
+func (m *Middleware) PlaybackMiddleware() gin.HandlerFunc {
+	key := helpers.PlaybackKey(m.env.PlaybackSigningSecret)
+
+	return func(c *gin.Context) {
+		requestID := requestid.Get(c)
+
+		token, err := helpers.ParsePlaybackToken(key, c.Param("token"), time.Now())
+		if err != nil || token.VideoID.String() != c.Param("id") {
+			models.ErrorResponse(c, http.StatusForbidden, models.ErrorData{
+				ID:            requestID,
+				Handler:       packageName,
+				PublicMessage: "playback URL is invalid/expired",
+			})
+			return
+		}
+
+		if token.ViewerID != uuid.Nil {
+			claims, err := m.RequestAccessClaims(c)
+			if err != nil || claims.UserID != token.ViewerID.String() {
+				models.ErrorResponse(c, http.StatusForbidden, models.ErrorData{
+					ID:            requestID,
+					Handler:       packageName,
+					PublicMessage: "playback URL was issued to another viewer",
+				})
+				return
+			}
+			c.Set(UserIDInContext, claims.UserID)
+			c.Set(AccessClaimsInContext, claims)
+		}
+
+		c.Next()
+	}
+}
@@ -25,35 +25,12 @@ func NewRestAuthMiddleware(middleware *Middleware) *RestAuthMiddleware {
 func (m *RestAuthMiddleware) AuthMiddleware(onlyAdmin bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := requestid.Get(c)
-		bearerToken := c.Request.Header.Get("Authorization")
-
-		if len(bearerToken) == 0 {
-			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
-				ID:            requestID,
-				Handler:       packageName,
-				PublicMessage: "auth token is missing",
-			})
-			return
-		}
-
-		if !strings.HasPrefix(bearerToken, "Bearer ") {
-			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
-				ID:            requestID,
-				Handler:       packageName,
-				PublicMessage: "auth token is invalid",
-			})
-			return
-		}
-
-		userID, isAdmin, err := m.middleware.ParseToken(bearerToken)
+		claims, err := m.middleware.RequestAccessClaims(c)
 		if err != nil {
-			models.ErrorResponse(c, http.StatusUnauthorized, models.ErrorData{
-				ID:            requestID,
-				Handler:       packageName,
-				PublicMessage: "token supplied is invalid/expired",
-			})
+			authErrorResponse(c, err)
 			return
 		}
+		userID, isAdmin := claims.UserID, claims.IsAdmin
 
 		uID, err := uuid.Parse(userID)
 		if err != nil {
@@ -86,7 +63,9 @@ func (m *RestAuthMiddleware) AuthMiddleware(onlyAdmin bool) gin.HandlerFunc {
 
 		c.Set(UserIDInContext, userID)
 		c.Set(IsAdminInContext, isAdmin)
+		c.Set(AccessClaimsInContext, claims)
 		c.Header(IsAdminOnHeaders, fmt.Sprint(isAdmin))
+		m.middleware.app.TouchSession(c, claims.FamilyID, c.ClientIP())
 		c.Next()
 	}
 }
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// App is what the middlewares need from the application, it is implemented by app.App
+type App interface {
+	GetUserByID(ctx *gin.Context, userID uuid.UUID) (*User, error)
+	IsTokenRevoked(ctx context.Context, tokenID, familyID uuid.UUID, userID string, issuedAt time.Time) bool
+	TouchSession(ctx context.Context, familyID uuid.UUID, ip string)
+}
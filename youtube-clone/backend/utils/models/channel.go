@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Channel is a public identity videos are published under. A user can own several channels.
+type Channel struct {
+	ID              uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID          uuid.UUID `gorm:"type:char(36);index;not null" json:"userID"`
+	Handle          string    `gorm:"size:30;uniqueIndex;not null" json:"handle"`
+	DisplayName     string    `gorm:"size:100;not null" json:"displayName"`
+	Description     string    `gorm:"type:text" json:"description"`
+	BannerURL       string    `gorm:"size:512" json:"bannerUrl,omitempty"`
+	AvatarURL       string    `gorm:"size:512" json:"avatarUrl,omitempty"`
+	SubscriberCount int64     `gorm:"not null;default:0" json:"subscriberCount"` // kept up to date by repository.Subscription
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+
+	// Subscription is the subscription of the requesting user, if any
+	Subscription *Subscription `gorm:"-" json:"subscription,omitempty"`
+}
+
+// ChannelRequest creates a channel or replaces the editable fields of one
+type ChannelRequest struct {
+	Handle      string `json:"handle" validate:"required,min=3,max=30"`
+	DisplayName string `json:"displayName" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=5000"`
+	BannerURL   string `json:"bannerUrl" validate:"omitempty,url,max=512"`
+	AvatarURL   string `json:"avatarUrl" validate:"omitempty,url,max=512"`
+}
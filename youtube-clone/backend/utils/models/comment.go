@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CommentSortNewest = "newest"
+	CommentSortTop    = "top"
+)
+
+// Comment is a top-level comment on a video, or a reply when ParentID is set.
+// Threads are one level deep: replies to a reply are attached to its top-level comment.
+type Comment struct {
+	ID         uuid.UUID       `gorm:"type:char(36);primary_key" json:"id"`
+	VideoID    uuid.UUID       `gorm:"type:char(36);index;not null" json:"videoID"`
+	UserID     uuid.UUID       `gorm:"type:char(36);index;not null" json:"userID"`
+	ParentID   *uuid.UUID      `gorm:"type:char(36);index" json:"parentID,omitempty"`
+	Body       string          `gorm:"type:text;not null" json:"body"`
+	Edited     bool            `gorm:"not null;default:false" json:"edited"`
+	Pinned     bool            `gorm:"not null;default:false" json:"pinned"`
+	ReplyCount int64           `gorm:"not null;default:0" json:"replyCount"`
+	CreatedAt  time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt  time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt  *gorm.DeletedAt `json:"deletedAt,omitempty"`
+
+	LikeCount    int64 `gorm:"not null;default:0" json:"likeCount"`
+	DislikeCount int64 `gorm:"not null;default:0" json:"dislikeCount"`
+}
+
+type CreateCommentRequest struct {
+	Body     string     `json:"body" validate:"required,max=10000"`
+	ParentID *uuid.UUID `json:"parentID"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" validate:"required,max=10000"`
+}
+
+type CommentSettingsRequest struct {
+	Disabled bool `json:"disabled"`
+}
@@ -1,17 +1,47 @@
 package models
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 type Env struct {
-	DBPassword            string
-	DBName                string
-	DBUsername            string
-	DBHost                string
-	DBPort                string
-	JWTAccessTokenExpiry  string
-	JWTRefreshTokenExpiry string
-	JWTSigningSecret      string
-	PORT                  string
+	DBPassword               string
+	DBName                   string
+	DBUsername               string
+	DBHost                   string
+	DBPort                   string
+	JWTAccessTokenExpiry     string
+	JWTRefreshTokenExpiry    string
+	JWTSigningSecret         string
+	JWTSigningKeysFile       string
+	JWTSigningSecretRetireAt string
+	AuthCookieMode           string
+	AuthCookieDomain         string
+	AuthCookieSecure         string
+	PORT                     string
+	StorageDriver            string
+	StorageLocalPath         string
+	S3Endpoint               string
+	S3AccessKey              string
+	S3SecretKey              string
+	S3Bucket                 string
+	S3Region                 string
+	S3UseSSL                 string
+	UploadMaxSize            string
+	UploadExpiry             string
+	FFmpegPath               string
+	FFprobePath              string
+	TranscodeWorkers         string
+	StoryboardInterval       string
+	PlaybackSigningSecret    string
+	PlaybackURLExpiry        string
+	ViewMinWatch             string
+	ViewDedupWindow          string
+	SearchIndexPath          string
+	SearchSuggestDenylist    string
+	TrustedProxies           string
 }
 
 func NewEnv() *Env {
@@ -23,17 +53,92 @@ func NewEnv() *Env {
 	jwtAccessTokenExpiry := os.Getenv("JWT_ACCESS_TOKEN_EXPIRY")
 	jwtRefreshTokenExpiry := os.Getenv("JWT_REFRESH_TOKEN_EXPIRY")
 	jwtSigningSecret := os.Getenv("JWT_SIGNING_SECRET")
+	jwtSigningKeysFile := os.Getenv("JWT_SIGNING_KEYS_FILE")
+	jwtSigningSecretRetireAt := os.Getenv("JWT_SIGNING_SECRET_RETIRE_AT")
+	authCookieMode := os.Getenv("AUTH_COOKIE_MODE")
+	authCookieDomain := os.Getenv("AUTH_COOKIE_DOMAIN")
+	authCookieSecure := os.Getenv("AUTH_COOKIE_SECURE")
 	port := os.Getenv("PORT")
+	storageDriver := os.Getenv("STORAGE_DRIVER")
+	storageLocalPath := os.Getenv("STORAGE_LOCAL_PATH")
+	s3Endpoint := os.Getenv("S3_ENDPOINT")
+	s3AccessKey := os.Getenv("S3_ACCESS_KEY")
+	s3SecretKey := os.Getenv("S3_SECRET_KEY")
+	s3Bucket := os.Getenv("S3_BUCKET")
+	s3Region := os.Getenv("S3_REGION")
+	s3UseSSL := os.Getenv("S3_USE_SSL")
+	uploadMaxSize := os.Getenv("UPLOAD_MAX_SIZE")
+	uploadExpiry := os.Getenv("UPLOAD_EXPIRY")
+	ffmpegPath := os.Getenv("FFMPEG_PATH")
+	ffprobePath := os.Getenv("FFPROBE_PATH")
+	transcodeWorkers := os.Getenv("TRANSCODE_WORKERS")
+	storyboardInterval := os.Getenv("STORYBOARD_INTERVAL")
+	playbackSigningSecret := os.Getenv("PLAYBACK_SIGNING_SECRET")
+	playbackURLExpiry := os.Getenv("PLAYBACK_URL_EXPIRY")
+	viewMinWatch := os.Getenv("VIEW_MIN_WATCH")
+	viewDedupWindow := os.Getenv("VIEW_DEDUP_WINDOW")
+	searchIndexPath := os.Getenv("SEARCH_INDEX_PATH")
+	searchSuggestDenylist := os.Getenv("SEARCH_SUGGEST_DENYLIST")
+	trustedProxies := os.Getenv("TRUSTED_PROXIES")
+
+	// playback URLs are signed with the JWT secret unless a dedicated key is configured
+	if playbackSigningSecret == "" {
+		playbackSigningSecret = jwtSigningSecret
+	}
 
 	return &Env{
-		DBPassword:            dbPass,
-		DBName:                dbName,
-		DBUsername:            dbUsername,
-		DBHost:                dbHost,
-		DBPort:                dbPort,
-		JWTAccessTokenExpiry:  jwtAccessTokenExpiry,
-		JWTRefreshTokenExpiry: jwtRefreshTokenExpiry,
-		JWTSigningSecret:      jwtSigningSecret,
-		PORT:                  port,
+		DBPassword:               dbPass,
+		DBName:                   dbName,
+		DBUsername:               dbUsername,
+		DBHost:                   dbHost,
+		DBPort:                   dbPort,
+		JWTAccessTokenExpiry:     jwtAccessTokenExpiry,
+		JWTRefreshTokenExpiry:    jwtRefreshTokenExpiry,
+		JWTSigningSecret:         jwtSigningSecret,
+		JWTSigningKeysFile:       jwtSigningKeysFile,
+		JWTSigningSecretRetireAt: jwtSigningSecretRetireAt,
+		AuthCookieMode:           authCookieMode,
+		AuthCookieDomain:         authCookieDomain,
+		AuthCookieSecure:         authCookieSecure,
+		PORT:                     port,
+		StorageDriver:            storageDriver,
+		StorageLocalPath:         storageLocalPath,
+		S3Endpoint:               s3Endpoint,
+		S3AccessKey:              s3AccessKey,
+		S3SecretKey:              s3SecretKey,
+		S3Bucket:                 s3Bucket,
+		S3Region:                 s3Region,
+		S3UseSSL:                 s3UseSSL,
+		UploadMaxSize:            uploadMaxSize,
+		UploadExpiry:             uploadExpiry,
+		FFmpegPath:               ffmpegPath,
+		FFprobePath:              ffprobePath,
+		TranscodeWorkers:         transcodeWorkers,
+		StoryboardInterval:       storyboardInterval,
+		PlaybackSigningSecret:    playbackSigningSecret,
+		PlaybackURLExpiry:        playbackURLExpiry,
+		ViewMinWatch:             viewMinWatch,
+		ViewDedupWindow:          viewDedupWindow,
+		SearchIndexPath:          searchIndexPath,
+		SearchSuggestDenylist:    searchSuggestDenylist,
+		TrustedProxies:           trustedProxies,
+	}
+}
+
+// AccessTokenExpiry is how long access tokens stay valid, configured in minutes
+func (e *Env) AccessTokenExpiry() time.Duration {
+	ttl, err := strconv.Atoi(e.JWTAccessTokenExpiry)
+	if err != nil {
+		return time.Minute * 10000
+	}
+	return time.Minute * time.Duration(ttl)
+}
+
+// RefreshTokenExpiry is how long refresh tokens stay valid, configured in hours
+func (e *Env) RefreshTokenExpiry() time.Duration {
+	ttl, err := strconv.Atoi(e.JWTRefreshTokenExpiry)
+	if err != nil {
+		return time.Hour * 240000
 	}
+	return time.Hour * time.Duration(ttl)
 }
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WatchCompletedPercent is the share of a video after which it counts as watched to the end
+const WatchCompletedPercent = 95
+
+// WatchHistory is the playback progress of a user on a video, one row per user and video
+type WatchHistory struct {
+	ID             uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_watch_history_user_video" json:"userID"`
+	VideoID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_watch_history_user_video" json:"videoID"`
+	Position       float64   `gorm:"not null;default:0" json:"position"` // seconds
+	PercentWatched float64   `gorm:"not null;default:0" json:"percentWatched"`
+	Completed      bool      `gorm:"not null;default:false" json:"completed"`
+	WatchedAt      time.Time `gorm:"index" json:"watchedAt"`
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+
+	Video *Video `gorm:"-" json:"video,omitempty"`
+}
+
+// HistorySettings holds the watch history preferences of a user, no row means the defaults
+type HistorySettings struct {
+	UserID    uuid.UUID `gorm:"type:char(36);primary_key" json:"userID"`
+	Paused    bool      `gorm:"not null;default:false" json:"paused"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+type WatchProgressRequest struct {
+	Position float64 `json:"position" validate:"gte=0"`
+}
+
+type HistorySettingsRequest struct {
+	Paused bool `json:"paused"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	JobTypeTranscode  = "transcode"
+	JobTypePackage    = "package"
+	JobTypeThumbnails = "thumbnails"
+	JobTypeStoryboard = "storyboard"
+
+	JobStatusPending = "pending"
+	JobStatusRunning = "running"
+	JobStatusFailed  = "failed"
+	JobStatusDone    = "done"
+)
+
+// Job is a unit of background processing for a video, persisted so it survives restarts
+type Job struct {
+	ID          uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	VideoID     uuid.UUID  `gorm:"type:char(36);index;not null" json:"videoID"`
+	Type        string     `gorm:"size:50;index:idx_jobs_claim,priority:2;not null" json:"type"`
+	Status      string     `gorm:"size:20;index:idx_jobs_claim,priority:1;not null" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null;default:3" json:"maxAttempts"`
+	LastError   string     `gorm:"type:text" json:"lastError,omitempty"`
+	RunAt       time.Time  `gorm:"index:idx_jobs_claim,priority:3;not null" json:"runAt"`
+	LockedAt    *time.Time `json:"lockedAt,omitempty"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PlaybackURL is a set of signed, expiring locations a viewer can play a video from
+type PlaybackURL struct {
+	Token         string    `json:"token"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+	BoundToViewer bool      `json:"boundToViewer"`
+	StreamURL     string    `json:"streamUrl"`
+	HLSURL        string    `json:"hlsUrl"`
+	DASHURL       string    `json:"dashUrl"`
+	StoryboardURL string    `json:"storyboardUrl"`
+}
+
+// PlaybackPath is the prefix the media of a video is served below for a signed token
+func PlaybackPath(videoID uuid.UUID, token string) string {
+	return fmt.Sprintf("/api/video/%s/play/%s", videoID.String(), token)
+}
+
+type PlaybackURLRequest struct {
+	// BindViewer restricts the URL to requests carrying the bearer token of the caller
+	BindViewer bool `json:"bindViewer"`
+}
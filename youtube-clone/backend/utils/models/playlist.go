@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PlaylistKindCustom = "custom"
+	// PlaylistKindWatchLater is the built-in private playlist every user has exactly one of
+	PlaylistKindWatchLater = "watch_later"
+)
+
+// WatchLaterRef addresses the watch later playlist of the caller in place of a playlist ID
+const WatchLaterRef = "watch-later"
+
+// Playlist is an ordered list of videos. Visibility takes the public, unlisted and private video visibilities.
+type Playlist struct {
+	ID          uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID      uuid.UUID `gorm:"type:char(36);index;not null" json:"userID"`
+	Kind        string    `gorm:"size:20;not null;default:custom" json:"kind"`
+	Title       string    `gorm:"size:150;not null" json:"title"`
+	Description string    `gorm:"type:text" json:"description"`
+	Visibility  string    `gorm:"size:20;index;not null;default:private" json:"visibility"`
+	ItemCount   int64     `gorm:"not null;default:0" json:"itemCount"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+
+	Collaborators []*PlaylistCollaborator `gorm:"-" json:"collaborators,omitempty"`
+}
+
+// IsVisibleTo reports whether viewerID may see the playlist. Like videos, unlisted playlists are
+// visible to anybody who knows their ID; uuid.Nil stands for an anonymous viewer.
+func (p *Playlist) IsVisibleTo(viewerID uuid.UUID) bool {
+	if viewerID != uuid.Nil && p.UserID == viewerID {
+		return true
+	}
+	for _, collaborator := range p.Collaborators {
+		if viewerID != uuid.Nil && collaborator.UserID == viewerID {
+			return true
+		}
+	}
+	return p.Visibility == VideoVisibilityPublic || p.Visibility == VideoVisibilityUnlisted
+}
+
+// PlaylistItem is a video in a playlist. Positions are dense, from 0 to ItemCount-1.
+type PlaylistItem struct {
+	ID         uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	PlaylistID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_playlist_item_video;index:idx_playlist_item_position" json:"playlistID"`
+	VideoID    uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_playlist_item_video" json:"videoID"`
+	Position   int       `gorm:"not null;index:idx_playlist_item_position" json:"position"`
+	AddedBy    uuid.UUID `gorm:"type:char(36);not null" json:"addedBy"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+
+	Video *Video `gorm:"-" json:"video,omitempty"`
+}
+
+// PlaylistCollaborator is a user the owner allowed to add videos to a playlist
+type PlaylistCollaborator struct {
+	PlaylistID uuid.UUID `gorm:"type:char(36);primary_key" json:"playlistID"`
+	UserID     uuid.UUID `gorm:"type:char(36);primary_key;index" json:"userID"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+type CreatePlaylistRequest struct {
+	Title       string `json:"title" validate:"required,max=150"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility" validate:"omitempty,oneof=public unlisted private"`
+}
+
+type UpdatePlaylistRequest struct {
+	Title       string `json:"title" validate:"required,max=150"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility" validate:"required,oneof=public unlisted private"`
+}
+
+type AddPlaylistItemRequest struct {
+	VideoID uuid.UUID `json:"videoID" validate:"required"`
+}
+
+type MovePlaylistItemRequest struct {
+	Position *int `json:"position" validate:"required,gte=0"`
+}
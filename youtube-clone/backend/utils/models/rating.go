@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RatingLike    = "like"
+	RatingDislike = "dislike"
+)
+
+const (
+	RatingTargetVideo   = "video"
+	RatingTargetComment = "comment"
+)
+
+// Rating is the like or dislike of a user on a video or a comment. A user rates a target at most once.
+type Rating struct {
+	ID         uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID     uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_rating_user_target" json:"userID"`
+	TargetType string    `gorm:"size:20;not null;uniqueIndex:idx_rating_user_target" json:"targetType"`
+	TargetID   uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_rating_user_target;index" json:"targetID"`
+	Value      string    `gorm:"size:10;not null" json:"value"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+type RateRequest struct {
+	Rating string `json:"rating" validate:"required,oneof=like dislike"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RenditionKindVideo = "video"
+	RenditionKindAudio = "audio"
+)
+
+// Rendition is a transcoded variant of a video at a given resolution and bitrate.
+// Video renditions carry a progressive MP4 in ObjectKey; once packaged every rendition
+// also has a track split in fMP4 segments below SegmentPrefix.
+type Rendition struct {
+	ID              uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	VideoID         uuid.UUID `gorm:"type:char(36);index;not null" json:"videoID"`
+	Name            string    `gorm:"size:20;not null" json:"name"`
+	Kind            string    `gorm:"size:10;not null;default:video" json:"kind"`
+	Width           int       `json:"width"`
+	Height          int       `json:"height"`
+	VideoBitrate    int       `json:"videoBitrate"` // bits per second
+	PeakBitrate     int       `json:"peakBitrate"`  // bits per second
+	AudioBitrate    int       `json:"audioBitrate"` // bits per second
+	Codecs          string    `gorm:"size:100" json:"codecs"`
+	ObjectKey       string    `gorm:"size:512" json:"-"`
+	Size            int64     `json:"size"`
+	MimeType        string    `gorm:"size:100" json:"mimeType"`
+	SegmentPrefix   string    `gorm:"size:512" json:"-"`
+	SegmentCount    int       `json:"segmentCount"`
+	SegmentDuration float64   `json:"segmentDuration"` // target seconds per segment
+	Duration        float64   `json:"duration"`        // seconds
+	CreatedAt       time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
 
 type VideoResponse struct {
 	ID      uuid.UUID `json:"id"`
@@ -9,28 +12,28 @@ type VideoResponse struct {
 	Content string    `json:"content"`
 }
 
-type ErrorResponse struct {
+type ErrorData struct {
 	ID            string `json:"id"`
 	Handler       string `json:"handler"`
 	PublicMessage string `json:"publicMessage"`
 }
 
 type GenericResponse struct {
-	Code    int           `json:"code"`
-	Data    interface{}   `json:"data,omitempty"`
-	Message *string       `json:"message,omitempty"`
-	Error   *ErrorResponse `json:"error,omitempty"`
+	Code    int         `json:"code"`
+	Data    interface{} `json:"data,omitempty"`
+	Message *string     `json:"message,omitempty"`
+	Error   *ErrorData  `json:"error,omitempty"`
 }
 
-func NewErrorResponse(id, handler, publicMessage string) *ErrorResponse {
-	return &ErrorResponse{
+func NewErrorData(id, handler, publicMessage string) *ErrorData {
+	return &ErrorData{
 		ID:            id,
 		Handler:       handler,
 		PublicMessage: publicMessage,
 	}
 }
 
-func NewGenericResponse(code int, data interface{}, message *string, err *ErrorResponse) *GenericResponse {
+func NewGenericResponse(code int, data interface{}, message *string, err *ErrorData) *GenericResponse {
 	return &GenericResponse{
 		Code:    code,
 		Data:    data,
@@ -38,3 +41,13 @@ func NewGenericResponse(code int, data interface{}, message *string, err *ErrorR
 		Error:   err,
 	}
 }
+
+// ErrorResponse aborts the request with code and the error wrapped in a GenericResponse
+func ErrorResponse(c *gin.Context, code int, err ErrorData) {
+	c.AbortWithStatusJSON(code, NewGenericResponse(code, nil, nil, &err))
+}
+
+// OkResponse responds with code and data wrapped in a GenericResponse
+func OkResponse(c *gin.Context, code int, message string, data interface{}) {
+	c.JSON(code, NewGenericResponse(code, data, &message, nil))
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// SearchQuery counts how many distinct searchers searched a normalized query, popular ones become suggestions
+type SearchQuery struct {
+	Query          string    `gorm:"size:200;primary_key" json:"query"`
+	Searchers      int64     `gorm:"not null;default:0" json:"searchers"`
+	LastSearchedAt time.Time `gorm:"index" json:"lastSearchedAt"`
+}
+
+// SearchQuerySearcher records who searched a query, so repeated searches of one searcher count once.
+// Searcher is a hash of the user or the network of an anonymous searcher.
+type SearchQuerySearcher struct {
+	Query     string    `gorm:"size:200;primary_key" json:"query"`
+	Searcher  string    `gorm:"size:64;primary_key" json:"searcher"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SearchRequest is read from the query string of a video search
+type SearchRequest struct {
+	Query     string   `form:"q" validate:"required,max=200"`
+	Uploaded  string   `form:"uploaded" validate:"omitempty,oneof=hour today week month year"`
+	Duration  string   `form:"duration" validate:"omitempty,oneof=short medium long"`
+	ChannelID string   `form:"channelID" validate:"omitempty,uuid"`
+	Tags      []string `form:"tag" validate:"max=10,dive,required,max=50"`
+}
+
+// SearchResult is a video matching a search, Highlights holds the matching fragments of its
+// title and description with the matched terms wrapped in <mark> tags
+type SearchResult struct {
+	Video      *Video              `json:"video"`
+	Score      float64             `json:"score"`
+	Highlights map[string][]string `json:"highlights,omitempty"`
+}
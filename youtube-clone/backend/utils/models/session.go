@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a signed in device of a user, one per login. It lives as long as its refresh token
+// family, revoking the session signs the device out.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:char(36);index;not null" json:"userID"`
+	FamilyID   uuid.UUID  `gorm:"type:char(36);uniqueIndex;not null" json:"-"`
+	DeviceName string     `gorm:"size:100" json:"deviceName"`
+	UserAgent  string     `gorm:"size:500" json:"userAgent"`
+	IP         string     `gorm:"size:45" json:"ip"`
+	LastSeenAt time.Time  `gorm:"index" json:"lastSeenAt"`
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expiresAt"`
+	RevokedAt  *time.Time `gorm:"index" json:"-"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+
+	// Current marks the session the request was made with
+	Current bool `gorm:"-" json:"current"`
+}
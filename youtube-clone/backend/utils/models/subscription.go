@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// SubscriptionNotifyAll notifies about every upload of the channel
+	SubscriptionNotifyAll = "all"
+	// SubscriptionNotifyPersonalized notifies about the uploads we expect the subscriber to care about
+	SubscriptionNotifyPersonalized = "personalized"
+	// SubscriptionNotifyNone never notifies
+	SubscriptionNotifyNone = "none"
+)
+
+// Subscription is a user following a channel
+type Subscription struct {
+	ID            uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID        uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_subscription_user_channel" json:"userID"`
+	ChannelID     uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_subscription_user_channel;index" json:"channelID"`
+	Notifications string    `gorm:"size:20;not null;default:personalized" json:"notifications"`
+	CreatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+type SubscribeRequest struct {
+	Notifications string `json:"notifications" validate:"omitempty,oneof=all personalized none"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Thumbnail is a poster image of a video, either extracted during processing or uploaded by the owner
+type Thumbnail struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	VideoID   uuid.UUID `gorm:"type:char(36);index;not null" json:"videoID"`
+	Position  int       `json:"position"` // percentage of the duration the frame was taken at
+	Custom    bool      `gorm:"not null;default:false" json:"custom"`
+	ObjectKey string    `gorm:"size:512" json:"-"`
+	MimeType  string    `gorm:"size:100" json:"mimeType"`
+	URL       string    `gorm:"-" json:"url"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// ThumbnailURL is the public location a thumbnail is served from
+func ThumbnailURL(videoID, thumbnailID uuid.UUID) string {
+	return fmt.Sprintf("/api/video/%s/thumbnails/%s", videoID.String(), thumbnailID.String())
+}
+
+type SelectThumbnailRequest struct {
+	ThumbnailID uuid.UUID `json:"thumbnailID" validate:"required"`
+}
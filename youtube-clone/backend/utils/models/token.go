@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the server side record of an issued refresh token. A refresh replaces the token
+// with a new one of the same family, presenting a replaced token again revokes the whole family.
+type RefreshToken struct {
+	ID         uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	FamilyID   uuid.UUID  `gorm:"type:char(36);index;not null" json:"familyID"`
+	UserID     uuid.UUID  `gorm:"type:char(36);index;not null" json:"userID"`
+	ExpiresAt  time.Time  `gorm:"index;not null" json:"expiresAt"`
+	UsedAt     *time.Time `json:"usedAt,omitempty"`
+	ReplacedBy *uuid.UUID `gorm:"type:char(36)" json:"replacedBy,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
+
+// RevokedToken is an access token signed out before it expired, the row can be dropped once the token expired
+type RevokedToken struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	UserID    uuid.UUID `gorm:"type:char(36);index;not null" json:"userID"`
+	ExpiresAt time.Time `gorm:"index;not null" json:"expiresAt"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+}
+
+// RevokedUserTokens revokes every access token of a user issued before IssuedBefore, it is set when
+// the user signs out of all devices
+type RevokedUserTokens struct {
+	UserID       uuid.UUID `gorm:"type:char(36);primary_key" json:"userID"`
+	IssuedBefore time.Time `gorm:"not null" json:"issuedBefore"`
+	UpdatedAt    time.Time `gorm:"index" json:"updatedAt"`
+}
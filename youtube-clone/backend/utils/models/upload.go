@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload tracks the state of a resumable (tus) upload until it is turned into a Video
+type Upload struct {
+	ID          uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:char(36);index;not null" json:"userID"`
+	Length      int64      `gorm:"column:upload_length;not null" json:"length"`
+	Offset      int64      `gorm:"column:upload_offset;not null;default:0" json:"offset"`
+	Parts       int        `gorm:"not null;default:0" json:"-"`
+	Metadata    string     `gorm:"type:text" json:"-"`
+	Title       string     `gorm:"size:255" json:"title"`
+	Description string     `gorm:"type:text" json:"description"`
+	FileName    string     `gorm:"size:255" json:"fileName"`
+	ContentType string     `gorm:"size:100" json:"contentType"`
+	Visibility  string     `gorm:"size:20" json:"visibility,omitempty"`
+	PublishAt   *time.Time `json:"publishAt,omitempty"`
+	ChannelID   *uuid.UUID `gorm:"type:char(36)" json:"channelID,omitempty"`
+	VideoID     *uuid.UUID `gorm:"type:char(36)" json:"videoID,omitempty"`
+	ExpiresAt   time.Time  `gorm:"index" json:"expiresAt"`
+	CreatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time  `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+}
+
+// IsComplete reports whether every byte of the upload has been received
+func (u *Upload) IsComplete() bool {
+	return u.Offset >= u.Length
+}
@@ -3,15 +3,17 @@ package models
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type User struct {
-	ID        uint32          `gorm:"primary_key;auto_increment" json:"id"`
+	ID        uuid.UUID       `gorm:"type:char(36);primary_key" json:"id"`
 	Username  string          `gorm:"size:255;not null;unique" json:"username" validate:"required,min=3,max=50"`
 	Email     string          `gorm:"size:100;not null;unique" json:"email" validate:"required,email"`
 	Password  string          `gorm:"size:100;not null;" json:"password" validate:"required,min=8"`
+	IsAdmin   bool            `gorm:"not null;default:false" json:"isAdmin"`
 	CreatedAt time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
 	UpdatedAt time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"updated_at"`
 	DeletedAt *gorm.DeletedAt `json:"deletedAt,omitempty"`
@@ -1,15 +1,121 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	VideoStatusPending    = "pending"
+	VideoStatusProcessing = "processing"
+	VideoStatusReady      = "ready"
+	VideoStatusFailed     = "failed"
+)
+
+const (
+	// VideoVisibilityPublic videos are listed and can be watched by anybody
+	VideoVisibilityPublic = "public"
+	// VideoVisibilityUnlisted videos can be watched by anybody with the link but are never listed
+	VideoVisibilityUnlisted = "unlisted"
+	// VideoVisibilityPrivate videos are only visible to their owner
+	VideoVisibilityPrivate = "private"
+	// VideoVisibilityScheduled videos are private until PublishAt, then public
+	VideoVisibilityScheduled = "scheduled"
+)
+
+// VideoSortColumns are the columns video listings can be sorted by
+var VideoSortColumns = []string{"created_at", "updated_at", "title"}
+
+type Video struct {
+	ID          uuid.UUID       `gorm:"type:char(36);primary_key" json:"id"`
+	UserID      uuid.UUID       `gorm:"type:char(36);index;not null" json:"userID"`
+	ChannelID   *uuid.UUID      `gorm:"type:char(36);index" json:"channelID,omitempty"`
+	Title       string          `gorm:"size:255;not null" json:"title"`
+	Description string          `gorm:"type:text" json:"description"`
+	Tags        []string        `gorm:"type:text;serializer:json" json:"tags,omitempty"`
+	ObjectKey   string          `gorm:"size:512" json:"objectKey,omitempty"`
+	Size        int64           `json:"size"`
+	MimeType    string          `gorm:"size:100" json:"mimeType,omitempty"`
+	Checksum    string          `gorm:"size:64" json:"checksum,omitempty"`
+	Status      string          `gorm:"size:20;index;not null;default:pending" json:"status"`
+	Visibility  string          `gorm:"size:20;index;not null;default:public" json:"visibility"`
+	PublishAt   *time.Time      `gorm:"index" json:"publishAt,omitempty"`
+	ThumbnailID *uuid.UUID      `gorm:"type:char(36)" json:"thumbnailID,omitempty"`
+	Duration    float64         `json:"duration,omitempty"` // seconds
+	Width       int             `json:"width,omitempty"`
+	Height      int             `json:"height,omitempty"`
+	FrameRate   float64         `json:"frameRate,omitempty"`
+	VideoCodec  string          `gorm:"size:32" json:"videoCodec,omitempty"`
+	AudioCodec  string          `gorm:"size:32" json:"audioCodec,omitempty"`
+	Bitrate     int             `json:"bitrate,omitempty"` // bits per second
+	Container   string          `gorm:"size:32" json:"container,omitempty"`
+	CreatedAt   time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time       `gorm:"default:CURRENT_TIMESTAMP" json:"updatedAt"`
+	DeletedAt   *gorm.DeletedAt `json:"deletedAt,omitempty"`
+
+	// CommentsDisabled hides the comments of the video and rejects new ones
+	CommentsDisabled bool `gorm:"not null;default:false" json:"commentsDisabled"`
+
+	// the rating counters are only changed together with the ratings, see repository.Rating
+	LikeCount    int64 `gorm:"not null;default:0" json:"likeCount"`
+	DislikeCount int64 `gorm:"not null;default:0" json:"dislikeCount"`
+	// ViewCount lags behind by up to one flush interval, see app.RunViewFlusher
+	ViewCount int64 `gorm:"not null;default:0" json:"viewCount"`
+
+	ThumbnailURL string       `gorm:"-" json:"thumbnailUrl,omitempty"`
+	Thumbnails   []*Thumbnail `gorm:"-" json:"thumbnails,omitempty"`
+	ViewerRating string       `gorm:"-" json:"viewerRating,omitempty"`
+	ResumeAt     *float64     `gorm:"-" json:"resumeAt,omitempty"` // seconds
+}
+
+// IsPublic reports whether the video is public at now, either directly or because its scheduled time has passed
+func (v *Video) IsPublic(now time.Time) bool {
+	switch v.Visibility {
+	case VideoVisibilityPublic:
+		return true
+	case VideoVisibilityScheduled:
+		return v.PublishAt != nil && !v.PublishAt.After(now)
+	}
+	return false
+}
+
+// IsVisibleTo reports whether viewerID may see the video at now. Unlisted videos are visible
+// to anybody who knows their ID; uuid.Nil stands for an anonymous viewer.
+func (v *Video) IsVisibleTo(viewerID uuid.UUID, now time.Time) bool {
+	if viewerID != uuid.Nil && v.UserID == viewerID {
+		return true
+	}
+	return v.Visibility == VideoVisibilityUnlisted || v.IsPublic(now)
+}
 
 type CreateVideoRequest struct {
-    UserID  uuid.UUID `json:"userID" validate:"required,uuid"`
-    Title   string    `json:"title" validate:"required"`
-    Content string    `json:"content"`
+	Title   string `json:"title" validate:"required"`
+	Content string `json:"content"`
+}
+
+type UploadVideoRequest struct {
+	Title       string     `form:"title" json:"title" validate:"required,max=255"`
+	Description string     `form:"description" json:"description"`
+	Visibility  string     `form:"visibility" json:"visibility" validate:"omitempty,oneof=public unlisted private scheduled"`
+	PublishAt   *time.Time `form:"publishAt" json:"publishAt" validate:"required_if=Visibility scheduled"`
+	ChannelID   *uuid.UUID `form:"channelID" json:"channelID"`
+}
+
+type UpdateVisibilityRequest struct {
+	Visibility string     `json:"visibility" validate:"required,oneof=public unlisted private scheduled"`
+	PublishAt  *time.Time `json:"publishAt" validate:"required_if=Visibility scheduled"`
+}
+
+type RecordViewRequest struct {
+	WatchedSeconds float64 `json:"watchedSeconds" validate:"required,gt=0"`
 }
 
 type UpdateVideoRequest struct {
-    Title   string    `json:"title" validate:"required"`
-    Content string    `json:"content" validate:"required"`
-    ID      uuid.UUID `json:"id" validate:"required"`
+	Title   string    `json:"title" validate:"required"`
+	Content string    `json:"content" validate:"required"`
+	ID      uuid.UUID `json:"id" validate:"required"`
+	// Tags replace the tags of the video, leaving them out keeps the current ones
+	Tags []string `json:"tags" validate:"omitempty,max=30,dive,required,max=50"`
 }
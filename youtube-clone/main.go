@@ -1,22 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
 	"github.com/joshua468/youtube-clone/backend/app"
-	"github.com/joshua468/youtube-clone/backend/handlers"
-	"github.com/joshua468/youtube-clone/backend/utils/middlewares"
-	"github.com/joshua468/youtube-clone/backendlogger"
-	"github.com/joshua468/youtube-clone/backend/models"
+	"github.com/joshua468/youtube-clone/backend/handlers/channel"
+	"github.com/joshua468/youtube-clone/backend/handlers/feed"
+	"github.com/joshua468/youtube-clone/backend/handlers/me"
+	"github.com/joshua468/youtube-clone/backend/handlers/playlist"
+	searchhandler "github.com/joshua468/youtube-clone/backend/handlers/search"
+	"github.com/joshua468/youtube-clone/backend/handlers/user"
+	handlers "github.com/joshua468/youtube-clone/backend/handlers/video"
+	"github.com/joshua468/youtube-clone/backend/handlers/wellknown"
 	"github.com/joshua468/youtube-clone/backend/repository"
+	"github.com/joshua468/youtube-clone/backend/search"
+	"github.com/joshua468/youtube-clone/backend/storage"
+	"github.com/joshua468/youtube-clone/backend/transcode"
+	"github.com/joshua468/youtube-clone/backend/utils/middleware"
+	"github.com/joshua468/youtube-clone/backend/utils/models"
 )
 
 func main() {
 	// Initialize logger
-	log := logger.NewLogger()
+	log := zerolog.New(os.Stdout).With().Timestamp().Logger()
 
 	// Load environment variables
 	env := models.NewEnv()
@@ -28,20 +47,108 @@ func main() {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
+	// Only take the client IP from X-Forwarded-For when the request came through one of our proxies,
+	// anyone else could choose the IP views are deduplicated and sessions are recorded with.
+	// TRUSTED_PROXIES is a comma separated list of addresses or CIDRs, none are trusted by default.
+	if err := router.SetTrustedProxies(strings.FieldsFunc(env.TrustedProxies, func(r rune) bool {
+		return r == ',' || r == ' '
+	})); err != nil {
+		log.Fatal().Err(err).Msg("Failed to set the trusted proxies")
+	}
+
 	// Initialize repository
-	store := repository.New(log, env)
+	store := repository.New(log, *env)
+
+	// Initialize blob storage for uploaded media
+	blobStore, err := storage.New(*env)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize blob storage")
+	}
+
+	// Initialize the video search index
+	searchIndex, err := search.New(*env)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize search index")
+	}
+	defer searchIndex.Close()
 
 	// Initialize application
-	application := app.NewApp(store)
+	application := app.New(*env, *store, blobStore, searchIndex, log)
+
+	// Stop on SIGINT or SIGTERM. The background workers are only stopped once the HTTP server has
+	// drained, so whatever the last requests buffered is still written on their way out.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	var background sync.WaitGroup
+
+	// Fill a new search index with the existing videos, or one that fell behind the database
+	background.Go(func() { _, _ = application.SyncSearchIndex(backgroundCtx) })
+
+	// Create the watch later playlists of accounts older than playlists
+	background.Go(func() { _, _ = application.BackfillWatchLater(backgroundCtx) })
+
+	// Expire abandoned resumable uploads
+	background.Go(func() { application.RunUploadJanitor(backgroundCtx, time.Hour) })
+
+	// Publish scheduled videos once their time has come
+	background.Go(func() { application.RunPublishScheduler(backgroundCtx, time.Minute) })
+
+	// Write buffered view counts in batches
+	background.Go(func() { application.RunViewFlusher(backgroundCtx, 10*time.Second) })
+
+	// Count searched queries and rebuild the search suggestions
+	background.Go(func() { application.RunSuggestionRefresher(backgroundCtx, 5*time.Minute) })
+
+	// Load the revoked access tokens before serving, then keep them in sync with other instances
+	if err := application.SyncRevocations(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load revoked tokens")
+	}
+	background.Go(func() { application.RunRevocationSync(backgroundCtx, 30*time.Second) })
+
+	// Start background video processing workers
+	workers, _ := strconv.Atoi(env.TranscodeWorkers)
+	pool := transcode.NewPool(log, repository.NewJob(store), workers)
+	pool.Handle(models.JobTypeTranscode, application.TranscodeVideo)
+	pool.Handle(models.JobTypePackage, application.PackageVideo)
+	pool.Handle(models.JobTypeThumbnails, application.ExtractThumbnails)
+	pool.Handle(models.JobTypeStoryboard, application.GenerateStoryboard)
+	background.Go(func() { pool.Run(backgroundCtx) })
+
+	// Load the JWT signing keys, new keys in the key file are picked up without a restart
+	signingKeys, err := middlewares.LoadKeySet(env)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load JWT signing keys")
+	}
+	background.Go(func() { signingKeys.RunReloader(backgroundCtx, log, time.Minute) })
 
 	// Initialize middleware
-	middleware := middlewares.NewMiddleware()
+	middleware := middlewares.NewMiddleware(*env, application, signingKeys)
 
 	// Initialize user handler
-	handlers.NewUserHandler(router.Group("/api"), log, application, env, middleware)
+	user.NewUserHandler(router.Group("/api"), &log, application, env, *middleware)
 
 	// Initialize video handler
-	handlers.NewVideoHandler(router.Group("/api"), log, application, env, middleware)
+	handlers.NewVideoHandler(router.Group("/api"), &log, application, env, *middleware)
+
+	// Initialize channel handler
+	channel.NewChannelHandler(router.Group("/api"), &log, application, env, *middleware)
+
+	// Initialize feed handler
+	feed.NewFeedHandler(router.Group("/api"), &log, application, env, *middleware)
+
+	// Initialize handler for the signed in user's own data
+	me.NewMeHandler(router.Group("/api"), &log, application, env, *middleware)
+
+	// Initialize playlist handler
+	playlist.NewPlaylistHandler(router.Group("/api"), &log, application, env, *middleware)
+
+	// Initialize search handler
+	searchhandler.NewSearchHandler(router.Group("/api"), &log, application, env, *middleware)
+
+	// Initialize handler for the public keys other services verify our tokens with
+	wellknown.NewWellKnownHandler(router.Group(""), &log, application, env, *middleware)
 
 	// Start HTTP server
 	port := os.Getenv("PORT")
@@ -49,8 +156,24 @@ func main() {
 		port = "8080" // Default port
 	}
 	addr := fmt.Sprintf(":%s", port)
-	log.Info().Str("address", addr).Msg("Starting server")
-	if err := http.ListenAndServe(addr, router); err != nil {
-		log.Fatal().Err(err).Msg("Failed to start HTTP server")
+	server := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		log.Info().Str("address", addr).Msg("Starting server")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal().Err(err).Msg("Failed to start HTTP server")
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info().Msg("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to shut down HTTP server gracefully")
 	}
+
+	// the workers flush their buffers once more when stopped
+	stopBackground()
+	background.Wait()
 }